@@ -0,0 +1,46 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// CartTokenHeader is the metadata header guests send their cart token in.
+const CartTokenHeader = "x-cart-token"
+
+// CartTokenKey holds the cart token of a guest request in the context. It is
+// only set when the request carries no bearer token.
+const CartTokenKey contextKey = "cartToken"
+
+// guestMethods are the methods that can be called with a cart token instead
+// of a bearer token, acting on the guest cart.
+var guestMethods = map[string]bool{
+	"/cart.CartService/GetCart":            true,
+	"/cart.CartService/AddItem":            true,
+	"/cart.CartService/UpdateItemQuantity": true,
+	"/cart.CartService/RemoveItem":         true,
+	"/cart.CartService/ClearCart":          true,
+
+	"/cart.CartService/AcknowledgePriceChanges": true,
+}
+
+// guestCartCreatingMethod can also be called with neither a bearer token nor
+// a cart token, creating a guest cart, so that guests who never add anything
+// leave no cart behind.
+const guestCartCreatingMethod = "/cart.CartService/AddItem"
+
+// ExtractCartTokenFromMetadata returns the cart token sent with the request,
+// if any.
+func ExtractCartTokenFromMetadata(ctx context.Context) (string, bool) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return "", false
+	}
+
+	values := md.Get(CartTokenHeader)
+	if len(values) == 0 || values[0] == "" {
+		return "", false
+	}
+	return values[0], true
+}
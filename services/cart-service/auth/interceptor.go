@@ -3,14 +3,23 @@ package auth
 import (
 	"context"
 
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/userpb"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type contextKey string
 
-const UserIDKey contextKey = "userID"
+const (
+	UserIDKey contextKey = "userID"
+	RoleKey   contextKey = "role"
+	// ImpersonatorIDKey holds the admin's user ID on requests made with an
+	// impersonation token.
+	ImpersonatorIDKey contextKey = "impersonatorID"
+)
 
-func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
+func UnaryAuthInterceptor(secretKey string, userClient userpb.UserServiceClient) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -21,8 +30,24 @@ func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 			return handler(ctx, req)
 		}
 
+		if apiKey, ok := ExtractAPIKeyFromMetadata(ctx); ok {
+			newCtx, err := authenticateAPIKey(ctx, userClient, apiKey, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(newCtx, req)
+		}
+
 		tokenString, err := ExtractTokenFromMetadata(ctx)
 		if err != nil {
+			// Without a bearer token, a guest may still act on their cart,
+			// or start one by adding an item.
+			if cartToken, ok := ExtractCartTokenFromMetadata(ctx); ok && guestMethods[info.FullMethod] {
+				return handler(context.WithValue(ctx, CartTokenKey, cartToken), req)
+			}
+			if info.FullMethod == guestCartCreatingMethod {
+				return handler(ctx, req)
+			}
 			return nil, err
 		}
 
@@ -31,7 +56,19 @@ func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 			return nil, err
 		}
 
+		if err := checkSession(ctx, userClient, claims); err != nil {
+			return nil, err
+		}
+
 		newCtx := context.WithValue(ctx, UserIDKey, claims.UserID)
+		newCtx = context.WithValue(newCtx, RoleKey, claims.Role)
+
+		if claims.ImpersonatorID != 0 {
+			if isDeniedToImpersonators(info.FullMethod) {
+				return nil, status.Errorf(codes.PermissionDenied, "method cannot be called while impersonating a user")
+			}
+			newCtx = context.WithValue(newCtx, ImpersonatorIDKey, claims.ImpersonatorID)
+		}
 
 		return handler(newCtx, req)
 	}
@@ -40,6 +77,7 @@ func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 func isUnauthenticatedMethod(fullMethod string) bool {
 	unauthenticatedMethods := []string{
 		"/grpc.health.v1.Health/Check",
+		"/cart.CartService/GetSharedWishlist",
 	}
 
 	for _, method := range unauthenticatedMethods {
@@ -49,3 +87,20 @@ func isUnauthenticatedMethod(fullMethod string) bool {
 	}
 	return false
 }
+
+// isDeniedToImpersonators reports whether fullMethod may not be called with an
+// impersonation token, so that support staff can see what the user sees
+// without being able to export or erase their data.
+func isDeniedToImpersonators(fullMethod string) bool {
+	deniedMethods := []string{
+		"/cart.CartService/ExportUserData",
+		"/cart.CartService/EraseUserData",
+	}
+
+	for _, method := range deniedMethods {
+		if fullMethod == method {
+			return true
+		}
+	}
+	return false
+}
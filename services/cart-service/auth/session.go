@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkSession asks User Service whether a token is still valid: whether the
+// session it was issued for is still active and no password change has
+// revoked it since, so that both take effect here too. Disabled accounts are
+// refused as well.
+func checkSession(ctx context.Context, userClient userpb.UserServiceClient, claims *AuthClaims) error {
+	_, err := userClient.CheckSession(ctx, &userpb.CheckSessionRequest{
+		SessionId:    claims.ID,
+		UserId:       strconv.Itoa(claims.UserID),
+		TokenVersion: int32(claims.TokenVersion),
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return status.Errorf(codes.Unauthenticated, "token has been revoked")
+		}
+		if status.Code(err) == codes.PermissionDenied {
+			return status.Errorf(codes.PermissionDenied, "account is disabled")
+		}
+		return status.Errorf(codes.Unavailable, "failed to check session: %v", err)
+	}
+	return nil
+}
@@ -19,22 +19,98 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	CartService_AddItem_FullMethodName            = "/cart.CartService/AddItem"
-	CartService_GetCart_FullMethodName            = "/cart.CartService/GetCart"
-	CartService_UpdateItemQuantity_FullMethodName = "/cart.CartService/UpdateItemQuantity"
-	CartService_RemoveItem_FullMethodName         = "/cart.CartService/RemoveItem"
-	CartService_ClearCart_FullMethodName          = "/cart.CartService/ClearCart"
+	CartService_AddItem_FullMethodName                 = "/cart.CartService/AddItem"
+	CartService_GetCart_FullMethodName                 = "/cart.CartService/GetCart"
+	CartService_UpdateItemQuantity_FullMethodName      = "/cart.CartService/UpdateItemQuantity"
+	CartService_RemoveItem_FullMethodName              = "/cart.CartService/RemoveItem"
+	CartService_ClearCart_FullMethodName               = "/cart.CartService/ClearCart"
+	CartService_AcknowledgePriceChanges_FullMethodName = "/cart.CartService/AcknowledgePriceChanges"
+	CartService_ApplyCoupon_FullMethodName             = "/cart.CartService/ApplyCoupon"
+	CartService_RemoveCoupon_FullMethodName            = "/cart.CartService/RemoveCoupon"
+	CartService_GetShippingQuotes_FullMethodName       = "/cart.CartService/GetShippingQuotes"
+	CartService_ListCarts_FullMethodName               = "/cart.CartService/ListCarts"
+	CartService_CreateCart_FullMethodName              = "/cart.CartService/CreateCart"
+	CartService_DeleteCart_FullMethodName              = "/cart.CartService/DeleteCart"
+	CartService_MergeCart_FullMethodName               = "/cart.CartService/MergeCart"
+	CartService_CreateWishlist_FullMethodName          = "/cart.CartService/CreateWishlist"
+	CartService_ListWishlists_FullMethodName           = "/cart.CartService/ListWishlists"
+	CartService_GetWishlist_FullMethodName             = "/cart.CartService/GetWishlist"
+	CartService_RenameWishlist_FullMethodName          = "/cart.CartService/RenameWishlist"
+	CartService_DeleteWishlist_FullMethodName          = "/cart.CartService/DeleteWishlist"
+	CartService_AddWishlistItem_FullMethodName         = "/cart.CartService/AddWishlistItem"
+	CartService_RemoveWishlistItem_FullMethodName      = "/cart.CartService/RemoveWishlistItem"
+	CartService_MoveWishlistItemToCart_FullMethodName  = "/cart.CartService/MoveWishlistItemToCart"
+	CartService_MoveCartItemToWishlist_FullMethodName  = "/cart.CartService/MoveCartItemToWishlist"
+	CartService_ShareWishlist_FullMethodName           = "/cart.CartService/ShareWishlist"
+	CartService_UnshareWishlist_FullMethodName         = "/cart.CartService/UnshareWishlist"
+	CartService_GetSharedWishlist_FullMethodName       = "/cart.CartService/GetSharedWishlist"
+	CartService_ListAbandonedCarts_FullMethodName      = "/cart.CartService/ListAbandonedCarts"
+	CartService_ListAbandonedCartEvents_FullMethodName = "/cart.CartService/ListAbandonedCartEvents"
+	CartService_ExportUserData_FullMethodName          = "/cart.CartService/ExportUserData"
+	CartService_EraseUserData_FullMethodName           = "/cart.CartService/EraseUserData"
 )
 
 // CartServiceClient is the client API for CartService service.
 //
 // For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
 type CartServiceClient interface {
+	// The cart RPCs act on the user's primary cart unless given the cart_id of
+	// another of their carts.
 	AddItem(ctx context.Context, in *AddItemRequest, opts ...grpc.CallOption) (*AddItemResponse, error)
 	GetCart(ctx context.Context, in *GetCartRequest, opts ...grpc.CallOption) (*GetCartResponse, error)
 	UpdateItemQuantity(ctx context.Context, in *UpdateItemQuantityRequest, opts ...grpc.CallOption) (*UpdateItemQuantityResponse, error)
 	RemoveItem(ctx context.Context, in *RemoveItemRequest, opts ...grpc.CallOption) (*RemoveItemResponse, error)
 	ClearCart(ctx context.Context, in *ClearCartRequest, opts ...grpc.CallOption) (*ClearCartResponse, error)
+	// Accepts the prices the shopper was shown for items whose price changed
+	// since they were put in the cart, clearing their PRICE_CHANGED warnings
+	// in GetCart, provided they are still the current prices.
+	AcknowledgePriceChanges(ctx context.Context, in *AcknowledgePriceChangesRequest, opts ...grpc.CallOption) (*AcknowledgePriceChangesResponse, error)
+	// Coupons on the cart of a signed-in user. Order Service decides whether a
+	// coupon applies; GetCart shows the resulting discounts, and the codes are
+	// meant to be passed on to CreateOrder at checkout.
+	ApplyCoupon(ctx context.Context, in *ApplyCouponRequest, opts ...grpc.CallOption) (*ApplyCouponResponse, error)
+	RemoveCoupon(ctx context.Context, in *RemoveCouponRequest, opts ...grpc.CallOption) (*RemoveCouponResponse, error)
+	// Shipping quotes from Order Service for the cart of a signed-in user,
+	// cheapest first. The chosen shipping_method_id is meant to be passed on to
+	// CreateOrder at checkout.
+	GetShippingQuotes(ctx context.Context, in *GetShippingQuotesRequest, opts ...grpc.CallOption) (*GetShippingQuotesResponse, error)
+	// Named carts, which users keep besides their primary cart, such as one
+	// per project or cost centre. The primary cart always exists, cannot be
+	// deleted and is listed first.
+	ListCarts(ctx context.Context, in *ListCartsRequest, opts ...grpc.CallOption) (*ListCartsResponse, error)
+	CreateCart(ctx context.Context, in *CreateCartRequest, opts ...grpc.CallOption) (*CreateCartResponse, error)
+	DeleteCart(ctx context.Context, in *DeleteCartRequest, opts ...grpc.CallOption) (*DeleteCartResponse, error)
+	// Guest carts. AddItem needs no authentication: called without a bearer
+	// token or cart token it creates a guest cart and returns its cart token.
+	// Sending the token in the x-cart-token header instead of a bearer token
+	// lets the cart RPCs above act on the guest cart. MergeCart folds a guest
+	// cart into the signed-in user's cart, typically right after login.
+	MergeCart(ctx context.Context, in *MergeCartRequest, opts ...grpc.CallOption) (*MergeCartResponse, error)
+	// Wishlists let users park products without buying them. A wishlist can
+	// be shared through a link token, which GetSharedWishlist accepts without
+	// authentication.
+	CreateWishlist(ctx context.Context, in *CreateWishlistRequest, opts ...grpc.CallOption) (*CreateWishlistResponse, error)
+	ListWishlists(ctx context.Context, in *ListWishlistsRequest, opts ...grpc.CallOption) (*ListWishlistsResponse, error)
+	GetWishlist(ctx context.Context, in *GetWishlistRequest, opts ...grpc.CallOption) (*GetWishlistResponse, error)
+	RenameWishlist(ctx context.Context, in *RenameWishlistRequest, opts ...grpc.CallOption) (*RenameWishlistResponse, error)
+	DeleteWishlist(ctx context.Context, in *DeleteWishlistRequest, opts ...grpc.CallOption) (*DeleteWishlistResponse, error)
+	AddWishlistItem(ctx context.Context, in *AddWishlistItemRequest, opts ...grpc.CallOption) (*AddWishlistItemResponse, error)
+	RemoveWishlistItem(ctx context.Context, in *RemoveWishlistItemRequest, opts ...grpc.CallOption) (*RemoveWishlistItemResponse, error)
+	MoveWishlistItemToCart(ctx context.Context, in *MoveWishlistItemToCartRequest, opts ...grpc.CallOption) (*MoveWishlistItemToCartResponse, error)
+	MoveCartItemToWishlist(ctx context.Context, in *MoveCartItemToWishlistRequest, opts ...grpc.CallOption) (*MoveCartItemToWishlistResponse, error)
+	ShareWishlist(ctx context.Context, in *ShareWishlistRequest, opts ...grpc.CallOption) (*ShareWishlistResponse, error)
+	UnshareWishlist(ctx context.Context, in *UnshareWishlistRequest, opts ...grpc.CallOption) (*UnshareWishlistResponse, error)
+	GetSharedWishlist(ctx context.Context, in *GetSharedWishlistRequest, opts ...grpc.CallOption) (*GetSharedWishlistResponse, error)
+	// Admin: primary carts of signed-in users that hold items but have not
+	// changed for a while. ListAbandonedCarts queries them as they are now;
+	// ListAbandonedCartEvents returns the event recorded each time a cart
+	// becomes abandoned, oldest first, for consumers to poll.
+	ListAbandonedCarts(ctx context.Context, in *ListAbandonedCartsRequest, opts ...grpc.CallOption) (*ListAbandonedCartsResponse, error)
+	ListAbandonedCartEvents(ctx context.Context, in *ListAbandonedCartEventsRequest, opts ...grpc.CallOption) (*ListAbandonedCartEventsResponse, error)
+	// Internal: called by User Service to serve data-subject requests for the
+	// authenticated user. Not exposed through the API gateway.
+	ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error)
+	EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error)
 }
 
 type cartServiceClient struct {
@@ -95,15 +171,307 @@ func (c *cartServiceClient) ClearCart(ctx context.Context, in *ClearCartRequest,
 	return out, nil
 }
 
+func (c *cartServiceClient) AcknowledgePriceChanges(ctx context.Context, in *AcknowledgePriceChangesRequest, opts ...grpc.CallOption) (*AcknowledgePriceChangesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AcknowledgePriceChangesResponse)
+	err := c.cc.Invoke(ctx, CartService_AcknowledgePriceChanges_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ApplyCoupon(ctx context.Context, in *ApplyCouponRequest, opts ...grpc.CallOption) (*ApplyCouponResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ApplyCouponResponse)
+	err := c.cc.Invoke(ctx, CartService_ApplyCoupon_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) RemoveCoupon(ctx context.Context, in *RemoveCouponRequest, opts ...grpc.CallOption) (*RemoveCouponResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveCouponResponse)
+	err := c.cc.Invoke(ctx, CartService_RemoveCoupon_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) GetShippingQuotes(ctx context.Context, in *GetShippingQuotesRequest, opts ...grpc.CallOption) (*GetShippingQuotesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetShippingQuotesResponse)
+	err := c.cc.Invoke(ctx, CartService_GetShippingQuotes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ListCarts(ctx context.Context, in *ListCartsRequest, opts ...grpc.CallOption) (*ListCartsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListCartsResponse)
+	err := c.cc.Invoke(ctx, CartService_ListCarts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) CreateCart(ctx context.Context, in *CreateCartRequest, opts ...grpc.CallOption) (*CreateCartResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateCartResponse)
+	err := c.cc.Invoke(ctx, CartService_CreateCart_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) DeleteCart(ctx context.Context, in *DeleteCartRequest, opts ...grpc.CallOption) (*DeleteCartResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteCartResponse)
+	err := c.cc.Invoke(ctx, CartService_DeleteCart_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) MergeCart(ctx context.Context, in *MergeCartRequest, opts ...grpc.CallOption) (*MergeCartResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MergeCartResponse)
+	err := c.cc.Invoke(ctx, CartService_MergeCart_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) CreateWishlist(ctx context.Context, in *CreateWishlistRequest, opts ...grpc.CallOption) (*CreateWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CreateWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_CreateWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ListWishlists(ctx context.Context, in *ListWishlistsRequest, opts ...grpc.CallOption) (*ListWishlistsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListWishlistsResponse)
+	err := c.cc.Invoke(ctx, CartService_ListWishlists_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) GetWishlist(ctx context.Context, in *GetWishlistRequest, opts ...grpc.CallOption) (*GetWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_GetWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) RenameWishlist(ctx context.Context, in *RenameWishlistRequest, opts ...grpc.CallOption) (*RenameWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RenameWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_RenameWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) DeleteWishlist(ctx context.Context, in *DeleteWishlistRequest, opts ...grpc.CallOption) (*DeleteWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_DeleteWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) AddWishlistItem(ctx context.Context, in *AddWishlistItemRequest, opts ...grpc.CallOption) (*AddWishlistItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(AddWishlistItemResponse)
+	err := c.cc.Invoke(ctx, CartService_AddWishlistItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) RemoveWishlistItem(ctx context.Context, in *RemoveWishlistItemRequest, opts ...grpc.CallOption) (*RemoveWishlistItemResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RemoveWishlistItemResponse)
+	err := c.cc.Invoke(ctx, CartService_RemoveWishlistItem_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) MoveWishlistItemToCart(ctx context.Context, in *MoveWishlistItemToCartRequest, opts ...grpc.CallOption) (*MoveWishlistItemToCartResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveWishlistItemToCartResponse)
+	err := c.cc.Invoke(ctx, CartService_MoveWishlistItemToCart_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) MoveCartItemToWishlist(ctx context.Context, in *MoveCartItemToWishlistRequest, opts ...grpc.CallOption) (*MoveCartItemToWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(MoveCartItemToWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_MoveCartItemToWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ShareWishlist(ctx context.Context, in *ShareWishlistRequest, opts ...grpc.CallOption) (*ShareWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ShareWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_ShareWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) UnshareWishlist(ctx context.Context, in *UnshareWishlistRequest, opts ...grpc.CallOption) (*UnshareWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(UnshareWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_UnshareWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) GetSharedWishlist(ctx context.Context, in *GetSharedWishlistRequest, opts ...grpc.CallOption) (*GetSharedWishlistResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetSharedWishlistResponse)
+	err := c.cc.Invoke(ctx, CartService_GetSharedWishlist_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ListAbandonedCarts(ctx context.Context, in *ListAbandonedCartsRequest, opts ...grpc.CallOption) (*ListAbandonedCartsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAbandonedCartsResponse)
+	err := c.cc.Invoke(ctx, CartService_ListAbandonedCarts_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ListAbandonedCartEvents(ctx context.Context, in *ListAbandonedCartEventsRequest, opts ...grpc.CallOption) (*ListAbandonedCartEventsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListAbandonedCartEventsResponse)
+	err := c.cc.Invoke(ctx, CartService_ListAbandonedCartEvents_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) ExportUserData(ctx context.Context, in *ExportUserDataRequest, opts ...grpc.CallOption) (*ExportUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ExportUserDataResponse)
+	err := c.cc.Invoke(ctx, CartService_ExportUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cartServiceClient) EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EraseUserDataResponse)
+	err := c.cc.Invoke(ctx, CartService_EraseUserData_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // CartServiceServer is the server API for CartService service.
 // All implementations must embed UnimplementedCartServiceServer
 // for forward compatibility.
 type CartServiceServer interface {
+	// The cart RPCs act on the user's primary cart unless given the cart_id of
+	// another of their carts.
 	AddItem(context.Context, *AddItemRequest) (*AddItemResponse, error)
 	GetCart(context.Context, *GetCartRequest) (*GetCartResponse, error)
 	UpdateItemQuantity(context.Context, *UpdateItemQuantityRequest) (*UpdateItemQuantityResponse, error)
 	RemoveItem(context.Context, *RemoveItemRequest) (*RemoveItemResponse, error)
 	ClearCart(context.Context, *ClearCartRequest) (*ClearCartResponse, error)
+	// Accepts the prices the shopper was shown for items whose price changed
+	// since they were put in the cart, clearing their PRICE_CHANGED warnings
+	// in GetCart, provided they are still the current prices.
+	AcknowledgePriceChanges(context.Context, *AcknowledgePriceChangesRequest) (*AcknowledgePriceChangesResponse, error)
+	// Coupons on the cart of a signed-in user. Order Service decides whether a
+	// coupon applies; GetCart shows the resulting discounts, and the codes are
+	// meant to be passed on to CreateOrder at checkout.
+	ApplyCoupon(context.Context, *ApplyCouponRequest) (*ApplyCouponResponse, error)
+	RemoveCoupon(context.Context, *RemoveCouponRequest) (*RemoveCouponResponse, error)
+	// Shipping quotes from Order Service for the cart of a signed-in user,
+	// cheapest first. The chosen shipping_method_id is meant to be passed on to
+	// CreateOrder at checkout.
+	GetShippingQuotes(context.Context, *GetShippingQuotesRequest) (*GetShippingQuotesResponse, error)
+	// Named carts, which users keep besides their primary cart, such as one
+	// per project or cost centre. The primary cart always exists, cannot be
+	// deleted and is listed first.
+	ListCarts(context.Context, *ListCartsRequest) (*ListCartsResponse, error)
+	CreateCart(context.Context, *CreateCartRequest) (*CreateCartResponse, error)
+	DeleteCart(context.Context, *DeleteCartRequest) (*DeleteCartResponse, error)
+	// Guest carts. AddItem needs no authentication: called without a bearer
+	// token or cart token it creates a guest cart and returns its cart token.
+	// Sending the token in the x-cart-token header instead of a bearer token
+	// lets the cart RPCs above act on the guest cart. MergeCart folds a guest
+	// cart into the signed-in user's cart, typically right after login.
+	MergeCart(context.Context, *MergeCartRequest) (*MergeCartResponse, error)
+	// Wishlists let users park products without buying them. A wishlist can
+	// be shared through a link token, which GetSharedWishlist accepts without
+	// authentication.
+	CreateWishlist(context.Context, *CreateWishlistRequest) (*CreateWishlistResponse, error)
+	ListWishlists(context.Context, *ListWishlistsRequest) (*ListWishlistsResponse, error)
+	GetWishlist(context.Context, *GetWishlistRequest) (*GetWishlistResponse, error)
+	RenameWishlist(context.Context, *RenameWishlistRequest) (*RenameWishlistResponse, error)
+	DeleteWishlist(context.Context, *DeleteWishlistRequest) (*DeleteWishlistResponse, error)
+	AddWishlistItem(context.Context, *AddWishlistItemRequest) (*AddWishlistItemResponse, error)
+	RemoveWishlistItem(context.Context, *RemoveWishlistItemRequest) (*RemoveWishlistItemResponse, error)
+	MoveWishlistItemToCart(context.Context, *MoveWishlistItemToCartRequest) (*MoveWishlistItemToCartResponse, error)
+	MoveCartItemToWishlist(context.Context, *MoveCartItemToWishlistRequest) (*MoveCartItemToWishlistResponse, error)
+	ShareWishlist(context.Context, *ShareWishlistRequest) (*ShareWishlistResponse, error)
+	UnshareWishlist(context.Context, *UnshareWishlistRequest) (*UnshareWishlistResponse, error)
+	GetSharedWishlist(context.Context, *GetSharedWishlistRequest) (*GetSharedWishlistResponse, error)
+	// Admin: primary carts of signed-in users that hold items but have not
+	// changed for a while. ListAbandonedCarts queries them as they are now;
+	// ListAbandonedCartEvents returns the event recorded each time a cart
+	// becomes abandoned, oldest first, for consumers to poll.
+	ListAbandonedCarts(context.Context, *ListAbandonedCartsRequest) (*ListAbandonedCartsResponse, error)
+	ListAbandonedCartEvents(context.Context, *ListAbandonedCartEventsRequest) (*ListAbandonedCartEventsResponse, error)
+	// Internal: called by User Service to serve data-subject requests for the
+	// authenticated user. Not exposed through the API gateway.
+	ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error)
+	EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error)
 	mustEmbedUnimplementedCartServiceServer()
 }
 
@@ -129,6 +497,78 @@ func (UnimplementedCartServiceServer) RemoveItem(context.Context, *RemoveItemReq
 func (UnimplementedCartServiceServer) ClearCart(context.Context, *ClearCartRequest) (*ClearCartResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ClearCart not implemented")
 }
+func (UnimplementedCartServiceServer) AcknowledgePriceChanges(context.Context, *AcknowledgePriceChangesRequest) (*AcknowledgePriceChangesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AcknowledgePriceChanges not implemented")
+}
+func (UnimplementedCartServiceServer) ApplyCoupon(context.Context, *ApplyCouponRequest) (*ApplyCouponResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApplyCoupon not implemented")
+}
+func (UnimplementedCartServiceServer) RemoveCoupon(context.Context, *RemoveCouponRequest) (*RemoveCouponResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveCoupon not implemented")
+}
+func (UnimplementedCartServiceServer) GetShippingQuotes(context.Context, *GetShippingQuotesRequest) (*GetShippingQuotesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetShippingQuotes not implemented")
+}
+func (UnimplementedCartServiceServer) ListCarts(context.Context, *ListCartsRequest) (*ListCartsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListCarts not implemented")
+}
+func (UnimplementedCartServiceServer) CreateCart(context.Context, *CreateCartRequest) (*CreateCartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateCart not implemented")
+}
+func (UnimplementedCartServiceServer) DeleteCart(context.Context, *DeleteCartRequest) (*DeleteCartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteCart not implemented")
+}
+func (UnimplementedCartServiceServer) MergeCart(context.Context, *MergeCartRequest) (*MergeCartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MergeCart not implemented")
+}
+func (UnimplementedCartServiceServer) CreateWishlist(context.Context, *CreateWishlistRequest) (*CreateWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) ListWishlists(context.Context, *ListWishlistsRequest) (*ListWishlistsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListWishlists not implemented")
+}
+func (UnimplementedCartServiceServer) GetWishlist(context.Context, *GetWishlistRequest) (*GetWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) RenameWishlist(context.Context, *RenameWishlistRequest) (*RenameWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RenameWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) DeleteWishlist(context.Context, *DeleteWishlistRequest) (*DeleteWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) AddWishlistItem(context.Context, *AddWishlistItemRequest) (*AddWishlistItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method AddWishlistItem not implemented")
+}
+func (UnimplementedCartServiceServer) RemoveWishlistItem(context.Context, *RemoveWishlistItemRequest) (*RemoveWishlistItemResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RemoveWishlistItem not implemented")
+}
+func (UnimplementedCartServiceServer) MoveWishlistItemToCart(context.Context, *MoveWishlistItemToCartRequest) (*MoveWishlistItemToCartResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveWishlistItemToCart not implemented")
+}
+func (UnimplementedCartServiceServer) MoveCartItemToWishlist(context.Context, *MoveCartItemToWishlistRequest) (*MoveCartItemToWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method MoveCartItemToWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) ShareWishlist(context.Context, *ShareWishlistRequest) (*ShareWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShareWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) UnshareWishlist(context.Context, *UnshareWishlistRequest) (*UnshareWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnshareWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) GetSharedWishlist(context.Context, *GetSharedWishlistRequest) (*GetSharedWishlistResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetSharedWishlist not implemented")
+}
+func (UnimplementedCartServiceServer) ListAbandonedCarts(context.Context, *ListAbandonedCartsRequest) (*ListAbandonedCartsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAbandonedCarts not implemented")
+}
+func (UnimplementedCartServiceServer) ListAbandonedCartEvents(context.Context, *ListAbandonedCartEventsRequest) (*ListAbandonedCartEventsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListAbandonedCartEvents not implemented")
+}
+func (UnimplementedCartServiceServer) ExportUserData(context.Context, *ExportUserDataRequest) (*ExportUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUserData not implemented")
+}
+func (UnimplementedCartServiceServer) EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUserData not implemented")
+}
 func (UnimplementedCartServiceServer) mustEmbedUnimplementedCartServiceServer() {}
 func (UnimplementedCartServiceServer) testEmbeddedByValue()                     {}
 
@@ -240,32 +680,560 @@ func _CartService_ClearCart_Handler(srv interface{}, ctx context.Context, dec fu
 	return interceptor(ctx, in, info, handler)
 }
 
-// CartService_ServiceDesc is the grpc.ServiceDesc for CartService service.
-// It's only intended for direct use with grpc.RegisterService,
-// and not to be introspected or modified (even as a copy)
-var CartService_ServiceDesc = grpc.ServiceDesc{
-	ServiceName: "cart.CartService",
-	HandlerType: (*CartServiceServer)(nil),
-	Methods: []grpc.MethodDesc{
-		{
-			MethodName: "AddItem",
-			Handler:    _CartService_AddItem_Handler,
-		},
-		{
-			MethodName: "GetCart",
-			Handler:    _CartService_GetCart_Handler,
-		},
-		{
-			MethodName: "UpdateItemQuantity",
-			Handler:    _CartService_UpdateItemQuantity_Handler,
-		},
-		{
-			MethodName: "RemoveItem",
-			Handler:    _CartService_RemoveItem_Handler,
-		},
-		{
-			MethodName: "ClearCart",
-			Handler:    _CartService_ClearCart_Handler,
+func _CartService_AcknowledgePriceChanges_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AcknowledgePriceChangesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).AcknowledgePriceChanges(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_AcknowledgePriceChanges_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).AcknowledgePriceChanges(ctx, req.(*AcknowledgePriceChangesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ApplyCoupon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApplyCouponRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ApplyCoupon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ApplyCoupon_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ApplyCoupon(ctx, req.(*ApplyCouponRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_RemoveCoupon_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveCouponRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).RemoveCoupon(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_RemoveCoupon_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).RemoveCoupon(ctx, req.(*RemoveCouponRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_GetShippingQuotes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetShippingQuotesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).GetShippingQuotes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_GetShippingQuotes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).GetShippingQuotes(ctx, req.(*GetShippingQuotesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ListCarts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListCartsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ListCarts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ListCarts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ListCarts(ctx, req.(*ListCartsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_CreateCart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).CreateCart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_CreateCart_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).CreateCart(ctx, req.(*CreateCartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_DeleteCart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).DeleteCart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_DeleteCart_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).DeleteCart(ctx, req.(*DeleteCartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_MergeCart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MergeCartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).MergeCart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_MergeCart_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).MergeCart(ctx, req.(*MergeCartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_CreateWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).CreateWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_CreateWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).CreateWishlist(ctx, req.(*CreateWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ListWishlists_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListWishlistsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ListWishlists(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ListWishlists_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ListWishlists(ctx, req.(*ListWishlistsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_GetWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).GetWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_GetWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).GetWishlist(ctx, req.(*GetWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_RenameWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RenameWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).RenameWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_RenameWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).RenameWishlist(ctx, req.(*RenameWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_DeleteWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).DeleteWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_DeleteWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).DeleteWishlist(ctx, req.(*DeleteWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_AddWishlistItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(AddWishlistItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).AddWishlistItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_AddWishlistItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).AddWishlistItem(ctx, req.(*AddWishlistItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_RemoveWishlistItem_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RemoveWishlistItemRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).RemoveWishlistItem(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_RemoveWishlistItem_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).RemoveWishlistItem(ctx, req.(*RemoveWishlistItemRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_MoveWishlistItemToCart_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveWishlistItemToCartRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).MoveWishlistItemToCart(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_MoveWishlistItemToCart_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).MoveWishlistItemToCart(ctx, req.(*MoveWishlistItemToCartRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_MoveCartItemToWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(MoveCartItemToWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).MoveCartItemToWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_MoveCartItemToWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).MoveCartItemToWishlist(ctx, req.(*MoveCartItemToWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ShareWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShareWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ShareWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ShareWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ShareWishlist(ctx, req.(*ShareWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_UnshareWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnshareWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).UnshareWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_UnshareWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).UnshareWishlist(ctx, req.(*UnshareWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_GetSharedWishlist_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetSharedWishlistRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).GetSharedWishlist(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_GetSharedWishlist_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).GetSharedWishlist(ctx, req.(*GetSharedWishlistRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ListAbandonedCarts_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAbandonedCartsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ListAbandonedCarts(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ListAbandonedCarts_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ListAbandonedCarts(ctx, req.(*ListAbandonedCartsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ListAbandonedCartEvents_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListAbandonedCartEventsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ListAbandonedCartEvents(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ListAbandonedCartEvents_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ListAbandonedCartEvents(ctx, req.(*ListAbandonedCartEventsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_ExportUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).ExportUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_ExportUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).ExportUserData(ctx, req.(*ExportUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CartService_EraseUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CartServiceServer).EraseUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CartService_EraseUserData_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CartServiceServer).EraseUserData(ctx, req.(*EraseUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CartService_ServiceDesc is the grpc.ServiceDesc for CartService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CartService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "cart.CartService",
+	HandlerType: (*CartServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "AddItem",
+			Handler:    _CartService_AddItem_Handler,
+		},
+		{
+			MethodName: "GetCart",
+			Handler:    _CartService_GetCart_Handler,
+		},
+		{
+			MethodName: "UpdateItemQuantity",
+			Handler:    _CartService_UpdateItemQuantity_Handler,
+		},
+		{
+			MethodName: "RemoveItem",
+			Handler:    _CartService_RemoveItem_Handler,
+		},
+		{
+			MethodName: "ClearCart",
+			Handler:    _CartService_ClearCart_Handler,
+		},
+		{
+			MethodName: "AcknowledgePriceChanges",
+			Handler:    _CartService_AcknowledgePriceChanges_Handler,
+		},
+		{
+			MethodName: "ApplyCoupon",
+			Handler:    _CartService_ApplyCoupon_Handler,
+		},
+		{
+			MethodName: "RemoveCoupon",
+			Handler:    _CartService_RemoveCoupon_Handler,
+		},
+		{
+			MethodName: "GetShippingQuotes",
+			Handler:    _CartService_GetShippingQuotes_Handler,
+		},
+		{
+			MethodName: "ListCarts",
+			Handler:    _CartService_ListCarts_Handler,
+		},
+		{
+			MethodName: "CreateCart",
+			Handler:    _CartService_CreateCart_Handler,
+		},
+		{
+			MethodName: "DeleteCart",
+			Handler:    _CartService_DeleteCart_Handler,
+		},
+		{
+			MethodName: "MergeCart",
+			Handler:    _CartService_MergeCart_Handler,
+		},
+		{
+			MethodName: "CreateWishlist",
+			Handler:    _CartService_CreateWishlist_Handler,
+		},
+		{
+			MethodName: "ListWishlists",
+			Handler:    _CartService_ListWishlists_Handler,
+		},
+		{
+			MethodName: "GetWishlist",
+			Handler:    _CartService_GetWishlist_Handler,
+		},
+		{
+			MethodName: "RenameWishlist",
+			Handler:    _CartService_RenameWishlist_Handler,
+		},
+		{
+			MethodName: "DeleteWishlist",
+			Handler:    _CartService_DeleteWishlist_Handler,
+		},
+		{
+			MethodName: "AddWishlistItem",
+			Handler:    _CartService_AddWishlistItem_Handler,
+		},
+		{
+			MethodName: "RemoveWishlistItem",
+			Handler:    _CartService_RemoveWishlistItem_Handler,
+		},
+		{
+			MethodName: "MoveWishlistItemToCart",
+			Handler:    _CartService_MoveWishlistItemToCart_Handler,
+		},
+		{
+			MethodName: "MoveCartItemToWishlist",
+			Handler:    _CartService_MoveCartItemToWishlist_Handler,
+		},
+		{
+			MethodName: "ShareWishlist",
+			Handler:    _CartService_ShareWishlist_Handler,
+		},
+		{
+			MethodName: "UnshareWishlist",
+			Handler:    _CartService_UnshareWishlist_Handler,
+		},
+		{
+			MethodName: "GetSharedWishlist",
+			Handler:    _CartService_GetSharedWishlist_Handler,
+		},
+		{
+			MethodName: "ListAbandonedCarts",
+			Handler:    _CartService_ListAbandonedCarts_Handler,
+		},
+		{
+			MethodName: "ListAbandonedCartEvents",
+			Handler:    _CartService_ListAbandonedCartEvents_Handler,
+		},
+		{
+			MethodName: "ExportUserData",
+			Handler:    _CartService_ExportUserData_Handler,
+		},
+		{
+			MethodName: "EraseUserData",
+			Handler:    _CartService_EraseUserData_Handler,
 		},
 	},
 	Streams:  []grpc.StreamDesc{},
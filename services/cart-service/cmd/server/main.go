@@ -1,9 +1,11 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
 	"strconv"
+	"time"
 
 	consulapi "github.com/hashicorp/consul/api"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
@@ -11,8 +13,12 @@ import (
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/config"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/db"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/handlers"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/sweeper"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/userpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -44,7 +50,6 @@ func main() {
 	if err != nil {
 		log.Fatalf("Failed to create Consul client: %v", err)
 	}
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey)))
 
 	productServiceAddress, err := utils.GetServiceAddress(consulClient, "product-service")
 	if err != nil {
@@ -59,7 +64,71 @@ func main() {
 	defer productConn.Close()
 	productClient := productpb.NewProductServiceClient(productConn)
 
-	cartpb.RegisterCartServiceServer(grpcServer, handlers.NewCartServiceServer(cartRepo, cfg.JWTSecretKey, consulClient, productClient))
+	userServiceAddress, err := utils.GetServiceAddress(consulClient, "user-service")
+	if err != nil {
+		log.Fatalf("Failed to get User Service address: %v", err)
+	}
+
+	userConn, err := grpc.NewClient(userServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to User Service: %v", err)
+	}
+
+	defer userConn.Close()
+	userClient := userpb.NewUserServiceClient(userConn)
+
+	orderServiceAddress, err := utils.GetServiceAddress(consulClient, "order-service")
+	if err != nil {
+		log.Fatalf("Failed to get Order Service address: %v", err)
+	}
+
+	orderConn, err := grpc.NewClient(orderServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to Order Service: %v", err)
+	}
+
+	defer orderConn.Close()
+	orderClient := orderpb.NewOrderServiceClient(orderConn)
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey, userClient)))
+
+	maxItemQuantity, err := strconv.Atoi(cfg.MaxItemQuantity)
+	if err != nil || maxItemQuantity <= 0 {
+		log.Fatalf("Invalid MAX_ITEM_QUANTITY %q", cfg.MaxItemQuantity)
+	}
+
+	maxNamedCarts, err := strconv.Atoi(cfg.MaxNamedCarts)
+	if err != nil || maxNamedCarts < 0 {
+		log.Fatalf("Invalid MAX_NAMED_CARTS %q", cfg.MaxNamedCarts)
+	}
+
+	maxCouponAttempts, err := strconv.Atoi(cfg.MaxCouponAttempts)
+	if err != nil || maxCouponAttempts <= 0 {
+		log.Fatalf("Invalid MAX_COUPON_ATTEMPTS %q", cfg.MaxCouponAttempts)
+	}
+
+	mergeStrategy := models.MergeStrategy(cfg.MergeStrategy)
+	if !mergeStrategy.Valid() {
+		log.Fatalf("Invalid CART_MERGE_STRATEGY %q", cfg.MergeStrategy)
+	}
+
+	abandonedCartAfter := parseDuration("ABANDONED_CART_AFTER", cfg.AbandonedCartAfter)
+
+	cartpb.RegisterCartServiceServer(grpcServer, handlers.NewCartServiceServer(cartRepo, cfg.JWTSecretKey, consulClient, productClient, orderClient, handlers.Config{
+		MaxItemQuantity: int32(maxItemQuantity),
+		MaxNamedCarts:   maxNamedCarts,
+
+		MaxCouponAttempts:   maxCouponAttempts,
+		CouponAttemptWindow: parseDuration("COUPON_ATTEMPT_WINDOW", cfg.CouponAttemptWindow),
+
+		GuestCartDuration: parseDuration("GUEST_CART_DURATION", cfg.GuestCartDuration),
+		MergeStrategy:     mergeStrategy,
+
+		AbandonedCartAfter: abandonedCartAfter,
+	}))
+
+	// expired guest carts and abandoned-cart events
+	go sweeper.New(cartRepo, parseDuration("CART_SWEEP_INTERVAL", cfg.SweepInterval), abandonedCartAfter).Run(context.Background())
 
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
@@ -86,3 +155,11 @@ func main() {
 
 	select {}
 }
+
+func parseDuration(name, value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil || d <= 0 {
+		log.Fatalf("Invalid %s %q", name, value)
+	}
+	return d
+}
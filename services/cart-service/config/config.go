@@ -14,6 +14,18 @@ type Config struct {
 	ConsulAddress string
 	ServiceName   string
 	ServiceHost   string
+
+	MaxItemQuantity string
+	MaxNamedCarts   string
+
+	MaxCouponAttempts   string
+	CouponAttemptWindow string
+
+	GuestCartDuration string
+	MergeStrategy     string
+
+	SweepInterval      string
+	AbandonedCartAfter string
 }
 
 func LoadConfig() *Config {
@@ -28,6 +40,18 @@ func LoadConfig() *Config {
 		ConsulAddress: getEnv("CONSUL_ADDRESS", "consul:8500"),
 		ServiceName:   getEnv("SERVICE_NAME", "user-service"),
 		ServiceHost:   getEnv("SERVICE_HOST", "user-service"),
+
+		MaxItemQuantity: getEnv("MAX_ITEM_QUANTITY", "99"),
+		MaxNamedCarts:   getEnv("MAX_NAMED_CARTS", "20"),
+
+		MaxCouponAttempts:   getEnv("MAX_COUPON_ATTEMPTS", "10"),
+		CouponAttemptWindow: getEnv("COUPON_ATTEMPT_WINDOW", "15m"),
+
+		GuestCartDuration: getEnv("GUEST_CART_DURATION", "720h"),
+		MergeStrategy:     getEnv("CART_MERGE_STRATEGY", "sum"),
+
+		SweepInterval:      getEnv("CART_SWEEP_INTERVAL", "10m"),
+		AbandonedCartAfter: getEnv("ABANDONED_CART_AFTER", "24h"),
 	}
 
 	return config
@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS guest_carts (
+  cart_id SERIAL PRIMARY KEY,
+  token_hash TEXT NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS guest_cart_items (
+  cart_id INT NOT NULL REFERENCES guest_carts (cart_id) ON DELETE CASCADE,
+  product_id INT NOT NULL,
+  quantity INT NOT NULL,
+  PRIMARY KEY (cart_id, product_id)
+);
@@ -0,0 +1,21 @@
+ALTER TABLE cart_items
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+ALTER TABLE guest_cart_items
+  ADD COLUMN IF NOT EXISTS created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP NOT NULL DEFAULT NOW();
+
+CREATE INDEX IF NOT EXISTS idx_guest_carts_expires_at ON guest_carts (expires_at);
+
+-- One event per idle period of a cart: a cart that changes and goes idle again
+-- has a new last_activity_at and gets a new event.
+CREATE TABLE IF NOT EXISTS abandoned_cart_events (
+  event_id BIGSERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  item_count INT NOT NULL,
+  total_quantity INT NOT NULL,
+  last_activity_at TIMESTAMP NOT NULL,
+  detected_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, last_activity_at)
+);
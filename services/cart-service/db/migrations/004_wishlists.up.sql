@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS wishlists (
+  wishlist_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  name TEXT NOT NULL,
+  share_token_hash TEXT UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS wishlist_items (
+  wishlist_id INT NOT NULL REFERENCES wishlists (wishlist_id) ON DELETE CASCADE,
+  product_id INT NOT NULL,
+  quantity INT NOT NULL,
+  added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (wishlist_id, product_id)
+);
@@ -0,0 +1,6 @@
+CREATE TABLE IF NOT EXISTS cart_coupons (
+  user_id INT NOT NULL,
+  code TEXT NOT NULL,
+  added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (user_id, code)
+);
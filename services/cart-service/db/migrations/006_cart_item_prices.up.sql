@@ -0,0 +1,5 @@
+-- The price of a product when it was put in the cart, or when the shopper
+-- last acknowledged a change to it. NULL for items added before prices were
+-- recorded.
+ALTER TABLE cart_items ADD COLUMN IF NOT EXISTS added_price NUMERIC(10, 2);
+ALTER TABLE guest_cart_items ADD COLUMN IF NOT EXISTS added_price NUMERIC(10, 2);
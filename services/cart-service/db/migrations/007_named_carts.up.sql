@@ -0,0 +1,25 @@
+-- Carts a user keeps besides their primary cart, which stays in cart_items.
+CREATE TABLE IF NOT EXISTS carts (
+  cart_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  name TEXT NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (user_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS named_cart_items (
+  cart_id INT NOT NULL REFERENCES carts (cart_id) ON DELETE CASCADE,
+  product_id INT NOT NULL,
+  quantity INT NOT NULL,
+  added_price NUMERIC(10, 2),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (cart_id, product_id)
+);
+
+CREATE TABLE IF NOT EXISTS named_cart_coupons (
+  cart_id INT NOT NULL REFERENCES carts (cart_id) ON DELETE CASCADE,
+  code TEXT NOT NULL,
+  added_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  PRIMARY KEY (cart_id, code)
+);
@@ -0,0 +1,105 @@
+-- Every cart is a row in carts and its items and coupons are keyed by
+-- cart_id: a user's primary cart (is_primary, with an empty name), their
+-- named carts, and guest carts, which have no user and keep their token in
+-- guest_carts.
+ALTER TABLE carts
+  ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT FALSE,
+  ALTER COLUMN user_id DROP NOT NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_carts_primary ON carts (user_id) WHERE is_primary;
+
+DO $$
+BEGIN
+  -- The primary cart's items and coupons were keyed by user_id.
+  IF EXISTS (
+    SELECT 1 FROM information_schema.columns
+    WHERE table_name = 'cart_items' AND column_name = 'user_id'
+  ) THEN
+    INSERT INTO carts (user_id, name, is_primary)
+    SELECT user_id, '', TRUE FROM cart_items
+    UNION
+    SELECT user_id, '', TRUE FROM cart_coupons
+    ON CONFLICT DO NOTHING;
+
+    ALTER TABLE cart_items ADD COLUMN cart_id INT;
+    UPDATE cart_items i SET cart_id = c.cart_id
+    FROM carts c
+    WHERE c.user_id = i.user_id AND c.is_primary;
+    ALTER TABLE cart_items DROP CONSTRAINT cart_items_pkey;
+    ALTER TABLE cart_items DROP COLUMN user_id;
+    ALTER TABLE cart_items ALTER COLUMN cart_id SET NOT NULL;
+    ALTER TABLE cart_items ADD PRIMARY KEY (cart_id, product_id);
+    ALTER TABLE cart_items ADD FOREIGN KEY (cart_id) REFERENCES carts (cart_id) ON DELETE CASCADE;
+
+    ALTER TABLE cart_coupons ADD COLUMN cart_id INT;
+    UPDATE cart_coupons p SET cart_id = c.cart_id
+    FROM carts c
+    WHERE c.user_id = p.user_id AND c.is_primary;
+    ALTER TABLE cart_coupons DROP CONSTRAINT cart_coupons_pkey;
+    ALTER TABLE cart_coupons DROP COLUMN user_id;
+    ALTER TABLE cart_coupons ALTER COLUMN cart_id SET NOT NULL;
+    ALTER TABLE cart_coupons ADD PRIMARY KEY (cart_id, code);
+    ALTER TABLE cart_coupons ADD FOREIGN KEY (cart_id) REFERENCES carts (cart_id) ON DELETE CASCADE;
+  END IF;
+
+  -- Named carts kept their items and coupons in tables of their own.
+  IF to_regclass('named_cart_items') IS NOT NULL THEN
+    INSERT INTO cart_items (cart_id, product_id, quantity, added_price, created_at, updated_at)
+    SELECT cart_id, product_id, quantity, added_price, created_at, updated_at FROM named_cart_items
+    ON CONFLICT DO NOTHING;
+
+    INSERT INTO cart_coupons (cart_id, code, added_at)
+    SELECT cart_id, code, added_at FROM named_cart_coupons
+    ON CONFLICT DO NOTHING;
+  END IF;
+
+  -- Guest carts had IDs of their own. Each gets a row in carts, and its
+  -- guest_carts row is renumbered to match.
+  IF NOT EXISTS (
+    SELECT 1 FROM information_schema.table_constraints
+    WHERE table_name = 'guest_carts' AND constraint_type = 'FOREIGN KEY'
+  ) THEN
+    CREATE TEMP TABLE guest_cart_ids ON COMMIT DROP AS
+    SELECT cart_id AS old_id, nextval('carts_cart_id_seq')::INT AS new_id FROM guest_carts;
+
+    INSERT INTO carts (cart_id, name, created_at)
+    SELECT m.new_id, '', g.created_at
+    FROM guest_cart_ids m
+    JOIN guest_carts g ON g.cart_id = m.old_id;
+
+    INSERT INTO cart_items (cart_id, product_id, quantity, added_price, created_at, updated_at)
+    SELECT m.new_id, i.product_id, i.quantity, i.added_price, i.created_at, i.updated_at
+    FROM guest_cart_items i
+    JOIN guest_cart_ids m ON m.old_id = i.cart_id;
+
+    DELETE FROM guest_cart_items;
+
+    -- Negated first, so that no new ID collides with an old one that has
+    -- not been renumbered yet.
+    UPDATE guest_carts g SET cart_id = -m.new_id
+    FROM guest_cart_ids m
+    WHERE g.cart_id = m.old_id;
+    UPDATE guest_carts SET cart_id = -cart_id;
+
+    ALTER TABLE guest_cart_items DROP CONSTRAINT IF EXISTS guest_cart_items_cart_id_fkey;
+    ALTER TABLE guest_carts ALTER COLUMN cart_id DROP DEFAULT;
+    DROP SEQUENCE IF EXISTS guest_carts_cart_id_seq;
+    ALTER TABLE guest_carts ADD FOREIGN KEY (cart_id) REFERENCES carts (cart_id) ON DELETE CASCADE;
+  END IF;
+END $$;
+
+-- Earlier migrations recreate these tables empty when they are re-applied.
+DROP TABLE IF EXISTS named_cart_items, named_cart_coupons, guest_cart_items;
+
+-- Abandoned-cart events are per cart. Earlier events were all for primary
+-- carts.
+ALTER TABLE abandoned_cart_events
+  ADD COLUMN IF NOT EXISTS cart_id INT,
+  ADD COLUMN IF NOT EXISTS is_primary BOOLEAN NOT NULL DEFAULT TRUE;
+
+UPDATE abandoned_cart_events e SET cart_id = c.cart_id
+FROM carts c
+WHERE e.cart_id IS NULL AND c.user_id = e.user_id AND c.is_primary;
+
+ALTER TABLE abandoned_cart_events DROP CONSTRAINT IF EXISTS abandoned_cart_events_user_id_last_activity_at_key;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_abandoned_cart_events_cart ON abandoned_cart_events (cart_id, last_activity_at);
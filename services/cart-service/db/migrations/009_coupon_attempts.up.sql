@@ -0,0 +1,7 @@
+-- How many coupons each user has tried to apply in the current window, so
+-- that ApplyCoupon cannot be used to guess codes.
+CREATE TABLE IF NOT EXISTS coupon_attempts (
+  user_id INT PRIMARY KEY,
+  attempts INT NOT NULL DEFAULT 1,
+  window_started_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
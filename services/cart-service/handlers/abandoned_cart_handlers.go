@@ -0,0 +1,133 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAbandonedPageSize = 50
+	maxAbandonedPageSize     = 500
+)
+
+func (s *CartServiceServer) ListAbandonedCarts(ctx context.Context, req *cartpb.ListAbandonedCartsRequest) (*cartpb.ListAbandonedCartsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	idleFor := s.cfg.AbandonedCartAfter
+	if req.IdleFor != "" {
+		var err error
+		idleFor, err = time.ParseDuration(req.IdleFor)
+		if err != nil || idleFor <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid idle_for")
+		}
+	}
+
+	pageSize := abandonedPageSize(req.PageSize)
+
+	// The page token is the ID of the last cart on the previous page.
+	afterCartID := 0
+	if req.PageToken != "" {
+		var err error
+		afterCartID, err = strconv.Atoi(req.PageToken)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	// One extra row is fetched to tell whether there is another page.
+	carts, err := s.repo.ListAbandonedCarts(ctx, time.Now().Add(-idleFor), afterCartID, pageSize+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list abandoned carts: %v", err)
+	}
+
+	var nextPageToken string
+	if len(carts) > pageSize {
+		carts = carts[:pageSize]
+		nextPageToken = strconv.Itoa(carts[pageSize-1].CartID)
+	}
+
+	resp := &cartpb.ListAbandonedCartsResponse{
+		NextPageToken: nextPageToken,
+	}
+	for _, cart := range carts {
+		resp.Carts = append(resp.Carts, mapAbandonedCartToProto(cart))
+	}
+
+	return resp, nil
+}
+
+func (s *CartServiceServer) ListAbandonedCartEvents(ctx context.Context, req *cartpb.ListAbandonedCartEventsRequest) (*cartpb.ListAbandonedCartEventsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	var afterEventID int64
+	if req.AfterEventId != "" {
+		var err error
+		afterEventID, err = strconv.ParseInt(req.AfterEventId, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid after_event_id")
+		}
+	}
+
+	events, err := s.repo.ListAbandonedCartEvents(ctx, afterEventID, abandonedPageSize(req.PageSize))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list abandoned cart events: %v", err)
+	}
+
+	resp := &cartpb.ListAbandonedCartEventsResponse{
+		LastEventId: req.AfterEventId,
+	}
+	for _, event := range events {
+		resp.Events = append(resp.Events, &cartpb.AbandonedCartEvent{
+			EventId:    strconv.FormatInt(event.EventID, 10),
+			Cart:       mapAbandonedCartToProto(&event.AbandonedCart),
+			DetectedAt: event.DetectedAt.Format(time.RFC3339),
+		})
+		resp.LastEventId = strconv.FormatInt(event.EventID, 10)
+	}
+
+	return resp, nil
+}
+
+func abandonedPageSize(requested int32) int {
+	pageSize := int(requested)
+	if pageSize <= 0 {
+		pageSize = defaultAbandonedPageSize
+	}
+	if pageSize > maxAbandonedPageSize {
+		pageSize = maxAbandonedPageSize
+	}
+	return pageSize
+}
+
+func mapAbandonedCartToProto(cart *models.AbandonedCart) *cartpb.AbandonedCart {
+	cartProto := &cartpb.AbandonedCart{
+		UserId:         strconv.Itoa(cart.UserID),
+		ItemCount:      cart.ItemCount,
+		TotalQuantity:  cart.TotalQuantity,
+		LastActivityAt: cart.LastActivityAt.Format(time.RFC3339),
+		Primary:        cart.Primary,
+	}
+	if cart.CartID != 0 {
+		cartProto.CartId = strconv.Itoa(cart.CartID)
+	}
+	return cartProto
+}
+
+func requireAdmin(ctx context.Context) error {
+	role, _ := ctx.Value(auth.RoleKey).(string)
+	if role != models.RoleAdmin {
+		return status.Errorf(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
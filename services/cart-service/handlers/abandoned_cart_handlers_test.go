@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func adminContext(userID int) context.Context {
+	return context.WithValue(userContext(userID), auth.RoleKey, models.RoleAdmin)
+}
+
+func TestListAbandonedCartsRequiresAdmin(t *testing.T) {
+	handler := NewCartServiceServer(nil, "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err := handler.ListAbandonedCarts(userContext(1), &cartpb.ListAbandonedCartsRequest{})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, got %v", err)
+	}
+}
+
+func TestListAbandonedCarts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	idle := time.Now().Add(-72 * time.Hour)
+	mock.ExpectQuery("FROM carts c JOIN cart_items").
+		WithArgs(sqlmock.AnyArg(), 0, 3).
+		WillReturnRows(sqlmock.NewRows([]string{"cart_id", "user_id", "is_primary", "count", "sum", "max"}).
+			AddRow(11, 4, true, 2, 3, idle).
+			AddRow(12, 4, false, 1, 1, idle).
+			AddRow(15, 9, true, 5, 8, idle))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.ListAbandonedCarts(adminContext(1), &cartpb.ListAbandonedCartsRequest{IdleFor: "48h", PageSize: 2})
+	if err != nil {
+		t.Fatalf("ListAbandonedCarts failed: %v", err)
+	}
+	if len(resp.Carts) != 2 || resp.NextPageToken != "12" {
+		t.Fatalf("Expected 2 carts and next page token 12, got %+v", resp)
+	}
+	// Named carts are abandoned just like primary carts.
+	if named := resp.Carts[1]; named.CartId != "12" || named.UserId != "4" || named.Primary {
+		t.Errorf("Expected named cart 12 of user 4, got %+v", named)
+	}
+}
+
+func TestListAbandonedCartEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	eventColumns := []string{"event_id", "cart_id", "user_id", "is_primary", "item_count", "total_quantity", "last_activity_at", "detected_at"}
+	now := time.Now()
+	mock.ExpectQuery("FROM abandoned_cart_events").
+		WithArgs(12, defaultAbandonedPageSize).
+		WillReturnRows(sqlmock.NewRows(eventColumns).
+			AddRow(13, 11, 4, true, 2, 3, now.Add(-48*time.Hour), now).
+			AddRow(15, 0, 9, true, 1, 1, now.Add(-30*time.Hour), now))
+	mock.ExpectQuery("FROM abandoned_cart_events").
+		WithArgs(15, defaultAbandonedPageSize).
+		WillReturnRows(sqlmock.NewRows(eventColumns))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.ListAbandonedCartEvents(adminContext(1), &cartpb.ListAbandonedCartEventsRequest{AfterEventId: "12"})
+	if err != nil {
+		t.Fatalf("ListAbandonedCartEvents failed: %v", err)
+	}
+	if len(resp.Events) != 2 || resp.LastEventId != "15" || resp.Events[1].Cart.UserId != "9" {
+		t.Errorf("Unexpected events: %+v", resp)
+	}
+
+	// Polling again with nothing new keeps the cursor where it was.
+	resp, err = handler.ListAbandonedCartEvents(adminContext(1), &cartpb.ListAbandonedCartEventsRequest{AfterEventId: resp.LastEventId})
+	if err != nil {
+		t.Fatalf("ListAbandonedCartEvents failed: %v", err)
+	}
+	if len(resp.Events) != 0 || resp.LastEventId != "15" {
+		t.Errorf("Expected no events and cursor 15, got %+v", resp)
+	}
+}
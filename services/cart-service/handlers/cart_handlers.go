@@ -2,40 +2,81 @@ package handlers
 
 import (
 	"context"
+	"fmt"
+	"log"
 	"strconv"
+	"time"
 
 	consulapi "github.com/hashicorp/consul/api"
-	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
+// Config holds the settings CartServiceServer needs beyond its dependencies.
+type Config struct {
+	// MaxItemQuantity caps the quantity of any one product in a cart.
+	MaxItemQuantity int32
+	// MaxNamedCarts caps how many carts a user can keep besides their
+	// primary cart.
+	MaxNamedCarts int
+
+	// MaxCouponAttempts caps how many coupons a user can try to apply per
+	// CouponAttemptWindow.
+	MaxCouponAttempts   int
+	CouponAttemptWindow time.Duration
+
+	GuestCartDuration time.Duration
+	// MergeStrategy resolves products in both carts when MergeCart is not
+	// given a strategy.
+	MergeStrategy models.MergeStrategy
+
+	// AbandonedCartAfter is how long a cart must be idle to count as
+	// abandoned when ListAbandonedCarts is not given a duration.
+	AbandonedCartAfter time.Duration
+}
+
 type CartServiceServer struct {
 	cartpb.UnimplementedCartServiceServer
 	repo          repository.CartRepository
 	jwtSecretKey  string
 	productClient productpb.ProductServiceClient
+	orderClient   orderpb.OrderServiceClient
 	consulClient  *consulapi.Client
+	cfg           Config
 }
 
-func NewCartServiceServer(repo repository.CartRepository, jwtSecretKey string, consulClient *consulapi.Client, productClient productpb.ProductServiceClient) cartpb.CartServiceServer {
+func NewCartServiceServer(repo repository.CartRepository, jwtSecretKey string, consulClient *consulapi.Client, productClient productpb.ProductServiceClient, orderClient orderpb.OrderServiceClient, cfg Config) cartpb.CartServiceServer {
 	return &CartServiceServer{
 		repo:          repo,
 		jwtSecretKey:  jwtSecretKey,
 		productClient: productClient,
+		orderClient:   orderClient,
 		consulClient:  consulClient,
+		cfg:           cfg,
 	}
 }
 
 func (s *CartServiceServer) AddItem(ctx context.Context, req *cartpb.AddItemRequest) (*cartpb.AddItemResponse, error) {
-	userID, ok := ctx.Value(auth.UserIDKey).(int)
-
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	// A guest without a cart gets one once the item is known to be
+	// available, so that failed calls leave no empty carts behind.
+	newGuest := isNewGuest(ctx)
+
+	var owner models.CartOwner
+	var err error
+	if newGuest {
+		if req.CartId != "" {
+			return nil, status.Errorf(codes.InvalidArgument, "guest carts have no named carts")
+		}
+	} else {
+		owner, err = s.cartOwner(ctx, req.CartId)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	if req.ProductId == "" || req.Quantity <= 0 {
@@ -47,52 +88,126 @@ func (s *CartServiceServer) AddItem(ctx context.Context, req *cartpb.AddItemRequ
 		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
 	}
 
-	item := &models.CartItem{
-		UserID:    userID,
-		ProductID: productID,
-		Quantity:  req.Quantity,
+	// Adding to a product already in the cart increases its quantity, so
+	// the resulting total is what has to be available.
+	var existingQuantity int32
+	if !newGuest {
+		existingQuantity, err = s.repo.GetItemQuantity(ctx, owner, productID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get cart item: %v", err)
+		}
 	}
 
-	err = s.repo.AddItem(ctx, item)
+	product, err := s.checkItemAvailable(ctx, productID, existingQuantity+req.Quantity)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to add item to cart: %v", err)
+		return nil, err
 	}
 
-	return &cartpb.AddItemResponse{
+	resp := &cartpb.AddItemResponse{
 		Message: "Item added to cart successfully",
-	}, nil
+	}
+	if newGuest {
+		cart, cartToken, err := s.createGuestCart(ctx)
+		if err != nil {
+			return nil, err
+		}
+		owner = models.CartOwner{CartID: cart.CartID}
+		resp.CartToken = cartToken
+		resp.ExpiresAt = cart.ExpiresAt.Format(time.RFC3339)
+	}
+
+	// The check above may race with another add of the same product, so the
+	// upsert enforces the same limit.
+	maxQuantity := min(s.cfg.MaxItemQuantity, product.Quantity)
+	err = s.repo.AddItem(ctx, owner, productID, req.Quantity, maxQuantity, product.Price)
+	if err != nil {
+		if err == repository.ErrItemQuantityExceeded {
+			return nil, preconditionFailure(violationQuantityLimit, "product:"+req.ProductId,
+				fmt.Sprintf("at most %d of this product can be in the cart", maxQuantity))
+		}
+		return nil, status.Errorf(codes.Internal, "failed to add item to cart: %v", err)
+	}
+
+	return resp, nil
 }
 
 func (s *CartServiceServer) GetCart(ctx context.Context, req *cartpb.GetCartRequest) (*cartpb.GetCartResponse, error) {
-	userID, ok := ctx.Value(auth.UserIDKey).(int)
-
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	owner, err := s.cartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
 	}
 
-	items, err := s.repo.GetCart(ctx, userID)
+	items, err := s.repo.GetCart(ctx, owner)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
 	}
 
-	var cartItems []*cartpb.CartItem
+	var productIDs []int
+	for _, item := range items {
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	// The cart is still returned when Product Service is down, just without
+	// prices and availability.
+	products, err := s.getProducts(ctx, productIDs)
+	if err != nil {
+		log.Printf("Failed to fetch products for cart of %s: %v", owner, err)
+	}
+
+	resp := &cartpb.GetCartResponse{
+		ProductDetailsAvailable: err == nil,
+	}
+	if !owner.IsGuest() {
+		resp.CartId = strconv.Itoa(owner.CartID)
+	}
 	for _, item := range items {
-		cartItems = append(cartItems, &cartpb.CartItem{
+		cartItem := &cartpb.CartItem{
 			ProductId: strconv.Itoa(item.ProductID),
 			Quantity:  item.Quantity,
-		})
+			AddedAt:   item.CreatedAt.Format(time.RFC3339),
+			UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+		}
+
+		cartItem.AddedPrice = addedPrice(item)
+
+		if product, ok := products[item.ProductID]; ok {
+			cartItem.ProductName = product.Name
+			cartItem.UnitPrice = product.Price
+			cartItem.LineTotal = roundPrice(product.Price * float64(item.Quantity))
+			cartItem.Available = true
+			cartItem.InStock = product.Quantity >= item.Quantity
+			cartItem.PriceChanged = priceChanged(item, product)
+			resp.Subtotal += cartItem.LineTotal
+		}
+
+		resp.Items = append(resp.Items, cartItem)
 	}
 
-	return &cartpb.GetCartResponse{
-		Items: cartItems,
-	}, nil
+	// Warnings need product details; without them nothing is known to have
+	// changed.
+	if resp.ProductDetailsAvailable {
+		resp.Warnings = cartWarnings(resp.Items)
+		for _, item := range resp.Items {
+			resp.RequiresAcknowledgement = resp.RequiresAcknowledgement || item.PriceChanged
+		}
+	}
+	resp.Subtotal = roundPrice(resp.Subtotal)
+	resp.Total = resp.Subtotal
+
+	// Discounts need prices, and guests cannot have coupons.
+	if resp.ProductDetailsAvailable && !owner.IsGuest() {
+		if err := s.addDiscounts(ctx, owner, items, resp); err != nil {
+			log.Printf("Failed to get discounts for cart of %s: %v", owner, err)
+		}
+	}
+
+	return resp, nil
 }
 
 func (s *CartServiceServer) UpdateItemQuantity(ctx context.Context, req *cartpb.UpdateItemQuantityRequest) (*cartpb.UpdateItemQuantityResponse, error) {
-	userID, ok := ctx.Value(auth.UserIDKey).(int)
-
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	owner, err := s.cartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
 	}
 
 	productID, err := strconv.Atoi(req.ProductId)
@@ -104,13 +219,11 @@ func (s *CartServiceServer) UpdateItemQuantity(ctx context.Context, req *cartpb.
 		return nil, status.Errorf(codes.InvalidArgument, "quantity must be greater than zero")
 	}
 
-	item := &models.CartItem{
-		UserID:    userID,
-		ProductID: productID,
-		Quantity:  req.Quantity,
+	if _, err := s.checkItemAvailable(ctx, productID, req.Quantity); err != nil {
+		return nil, err
 	}
 
-	err = s.repo.UpdateItemQuantity(ctx, item)
+	err = s.repo.UpdateItemQuantity(ctx, owner, productID, req.Quantity)
 	if err != nil {
 		if err.Error() == "item not found in cart" {
 			return nil, status.Errorf(codes.NotFound, "item not found in cart")
@@ -124,10 +237,9 @@ func (s *CartServiceServer) UpdateItemQuantity(ctx context.Context, req *cartpb.
 }
 
 func (s *CartServiceServer) RemoveItem(ctx context.Context, req *cartpb.RemoveItemRequest) (*cartpb.RemoveItemResponse, error) {
-	userID, ok := ctx.Value(auth.UserIDKey).(int)
-
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	owner, err := s.cartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
 	}
 
 	productID, err := strconv.Atoi(req.ProductId)
@@ -135,7 +247,7 @@ func (s *CartServiceServer) RemoveItem(ctx context.Context, req *cartpb.RemoveIt
 		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
 	}
 
-	err = s.repo.RemoveItem(ctx, userID, productID)
+	err = s.repo.RemoveItem(ctx, owner, productID)
 	if err != nil {
 		if err.Error() == "item not found in cart" {
 			return nil, status.Errorf(codes.NotFound, "item not found in cart")
@@ -149,13 +261,12 @@ func (s *CartServiceServer) RemoveItem(ctx context.Context, req *cartpb.RemoveIt
 }
 
 func (s *CartServiceServer) ClearCart(ctx context.Context, req *cartpb.ClearCartRequest) (*cartpb.ClearCartResponse, error) {
-	userID, ok := ctx.Value(auth.UserIDKey).(int)
-
-	if !ok {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	owner, err := s.cartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
 	}
 
-	err := s.repo.ClearCart(ctx, userID)
+	err = s.repo.ClearCart(ctx, owner)
 	if err != nil {
 		return nil, status.Errorf(codes.Internal, "failed to clear cart: %v", err)
 	}
@@ -164,3 +275,11 @@ func (s *CartServiceServer) ClearCart(ctx context.Context, req *cartpb.ClearCart
 		Message: "Cart cleared successfully",
 	}, nil
 }
+
+// addedPrice returns the price item was added at, or 0 when not recorded.
+func addedPrice(item *models.CartItem) float64 {
+	if item.AddedPrice == nil {
+		return 0
+	}
+	return *item.AddedPrice
+}
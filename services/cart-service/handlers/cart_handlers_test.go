@@ -0,0 +1,326 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var cartItemColumns = []string{"product_id", "quantity", "added_price", "created_at", "updated_at"}
+
+var testConfig = Config{
+	MaxItemQuantity: 10,
+	MaxNamedCarts:   2,
+
+	MaxCouponAttempts:   3,
+	CouponAttemptWindow: time.Minute,
+
+	GuestCartDuration: time.Hour,
+	MergeStrategy:     models.MergeStrategySum,
+
+	AbandonedCartAfter: 24 * time.Hour,
+}
+
+// fakeProductClient serves product lookups from a fixed set of products.
+type fakeProductClient struct {
+	productpb.ProductServiceClient
+	products map[string]*productpb.Product
+	err      error
+}
+
+func (c *fakeProductClient) GetProducts(ctx context.Context, req *productpb.GetProductsRequest, opts ...grpc.CallOption) (*productpb.GetProductsResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if len(req.ProductIds) > productBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d products can be requested at once", productBatchSize)
+	}
+
+	resp := &productpb.GetProductsResponse{}
+	for _, id := range req.ProductIds {
+		if product, ok := c.products[id]; ok {
+			resp.Products = append(resp.Products, product)
+		}
+	}
+	return resp, nil
+}
+
+func (c *fakeProductClient) GetProduct(ctx context.Context, req *productpb.GetProductRequest, opts ...grpc.CallOption) (*productpb.GetProductResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	product, ok := c.products[req.ProductId]
+	if !ok {
+		return nil, status.Errorf(codes.NotFound, "product not found")
+	}
+	return &productpb.GetProductResponse{Product: product}, nil
+}
+
+func userContext(userID int) context.Context {
+	return context.WithValue(context.Background(), auth.UserIDKey, userID)
+}
+
+var cartColumns = []string{"cart_id", "user_id", "name", "is_primary", "created_at"}
+
+// primaryCartID is the ID of the primary cart of every user in these tests.
+const primaryCartID = 100
+
+// expectPrimaryCart expects the lookup of the primary cart of userID.
+func expectPrimaryCart(mock sqlmock.Sqlmock, userID int) {
+	mock.ExpectQuery("FROM carts WHERE user_id = \\$1 AND is_primary").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(cartColumns).AddRow(primaryCartID, userID, "", true, time.Now()))
+}
+
+func TestGetCartEnrichesItems(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).
+			AddRow(10, 3, nil, now, now).
+			AddRow(11, 1, nil, now, now).
+			AddRow(12, 5, nil, now, now))
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Mug", Price: 4.99, Quantity: 100},
+		"12": {ProductId: "12", Name: "Lamp", Price: 20, Quantity: 2},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.GetCart(userContext(1), &cartpb.GetCartRequest{})
+	if err != nil {
+		t.Fatalf("GetCart failed: %v", err)
+	}
+
+	if !resp.ProductDetailsAvailable || len(resp.Items) != 3 {
+		t.Fatalf("Unexpected response: %+v", resp)
+	}
+	mug, missing, lamp := resp.Items[0], resp.Items[1], resp.Items[2]
+	if mug.ProductName != "Mug" || mug.LineTotal != 14.97 || !mug.Available || !mug.InStock {
+		t.Errorf("Unexpected mug line: %+v", mug)
+	}
+	if missing.Available || missing.LineTotal != 0 {
+		t.Errorf("Expected deleted product to be unavailable: %+v", missing)
+	}
+	if !lamp.Available || lamp.InStock {
+		t.Errorf("Expected lamp to be short on stock: %+v", lamp)
+	}
+	if resp.Subtotal != 114.97 {
+		t.Errorf("Expected subtotal 114.97, got %v", resp.Subtotal)
+	}
+}
+
+func TestGetCartWithoutProductService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(10, 3, nil, now, now))
+
+	productClient := &fakeProductClient{err: errors.New("connection refused")}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.GetCart(userContext(1), &cartpb.GetCartRequest{})
+	if err != nil {
+		t.Fatalf("Expected the cart despite Product Service being down, got %v", err)
+	}
+	if resp.ProductDetailsAvailable || len(resp.Items) != 1 || resp.Items[0].Quantity != 3 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+}
+
+// preconditionViolation returns the type of the single precondition
+// violation attached to err.
+func preconditionViolation(t *testing.T, err error) string {
+	t.Helper()
+
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition || len(st.Details()) != 1 {
+		t.Fatalf("Expected FailedPrecondition with details, got %v", err)
+	}
+	return st.Details()[0].(*errdetails.PreconditionFailure).Violations[0].Type
+}
+
+func TestAddItemChecksStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 5},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "10", Quantity: 3})
+	if violation := preconditionViolation(t, err); violation != violationInsufficientStock {
+		t.Errorf("Expected %s, got %s", violationInsufficientStock, violation)
+	}
+
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 99).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}))
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "99", Quantity: 1})
+	if violation := preconditionViolation(t, err); violation != violationProductNotFound {
+		t.Errorf("Expected %s, got %s", violationProductNotFound, violation)
+	}
+
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO cart_items .* ON CONFLICT").
+		WithArgs(primaryCartID, 10, 2, 20.0, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "10", Quantity: 2})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+
+	// A concurrent add filled the cart after the quantity was read, so the
+	// capped upsert changes nothing.
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	mock.ExpectExec("INSERT INTO cart_items .* ON CONFLICT").
+		WithArgs(primaryCartID, 10, 2, 20.0, 5).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "10", Quantity: 2})
+	if violation := preconditionViolation(t, err); violation != violationQuantityLimit {
+		t.Errorf("Expected %s, got %s", violationQuantityLimit, violation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetProductsBatches(t *testing.T) {
+	products := make(map[string]*productpb.Product)
+	var productIDs []int
+	for i := 1; i <= 2*productBatchSize+1; i++ {
+		id := strconv.Itoa(i)
+		products[id] = &productpb.Product{ProductId: id, Price: 1, Quantity: 1}
+		productIDs = append(productIDs, i)
+	}
+	handler := NewCartServiceServer(nil, "secret", nil, &fakeProductClient{products: products}, &fakeOrderClient{}, testConfig).(*CartServiceServer)
+
+	got, err := handler.getProducts(context.Background(), productIDs)
+	if err != nil {
+		t.Fatalf("getProducts failed: %v", err)
+	}
+	if len(got) != len(productIDs) {
+		t.Errorf("Expected %d products, got %d", len(productIDs), len(got))
+	}
+}
+
+func TestUpdateItemQuantityLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrimaryCart(mock, 1)
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Mug", Price: 4.99, Quantity: 100},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.UpdateItemQuantity(userContext(1), &cartpb.UpdateItemQuantityRequest{ProductId: "10", Quantity: 11})
+	if violation := preconditionViolation(t, err); violation != violationQuantityLimit {
+		t.Errorf("Expected %s, got %s", violationQuantityLimit, violation)
+	}
+}
+
+func TestAddItemWithoutProductService(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}))
+
+	productClient := &fakeProductClient{err: errors.New("connection refused")}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "10", Quantity: 1})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable, got %v", err)
+	}
+}
+
+// fakeOrderClient previews discounts and quotes shipping with fixed
+// responses.
+type fakeOrderClient struct {
+	orderpb.OrderServiceClient
+	preview *orderpb.PreviewDiscountsResponse
+	quotes  *orderpb.GetShippingQuotesResponse
+	err     error
+
+	quoteRequest *orderpb.GetShippingQuotesRequest
+}
+
+func (c *fakeOrderClient) PreviewDiscounts(ctx context.Context, req *orderpb.PreviewDiscountsRequest, opts ...grpc.CallOption) (*orderpb.PreviewDiscountsResponse, error) {
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.preview == nil {
+		return &orderpb.PreviewDiscountsResponse{}, nil
+	}
+	return c.preview, nil
+}
+
+func (c *fakeOrderClient) GetShippingQuotes(ctx context.Context, req *orderpb.GetShippingQuotesRequest, opts ...grpc.CallOption) (*orderpb.GetShippingQuotesResponse, error) {
+	c.quoteRequest = req
+	if c.err != nil {
+		return nil, c.err
+	}
+	if c.quotes == nil {
+		return &orderpb.GetShippingQuotesResponse{}, nil
+	}
+	return c.quotes, nil
+}
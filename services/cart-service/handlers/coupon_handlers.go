@@ -0,0 +1,168 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// orderLookupTimeout bounds calls to Order Service, for the same reason as
+// productLookupTimeout.
+const orderLookupTimeout = 2 * time.Second
+
+const violationCouponRejected = "COUPON_REJECTED"
+
+// couponRejectedDescription is given for every coupon that cannot be applied,
+// so that ApplyCoupon does not tell which codes exist.
+const couponRejectedDescription = "the coupon cannot be applied to this cart"
+
+func (s *CartServiceServer) ApplyCoupon(ctx context.Context, req *cartpb.ApplyCouponRequest) (*cartpb.ApplyCouponResponse, error) {
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	code := normaliseCouponCode(req.Code)
+	if code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	attempts, err := s.repo.RecordCouponAttempt(ctx, owner.UserID, s.cfg.CouponAttemptWindow)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record coupon attempt: %v", err)
+	}
+	if attempts > s.cfg.MaxCouponAttempts {
+		return nil, status.Errorf(codes.ResourceExhausted, "too many coupon attempts, try again later")
+	}
+
+	items, err := s.repo.GetCart(ctx, owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+	}
+	if len(items) == 0 {
+		return nil, preconditionFailure(violationCouponRejected, "coupon:"+code, "the cart is empty")
+	}
+
+	couponCodes, err := s.repo.ListCartCoupons(ctx, owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get coupons: %v", err)
+	}
+
+	// The coupon is previewed together with the ones already applied, so it
+	// is only accepted if it actually changes the discount.
+	preview, err := s.previewDiscounts(ctx, items, append(couponCodes, code))
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to check coupon: %v", err)
+	}
+	for _, rejected := range preview.RejectedCoupons {
+		if rejected.Code == code {
+			return nil, preconditionFailure(violationCouponRejected, "coupon:"+code, couponRejectedDescription)
+		}
+	}
+
+	if err := s.repo.AddCartCoupon(ctx, owner, code); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to apply coupon: %v", err)
+	}
+
+	return &cartpb.ApplyCouponResponse{
+		Message:       "Coupon applied successfully",
+		Discounts:     mapDiscountsToProto(preview.Discounts),
+		DiscountTotal: preview.DiscountAmount,
+	}, nil
+}
+
+func (s *CartServiceServer) RemoveCoupon(ctx context.Context, req *cartpb.RemoveCouponRequest) (*cartpb.RemoveCouponResponse, error) {
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RemoveCartCoupon(ctx, owner, normaliseCouponCode(req.Code))
+	if err != nil {
+		if err == repository.ErrCouponNotFound {
+			return nil, status.Errorf(codes.NotFound, "coupon not found in cart")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to remove coupon: %v", err)
+	}
+
+	return &cartpb.RemoveCouponResponse{
+		Message: "Coupon removed successfully",
+	}, nil
+}
+
+// addDiscounts fills in the coupons and discounts of a user's cart. The cart
+// is still returned when Order Service is down, just at its subtotal.
+func (s *CartServiceServer) addDiscounts(ctx context.Context, owner models.CartOwner, items []*models.CartItem, resp *cartpb.GetCartResponse) error {
+	codes, err := s.repo.ListCartCoupons(ctx, owner)
+	if err != nil {
+		return err
+	}
+	resp.CouponCodes = codes
+
+	if len(items) == 0 {
+		resp.DiscountsAvailable = true
+		return nil
+	}
+
+	preview, err := s.previewDiscounts(ctx, items, codes)
+	if err != nil {
+		return err
+	}
+
+	resp.Discounts = mapDiscountsToProto(preview.Discounts)
+	resp.DiscountTotal = preview.DiscountAmount
+	resp.Total = roundPrice(resp.Subtotal - preview.DiscountAmount)
+	resp.DiscountsAvailable = true
+	for _, rejected := range preview.RejectedCoupons {
+		resp.RejectedCoupons = append(resp.RejectedCoupons, &cartpb.RejectedCoupon{
+			Code:   rejected.Code,
+			Reason: rejected.Reason,
+		})
+	}
+	return nil
+}
+
+// previewDiscounts asks Order Service what the items would be discounted by
+// with the given coupons, on behalf of the caller.
+func (s *CartServiceServer) previewDiscounts(ctx context.Context, items []*models.CartItem, codes []string) (*orderpb.PreviewDiscountsResponse, error) {
+	req := &orderpb.PreviewDiscountsRequest{
+		CouponCodes: codes,
+	}
+	for _, item := range items {
+		req.Items = append(req.Items, &orderpb.OrderItem{
+			ProductId: strconv.Itoa(item.ProductID),
+			Quantity:  item.Quantity,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(auth.ForwardAuthorization(ctx), orderLookupTimeout)
+	defer cancel()
+
+	return s.orderClient.PreviewDiscounts(ctx, req)
+}
+
+func mapDiscountsToProto(discounts []*orderpb.Discount) []*cartpb.CartDiscount {
+	var discountProtos []*cartpb.CartDiscount
+	for _, discount := range discounts {
+		discountProtos = append(discountProtos, &cartpb.CartDiscount{
+			Code:        discount.Code,
+			Description: discount.Description,
+			Amount:      discount.Amount,
+		})
+	}
+	return discountProtos
+}
+
+// normaliseCouponCode matches how Order Service stores coupon codes.
+func normaliseCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
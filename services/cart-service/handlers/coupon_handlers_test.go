@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestApplyCouponRejected(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	expectCouponAttempt(mock, 1, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(10, 1, nil, now, now))
+	mock.ExpectQuery("SELECT code FROM cart_coupons").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}))
+
+	orderClient := &fakeOrderClient{preview: &orderpb.PreviewDiscountsResponse{
+		RejectedCoupons: []*orderpb.RejectedCoupon{{Code: "SAVE10", Reason: "coupon does not exist"}},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, orderClient, testConfig)
+
+	_, err = handler.ApplyCoupon(userContext(1), &cartpb.ApplyCouponRequest{Code: " save10 "})
+	if violation := preconditionViolation(t, err); violation != violationCouponRejected {
+		t.Errorf("Expected %s, got %s", violationCouponRejected, violation)
+	}
+	// Unknown codes are rejected like any other, so they cannot be told apart.
+	if st, _ := status.FromError(err); st.Message() != couponRejectedDescription {
+		t.Errorf("Expected the generic rejection, got %q", st.Message())
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestApplyCouponRateLimited(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrimaryCart(mock, 1)
+	expectCouponAttempt(mock, 1, testConfig.MaxCouponAttempts+1)
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.ApplyCoupon(userContext(1), &cartpb.ApplyCouponRequest{Code: "SAVE10"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// expectCouponAttempt expects an attempt by userID to apply a coupon to be
+// counted as their attempts-th.
+func expectCouponAttempt(mock sqlmock.Sqlmock, userID, attempts int) {
+	mock.ExpectQuery("INSERT INTO coupon_attempts").
+		WithArgs(userID, testConfig.CouponAttemptWindow.Seconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"attempts"}).AddRow(attempts))
+}
+
+func TestApplyCoupon(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	expectCouponAttempt(mock, 1, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(10, 1, nil, now, now))
+	mock.ExpectQuery("SELECT code FROM cart_coupons").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}))
+	mock.ExpectExec("INSERT INTO cart_coupons").
+		WithArgs(primaryCartID, "SAVE10").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	orderClient := &fakeOrderClient{preview: &orderpb.PreviewDiscountsResponse{
+		DiscountAmount: 2,
+		Discounts:      []*orderpb.Discount{{Code: "SAVE10", Amount: 2}},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, orderClient, testConfig)
+
+	resp, err := handler.ApplyCoupon(userContext(1), &cartpb.ApplyCouponRequest{Code: "save10"})
+	if err != nil {
+		t.Fatalf("ApplyCoupon failed: %v", err)
+	}
+	if resp.DiscountTotal != 2 || len(resp.Discounts) != 1 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetCartWithDiscounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(10, 2, nil, now, now))
+	mock.ExpectQuery("SELECT code FROM cart_coupons").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}).AddRow("SAVE10").AddRow("OLD"))
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 5},
+	}}
+	orderClient := &fakeOrderClient{preview: &orderpb.PreviewDiscountsResponse{
+		DiscountAmount:  4,
+		Discounts:       []*orderpb.Discount{{Code: "SAVE10", Amount: 4}},
+		RejectedCoupons: []*orderpb.RejectedCoupon{{Code: "OLD", Reason: "promotion has ended"}},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, orderClient, testConfig)
+
+	resp, err := handler.GetCart(userContext(1), &cartpb.GetCartRequest{})
+	if err != nil {
+		t.Fatalf("GetCart failed: %v", err)
+	}
+	if !resp.DiscountsAvailable || resp.Subtotal != 40 || resp.DiscountTotal != 4 || resp.Total != 36 {
+		t.Errorf("Unexpected totals: %+v", resp)
+	}
+	if len(resp.CouponCodes) != 2 || len(resp.RejectedCoupons) != 1 {
+		t.Errorf("Unexpected coupons: %+v", resp)
+	}
+}
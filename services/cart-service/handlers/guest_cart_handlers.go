@@ -0,0 +1,117 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// cartOwner returns the cart a request acts on: the signed-in user's primary
+// cart or their cart cartID, or the guest cart whose token the request
+// carries.
+func (s *CartServiceServer) cartOwner(ctx context.Context, cartID string) (models.CartOwner, error) {
+	if _, ok := ctx.Value(auth.UserIDKey).(int); ok {
+		return s.userCartOwner(ctx, cartID)
+	}
+
+	cartToken, ok := ctx.Value(auth.CartTokenKey).(string)
+	if !ok {
+		return models.CartOwner{}, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if cartID != "" {
+		return models.CartOwner{}, status.Errorf(codes.InvalidArgument, "guest carts have no named carts")
+	}
+
+	cart, err := s.getGuestCart(ctx, cartToken)
+	if err != nil {
+		return models.CartOwner{}, err
+	}
+	return models.CartOwner{CartID: cart.CartID}, nil
+}
+
+func (s *CartServiceServer) getGuestCart(ctx context.Context, cartToken string) (*models.GuestCart, error) {
+	cart, err := s.repo.GetGuestCartByToken(ctx, utils.HashToken(cartToken))
+	if err != nil {
+		if err == repository.ErrGuestCartNotFound {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired cart token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get guest cart: %v", err)
+	}
+	return cart, nil
+}
+
+// isNewGuest reports whether the request carries neither a bearer token nor
+// a cart token, which only AddItem accepts.
+func isNewGuest(ctx context.Context) bool {
+	_, isUser := ctx.Value(auth.UserIDKey).(int)
+	_, isGuest := ctx.Value(auth.CartTokenKey).(string)
+	return !isUser && !isGuest
+}
+
+// createGuestCart creates a guest cart and returns it along with the cart
+// token that identifies it.
+func (s *CartServiceServer) createGuestCart(ctx context.Context) (*models.GuestCart, string, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "failed to generate cart token")
+	}
+
+	cart := &models.GuestCart{
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.GuestCartDuration),
+	}
+
+	err = s.repo.CreateGuestCart(ctx, cart)
+	if err != nil {
+		return nil, "", status.Errorf(codes.Internal, "failed to create guest cart: %v", err)
+	}
+	return cart, token, nil
+}
+
+// MergeCart moves the items of a guest cart into the caller's cart and
+// deletes the guest cart, so the token cannot be merged twice.
+func (s *CartServiceServer) MergeCart(ctx context.Context, req *cartpb.MergeCartRequest) (*cartpb.MergeCartResponse, error) {
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.CartToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "cart token is required")
+	}
+
+	strategy := s.cfg.MergeStrategy
+	if req.ConflictStrategy != "" {
+		strategy = models.MergeStrategy(req.ConflictStrategy)
+		if !strategy.Valid() {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid conflict strategy %q", req.ConflictStrategy)
+		}
+	}
+
+	cart, err := s.getGuestCart(ctx, req.CartToken)
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return nil, status.Errorf(codes.NotFound, "guest cart not found or expired")
+		}
+		return nil, err
+	}
+
+	result, err := s.repo.MergeGuestCart(ctx, cart.CartID, owner, strategy, s.cfg.MaxItemQuantity)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to merge cart: %v", err)
+	}
+
+	return &cartpb.MergeCartResponse{
+		Message:           "Cart merged successfully",
+		ItemsAdded:        int32(result.ItemsAdded),
+		ConflictsResolved: int32(result.Conflicts),
+	}, nil
+}
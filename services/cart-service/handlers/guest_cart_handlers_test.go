@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var guestCartColumns = []string{"cart_id", "token_hash", "created_at", "expires_at"}
+
+func guestContext(cartToken string) context.Context {
+	return context.WithValue(context.Background(), auth.CartTokenKey, cartToken)
+}
+
+func TestGuestCartAddItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 5},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	mock.ExpectQuery("FROM guest_carts").
+		WithArgs(utils.HashToken("guest-token")).
+		WillReturnRows(sqlmock.NewRows(guestCartColumns).AddRow(4, utils.HashToken("guest-token"), time.Now(), time.Now().Add(time.Hour)))
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(4, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}))
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(4, 10, 2, 20.0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	_, err = handler.AddItem(guestContext("guest-token"), &cartpb.AddItemRequest{ProductId: "10", Quantity: 2})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGuestCartCreatedByFirstAddItem(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 5},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	// An unavailable product creates no cart.
+	_, err = handler.AddItem(context.Background(), &cartpb.AddItemRequest{ProductId: "10", Quantity: 6})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition, got %v", err)
+	}
+
+	mock.ExpectQuery("INSERT INTO guest_carts").
+		WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"cart_id", "created_at"}).AddRow(4, time.Now()))
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(4, 10, 2, 20.0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := handler.AddItem(context.Background(), &cartpb.AddItemRequest{ProductId: "10", Quantity: 2})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if resp.CartToken == "" || resp.ExpiresAt == "" {
+		t.Errorf("Expected a cart token and expiry, got %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGuestCartExpiredToken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM guest_carts").
+		WithArgs(utils.HashToken("expired-token")).
+		WillReturnRows(sqlmock.NewRows(guestCartColumns))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.GetCart(guestContext("expired-token"), &cartpb.GetCartRequest{})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestMergeCart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("FROM guest_carts").
+		WithArgs(utils.HashToken("guest-token")).
+		WillReturnRows(sqlmock.NewRows(guestCartColumns).AddRow(4, utils.HashToken("guest-token"), time.Now(), time.Now().Add(time.Hour)))
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM cart_items").
+		WithArgs(4).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "quantity", "added_price"}).AddRow(10, 8, 20.0).AddRow(11, 1, 15.5))
+	mock.ExpectExec("DELETE FROM carts").
+		WithArgs(4).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO cart_items .* ON CONFLICT \\(cart_id, product_id\\) DO NOTHING").
+		WithArgs(primaryCartID, 10, 8, 20.0).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	// 3 + 8 is capped at the maximum quantity of 10.
+	mock.ExpectExec("UPDATE cart_items SET quantity").
+		WithArgs(10, primaryCartID, 10).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(primaryCartID, 11, 1, 15.5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.MergeCart(userContext(1), &cartpb.MergeCartRequest{CartToken: "guest-token"})
+	if err != nil {
+		t.Fatalf("MergeCart failed: %v", err)
+	}
+	if resp.ItemsAdded != 1 || resp.ConflictsResolved != 1 {
+		t.Errorf("Expected 1 item added and 1 conflict, got %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMergeCartInvalidStrategy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	expectPrimaryCart(mock, 1)
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.MergeCart(userContext(1), &cartpb.MergeCartRequest{CartToken: "guest-token", ConflictStrategy: "newest"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
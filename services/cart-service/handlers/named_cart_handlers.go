@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxCartNameLength = 100
+
+	violationCartLimit = "CART_LIMIT_EXCEEDED"
+
+	// primaryCartName is what ListCarts calls the primary cart, which has no
+	// name of its own.
+	primaryCartName = "Primary"
+)
+
+// userCartOwner returns the signed-in user's primary cart, or their cart
+// cartID.
+func (s *CartServiceServer) userCartOwner(ctx context.Context, cartID string) (models.CartOwner, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return models.CartOwner{}, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	cart, err := s.getUserCart(ctx, cartID, userID)
+	if err != nil {
+		return models.CartOwner{}, err
+	}
+	return cart.Owner(), nil
+}
+
+// getUserCart returns the user's primary cart when cartIDParam is empty, and
+// otherwise their cart with that ID.
+func (s *CartServiceServer) getUserCart(ctx context.Context, cartIDParam string, userID int) (*models.Cart, error) {
+	if cartIDParam == "" {
+		cart, err := s.repo.GetPrimaryCart(ctx, userID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+		}
+		return cart, nil
+	}
+
+	cartID, err := strconv.Atoi(cartIDParam)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid cart ID")
+	}
+
+	cart, err := s.repo.GetUserCart(ctx, cartID, userID)
+	if err != nil {
+		if err == repository.ErrCartNotFound {
+			return nil, status.Errorf(codes.NotFound, "cart not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+	}
+	return cart, nil
+}
+
+// ListCarts returns the caller's primary cart followed by their named carts,
+// without items; use GetCart for those.
+func (s *CartServiceServer) ListCarts(ctx context.Context, req *cartpb.ListCartsRequest) (*cartpb.ListCartsResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	// Creates the primary cart if the user has never used it, so that it
+	// is always listed.
+	if _, err := s.repo.GetPrimaryCart(ctx, userID); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+	}
+
+	carts, err := s.repo.ListCarts(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list carts: %v", err)
+	}
+
+	resp := &cartpb.ListCartsResponse{}
+	for _, cart := range carts {
+		resp.Carts = append(resp.Carts, mapCartToProto(cart))
+	}
+
+	return resp, nil
+}
+
+func (s *CartServiceServer) CreateCart(ctx context.Context, req *cartpb.CreateCartRequest) (*cartpb.CreateCartResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "cart name is required")
+	}
+	if utf8.RuneCountInString(name) > maxCartNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "cart name must be at most %d characters", maxCartNameLength)
+	}
+
+	cart := &models.Cart{
+		UserID: userID,
+		Name:   name,
+	}
+
+	err := s.repo.CreateNamedCart(ctx, cart, s.cfg.MaxNamedCarts)
+	if err != nil {
+		if err == repository.ErrCartNameTaken {
+			return nil, status.Errorf(codes.AlreadyExists, "a cart with this name already exists")
+		}
+		if err == repository.ErrTooManyCarts {
+			return nil, preconditionFailure(violationCartLimit, "user:"+strconv.Itoa(userID),
+				fmt.Sprintf("at most %d named carts can be kept", s.cfg.MaxNamedCarts))
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create cart: %v", err)
+	}
+
+	return &cartpb.CreateCartResponse{
+		Cart: mapCartToProto(cart),
+	}, nil
+}
+
+// DeleteCart deletes a named cart with its items and coupons. The primary
+// cart can only be cleared.
+func (s *CartServiceServer) DeleteCart(ctx context.Context, req *cartpb.DeleteCartRequest) (*cartpb.DeleteCartResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.CartId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "the primary cart cannot be deleted")
+	}
+
+	cart, err := s.getUserCart(ctx, req.CartId, userID)
+	if err != nil {
+		return nil, err
+	}
+	if cart.Primary {
+		return nil, status.Errorf(codes.InvalidArgument, "the primary cart cannot be deleted")
+	}
+
+	err = s.repo.DeleteNamedCart(ctx, cart.CartID, userID)
+	if err != nil {
+		if err == repository.ErrCartNotFound {
+			return nil, status.Errorf(codes.NotFound, "cart not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete cart: %v", err)
+	}
+
+	return &cartpb.DeleteCartResponse{
+		Message: "Cart deleted successfully",
+	}, nil
+}
+
+func mapCartToProto(cart *models.Cart) *cartpb.Cart {
+	cartProto := &cartpb.Cart{
+		CartId:        strconv.Itoa(cart.CartID),
+		Name:          cart.Name,
+		Primary:       cart.Primary,
+		ItemCount:     int32(cart.ItemCount),
+		TotalQuantity: int32(cart.TotalQuantity),
+		CreatedAt:     cart.CreatedAt.Format(time.RFC3339),
+	}
+	if cart.Primary {
+		cartProto.Name = primaryCartName
+	}
+	for _, item := range cart.Items {
+		cartProto.Items = append(cartProto.Items, &cartpb.CartItem{
+			ProductId:  strconv.Itoa(item.ProductID),
+			Quantity:   item.Quantity,
+			AddedPrice: addedPrice(item),
+			AddedAt:    item.CreatedAt.Format(time.RFC3339),
+			UpdatedAt:  item.UpdatedAt.Format(time.RFC3339),
+		})
+	}
+	return cartProto
+}
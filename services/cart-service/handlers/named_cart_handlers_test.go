@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreateCartNameTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectLockPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM carts").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery("INSERT INTO carts").
+		WithArgs(1, "Office refit").
+		WillReturnError(&pq.Error{Code: "23505"})
+	mock.ExpectRollback()
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.CreateCart(userContext(1), &cartpb.CreateCartRequest{Name: " Office refit "})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCreateCartLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectLockPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT COUNT\\(\\*\\) FROM carts WHERE user_id = \\$1 AND NOT is_primary").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(testConfig.MaxNamedCarts))
+	mock.ExpectRollback()
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.CreateCart(userContext(1), &cartpb.CreateCartRequest{Name: "Garden"})
+	if violation := preconditionViolation(t, err); violation != violationCartLimit {
+		t.Errorf("Expected %s, got %s", violationCartLimit, violation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// expectLockPrimaryCart expects the upsert CreateNamedCart locks the primary
+// cart of userID with.
+func expectLockPrimaryCart(mock sqlmock.Sqlmock, userID int) {
+	mock.ExpectQuery("INSERT INTO carts .* ON CONFLICT \\(user_id\\) WHERE is_primary").
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows(cartColumns).AddRow(primaryCartID, userID, "", true, time.Now()))
+}
+
+func TestListCarts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("FROM carts c LEFT JOIN cart_items").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"cart_id", "user_id", "name", "is_primary", "count", "sum", "created_at"}).
+			AddRow(primaryCartID, 1, "", true, 2, 5, now).
+			AddRow(7, 1, "Office refit", false, 1, 4, now))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.ListCarts(userContext(1), &cartpb.ListCartsRequest{})
+	if err != nil {
+		t.Fatalf("ListCarts failed: %v", err)
+	}
+	if len(resp.Carts) != 2 {
+		t.Fatalf("Expected 2 carts, got %+v", resp.Carts)
+	}
+	if primary := resp.Carts[0]; !primary.Primary || primary.CartId != strconv.Itoa(primaryCartID) || primary.Name != primaryCartName || primary.ItemCount != 2 || primary.TotalQuantity != 5 {
+		t.Errorf("Unexpected primary cart: %+v", primary)
+	}
+	if named := resp.Carts[1]; named.Primary || named.CartId != "7" || named.Name != "Office refit" || named.TotalQuantity != 4 {
+		t.Errorf("Unexpected named cart: %+v", named)
+	}
+}
+
+func TestAddItemToNamedCart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM carts").
+		WithArgs(7, 1).
+		WillReturnRows(sqlmock.NewRows(cartColumns).AddRow(7, 1, "Office refit", false, time.Now()))
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(7, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}))
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(7, 10, 2, 20.0, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 5},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.AddItem(userContext(1), &cartpb.AddItemRequest{ProductId: "10", Quantity: 2, CartId: "7"})
+	if err != nil {
+		t.Fatalf("AddItem failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestNamedCartOfAnotherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM carts").
+		WithArgs(7, 2).
+		WillReturnRows(sqlmock.NewRows(cartColumns))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.GetCart(userContext(2), &cartpb.GetCartRequest{CartId: "7"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestNamedCartRestrictions(t *testing.T) {
+	handler := NewCartServiceServer(nil, "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err := handler.DeleteCart(userContext(1), &cartpb.DeleteCartRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected deleting the primary cart to be InvalidArgument, got %v", err)
+	}
+
+	_, err = handler.GetCart(guestContext("guest-token"), &cartpb.GetCartRequest{CartId: "7"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected a guest naming a cart to be InvalidArgument, got %v", err)
+	}
+}
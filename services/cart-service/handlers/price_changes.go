@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"math"
+	"strconv"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Types of the warnings GetCart returns for items that changed since they
+// were put in the cart.
+const (
+	warningPriceChanged = "PRICE_CHANGED"
+	warningOutOfStock   = "OUT_OF_STOCK"
+	warningUnavailable  = "UNAVAILABLE"
+)
+
+func (s *CartServiceServer) AcknowledgePriceChanges(ctx context.Context, req *cartpb.AcknowledgePriceChangesRequest) (*cartpb.AcknowledgePriceChangesResponse, error) {
+	owner, err := s.cartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(req.Prices) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "prices are required")
+	}
+
+	seen := make(map[int]float64)
+	for _, price := range req.Prices {
+		productID, err := strconv.Atoi(price.ProductId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+		}
+		seen[productID] = price.UnitPrice
+	}
+
+	items, err := s.repo.GetCart(ctx, owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+	}
+
+	var productIDs []int
+	for _, item := range items {
+		if _, ok := seen[item.ProductID]; ok {
+			productIDs = append(productIDs, item.ProductID)
+		}
+	}
+	if len(productIDs) != len(seen) {
+		return nil, status.Errorf(codes.NotFound, "product not found in cart")
+	}
+
+	// Unlike GetCart this cannot degrade: acknowledging needs the prices the
+	// shopper is agreeing to.
+	products, err := s.getProducts(ctx, productIDs)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to fetch products: %v", err)
+	}
+
+	// The price may have changed again since the shopper was shown it, in
+	// which case nothing is acknowledged and the cart has to be reviewed
+	// again.
+	prices := make(map[int]float64)
+	for _, item := range items {
+		seenPrice, ok := seen[item.ProductID]
+		if !ok {
+			continue
+		}
+
+		subject := "product:" + strconv.Itoa(item.ProductID)
+		product, ok := products[item.ProductID]
+		if !ok {
+			return nil, preconditionFailure(violationProductNotFound, subject, "product does not exist")
+		}
+		if math.Round(seenPrice*100) != math.Round(product.Price*100) {
+			return nil, preconditionFailure(warningPriceChanged, subject,
+				fmt.Sprintf("the price of %s is now %.2f", product.Name, product.Price))
+		}
+
+		if item.AddedPrice == nil || priceChanged(item, product) {
+			prices[item.ProductID] = product.Price
+		}
+	}
+
+	updated, err := s.repo.AcknowledgePrices(ctx, owner, prices)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to acknowledge price changes: %v", err)
+	}
+	if updated > 0 {
+		log.Printf("Acknowledged %d price changes in cart of %s", updated, owner)
+	}
+
+	return &cartpb.AcknowledgePriceChangesResponse{
+		Message:      "Price changes acknowledged",
+		ItemsUpdated: int32(updated),
+	}, nil
+}
+
+// priceChanged reports whether the product's price differs from the price
+// the item was added at. Items without an added price never count as
+// changed.
+func priceChanged(item *models.CartItem, product *productpb.Product) bool {
+	if item.AddedPrice == nil {
+		return false
+	}
+	return math.Round(*item.AddedPrice*100) != math.Round(product.Price*100)
+}
+
+// cartWarnings returns a warning for every item of an enriched cart that
+// has changed price, run short of stock or disappeared.
+func cartWarnings(items []*cartpb.CartItem) []*cartpb.CartWarning {
+	var warnings []*cartpb.CartWarning
+	for _, item := range items {
+		switch {
+		case !item.Available:
+			warnings = append(warnings, &cartpb.CartWarning{
+				ProductId: item.ProductId,
+				Type:      warningUnavailable,
+				Message:   "this product is no longer available",
+			})
+			continue
+		case !item.InStock:
+			warnings = append(warnings, &cartpb.CartWarning{
+				ProductId: item.ProductId,
+				Type:      warningOutOfStock,
+				Message:   fmt.Sprintf("%s is now out of stock in the quantity requested", item.ProductName),
+			})
+		}
+
+		if item.PriceChanged {
+			warnings = append(warnings, &cartpb.CartWarning{
+				ProductId: item.ProductId,
+				Type:      warningPriceChanged,
+				Message:   fmt.Sprintf("the price of %s changed from %.2f to %.2f", item.ProductName, item.AddedPrice, item.UnitPrice),
+			})
+		}
+	}
+	return warnings
+}
@@ -0,0 +1,135 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+)
+
+func TestGetCartWarnings(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).
+			AddRow(10, 1, 4.99, now, now).
+			AddRow(11, 1, 15.0, now, now).
+			AddRow(12, 3, 20.0, now, now).
+			AddRow(13, 1, nil, now, now))
+	mock.ExpectQuery("SELECT code FROM cart_coupons").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows([]string{"code"}))
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Mug", Price: 4.99, Quantity: 100},
+		"11": {ProductId: "11", Name: "Vase", Price: 18, Quantity: 100},
+		"12": {ProductId: "12", Name: "Lamp", Price: 20, Quantity: 2},
+		"13": {ProductId: "13", Name: "Rug", Price: 60, Quantity: 1},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.GetCart(userContext(1), &cartpb.GetCartRequest{})
+	if err != nil {
+		t.Fatalf("GetCart failed: %v", err)
+	}
+
+	if !resp.RequiresAcknowledgement {
+		t.Errorf("Expected the price change to need acknowledgement")
+	}
+	if !resp.Items[1].PriceChanged || resp.Items[1].AddedPrice != 15 {
+		t.Errorf("Unexpected vase line: %+v", resp.Items[1])
+	}
+	if resp.Items[0].PriceChanged || resp.Items[3].PriceChanged {
+		t.Errorf("Expected unchanged and unrecorded prices not to count as changed")
+	}
+
+	if len(resp.Warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %+v", resp.Warnings)
+	}
+	if w := resp.Warnings[0]; w.ProductId != "11" || w.Type != warningPriceChanged {
+		t.Errorf("Unexpected warning: %+v", w)
+	}
+	if w := resp.Warnings[1]; w.ProductId != "12" || w.Type != warningOutOfStock {
+		t.Errorf("Unexpected warning: %+v", w)
+	}
+}
+
+func TestAcknowledgePriceChanges(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).
+			AddRow(10, 1, 4.99, now, now).
+			AddRow(11, 1, 15.0, now, now))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE cart_items SET added_price").
+		WithArgs(18.0, primaryCartID, 11).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Mug", Price: 4.99, Quantity: 100},
+		"11": {ProductId: "11", Name: "Vase", Price: 18, Quantity: 100},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.AcknowledgePriceChanges(userContext(1), &cartpb.AcknowledgePriceChangesRequest{
+		Prices: []*cartpb.AcknowledgedPrice{{ProductId: "11", UnitPrice: 18}},
+	})
+	if err != nil {
+		t.Fatalf("AcknowledgePriceChanges failed: %v", err)
+	}
+	if resp.ItemsUpdated != 1 {
+		t.Errorf("Expected 1 item updated, got %d", resp.ItemsUpdated)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestAcknowledgePriceChangesRejectsStalePrice(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(11, 1, 15.0, now, now))
+
+	// The shopper was shown 18.00, but the price has since gone up again.
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"11": {ProductId: "11", Name: "Vase", Price: 21, Quantity: 100},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.AcknowledgePriceChanges(userContext(1), &cartpb.AcknowledgePriceChangesRequest{
+		Prices: []*cartpb.AcknowledgedPrice{{ProductId: "11", UnitPrice: 18}},
+	})
+	if violation := preconditionViolation(t, err); violation != warningPriceChanged {
+		t.Errorf("Expected %s, got %s", warningPriceChanged, violation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
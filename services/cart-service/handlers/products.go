@@ -0,0 +1,127 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// productLookupTimeout bounds calls to Product Service, so a slow Product
+// Service degrades carts instead of stalling them.
+const productLookupTimeout = 2 * time.Second
+
+// productBatchSize is the most products Product Service's GetProducts looks up
+// in one call.
+const productBatchSize = 100
+
+// getProducts fetches the given products, in batches Product Service
+// accepts, keyed by product ID. Products that do not exist are absent from
+// the result.
+func (s *CartServiceServer) getProducts(ctx context.Context, productIDs []int) (map[int]*productpb.Product, error) {
+	products := make(map[int]*productpb.Product)
+	if len(productIDs) == 0 {
+		return products, nil
+	}
+
+	var ids []string
+	for _, productID := range productIDs {
+		ids = append(ids, strconv.Itoa(productID))
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, productLookupTimeout)
+	defer cancel()
+
+	for batch := range slices.Chunk(ids, productBatchSize) {
+		resp, err := s.productClient.GetProducts(ctx, &productpb.GetProductsRequest{
+			ProductIds: batch,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		for _, product := range resp.Products {
+			productID, err := strconv.Atoi(product.ProductId)
+			if err != nil {
+				continue
+			}
+			products[productID] = product
+		}
+	}
+	return products, nil
+}
+
+// roundPrice rounds an amount to whole cents.
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
+
+// Violation types reported in the PreconditionFailure details of cart
+// changes that cannot be satisfied.
+const (
+	violationProductNotFound   = "PRODUCT_NOT_FOUND"
+	violationInsufficientStock = "INSUFFICIENT_STOCK"
+	violationQuantityLimit     = "QUANTITY_LIMIT_EXCEEDED"
+)
+
+// checkItemAvailable checks that quantity of a product can be put in a cart:
+// the product must exist, have enough stock and be within the per-item limit.
+// It returns the product, so callers can record its current price.
+func (s *CartServiceServer) checkItemAvailable(ctx context.Context, productID int, quantity int32) (*productpb.Product, error) {
+	subject := "product:" + strconv.Itoa(productID)
+
+	if quantity > s.cfg.MaxItemQuantity {
+		return nil, preconditionFailure(violationQuantityLimit, subject,
+			fmt.Sprintf("at most %d of a product can be added to the cart", s.cfg.MaxItemQuantity))
+	}
+
+	product, err := s.getProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	if product.Quantity < quantity {
+		return nil, preconditionFailure(violationInsufficientStock, subject,
+			fmt.Sprintf("only %d in stock", product.Quantity))
+	}
+	return product, nil
+}
+
+// getProduct fetches a single product, failing with a PRODUCT_NOT_FOUND
+// violation when it does not exist.
+func (s *CartServiceServer) getProduct(ctx context.Context, productID int) (*productpb.Product, error) {
+	ctx, cancel := context.WithTimeout(ctx, productLookupTimeout)
+	defer cancel()
+
+	resp, err := s.productClient.GetProduct(ctx, &productpb.GetProductRequest{
+		ProductId: strconv.Itoa(productID),
+	})
+	if err != nil {
+		if status.Code(err) == codes.NotFound {
+			return nil, preconditionFailure(violationProductNotFound, "product:"+strconv.Itoa(productID), "product does not exist")
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to check product availability: %v", err)
+	}
+	return resp.Product, nil
+}
+
+func preconditionFailure(violationType, subject, description string) error {
+	st, err := status.New(codes.FailedPrecondition, description).WithDetails(&errdetails.PreconditionFailure{
+		Violations: []*errdetails.PreconditionFailure_Violation{{
+			Type:        violationType,
+			Subject:     subject,
+			Description: description,
+		}},
+	})
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "%s", description)
+	}
+	return st.Err()
+}
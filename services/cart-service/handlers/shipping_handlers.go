@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// GetShippingQuotes asks Order Service what shipping the cart would cost, on
+// behalf of the caller. Order Service validates the destination.
+func (s *CartServiceServer) GetShippingQuotes(ctx context.Context, req *cartpb.GetShippingQuotesRequest) (*cartpb.GetShippingQuotesResponse, error) {
+	if req.ShippingAddressId == "" && req.Country == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "shipping address or country is required")
+	}
+
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := s.repo.GetCart(ctx, owner)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+	}
+	if len(items) == 0 {
+		return &cartpb.GetShippingQuotesResponse{}, nil
+	}
+
+	quoteReq := &orderpb.GetShippingQuotesRequest{
+		ShippingAddressId: req.ShippingAddressId,
+		Country:           req.Country,
+	}
+	for _, item := range items {
+		quoteReq.Items = append(quoteReq.Items, &orderpb.OrderItem{
+			ProductId: strconv.Itoa(item.ProductID),
+			Quantity:  item.Quantity,
+		})
+	}
+
+	ctx, cancel := context.WithTimeout(auth.ForwardAuthorization(ctx), orderLookupTimeout)
+	defer cancel()
+
+	quotes, err := s.orderClient.GetShippingQuotes(ctx, quoteReq)
+	if err != nil {
+		// Invalid destinations are the caller's to fix.
+		if code := status.Code(err); code == codes.InvalidArgument {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to get shipping quotes: %v", err)
+	}
+
+	resp := &cartpb.GetShippingQuotesResponse{
+		TotalWeightGrams: quotes.TotalWeightGrams,
+	}
+	for _, quote := range quotes.Quotes {
+		resp.Quotes = append(resp.Quotes, &cartpb.ShippingQuote{
+			ShippingMethodId: quote.ShippingMethodId,
+			Carrier:          quote.Carrier,
+			Name:             quote.Name,
+			Amount:           quote.Amount,
+			MinDeliveryDays:  quote.MinDeliveryDays,
+			MaxDeliveryDays:  quote.MaxDeliveryDays,
+		})
+	}
+
+	return resp, nil
+}
@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetShippingQuotes(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items").
+		WithArgs(primaryCartID).
+		WillReturnRows(sqlmock.NewRows(cartItemColumns).AddRow(10, 2, nil, now, now))
+
+	orderClient := &fakeOrderClient{quotes: &orderpb.GetShippingQuotesResponse{
+		Quotes:           []*orderpb.ShippingQuote{{ShippingMethodId: "3", Carrier: "DHL", Name: "Express", Amount: 12.5}},
+		TotalWeightGrams: 800,
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, orderClient, testConfig)
+
+	resp, err := handler.GetShippingQuotes(userContext(1), &cartpb.GetShippingQuotesRequest{Country: "de"})
+	if err != nil {
+		t.Fatalf("GetShippingQuotes failed: %v", err)
+	}
+	if len(resp.Quotes) != 1 || resp.Quotes[0].ShippingMethodId != "3" || resp.TotalWeightGrams != 800 {
+		t.Errorf("Unexpected response: %+v", resp)
+	}
+
+	request := orderClient.quoteRequest
+	if request.Country != "de" || len(request.Items) != 1 || request.Items[0].ProductId != "10" || request.Items[0].Quantity != 2 {
+		t.Errorf("Unexpected quote request: %+v", request)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetShippingQuotesNeedsDestination(t *testing.T) {
+	handler := NewCartServiceServer(nil, "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err := handler.GetShippingQuotes(userContext(1), &cartpb.GetShippingQuotesRequest{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument, got %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// cartDataExport is the document ExportUserData returns.
+type cartDataExport struct {
+	Cart       json.RawMessage   `json:"cart"`
+	NamedCarts []json.RawMessage `json:"named_carts"`
+	Wishlists  []json.RawMessage `json:"wishlists"`
+}
+
+// ExportUserData returns the caller's carts and wishlists as JSON for
+// inclusion in a data-subject export assembled by User Service.
+func (s *CartServiceServer) ExportUserData(ctx context.Context, req *cartpb.ExportUserDataRequest) (*cartpb.ExportUserDataResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	export := cartDataExport{
+		NamedCarts: []json.RawMessage{},
+		Wishlists:  []json.RawMessage{},
+	}
+
+	carts, err := s.repo.ListCarts(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list carts: %v", err)
+	}
+
+	primary := &cartpb.GetCartResponse{}
+	for _, cart := range carts {
+		cart.Items, err = s.repo.GetCart(ctx, cart.Owner())
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get cart: %v", err)
+		}
+
+		if cart.Primary {
+			primary.CartId = strconv.Itoa(cart.CartID)
+			for _, item := range cart.Items {
+				primary.Items = append(primary.Items, &cartpb.CartItem{
+					ProductId: strconv.Itoa(item.ProductID),
+					Quantity:  item.Quantity,
+					AddedAt:   item.CreatedAt.Format(time.RFC3339),
+					UpdatedAt: item.UpdatedAt.Format(time.RFC3339),
+				})
+			}
+			continue
+		}
+
+		data, err := protojson.Marshal(mapCartToProto(cart))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode cart: %v", err)
+		}
+		export.NamedCarts = append(export.NamedCarts, data)
+	}
+
+	export.Cart, err = protojson.Marshal(primary)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode cart: %v", err)
+	}
+
+	wishlists, err := s.repo.ListWishlists(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list wishlists: %v", err)
+	}
+
+	for _, summary := range wishlists {
+		wishlist, err := s.repo.GetWishlist(ctx, summary.WishlistID, userID)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to get wishlist: %v", err)
+		}
+
+		data, err := protojson.Marshal(mapWishlistToProto(wishlist))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode wishlist: %v", err)
+		}
+		export.Wishlists = append(export.Wishlists, data)
+	}
+
+	data, err := json.Marshal(export)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode export: %v", err)
+	}
+
+	return &cartpb.ExportUserDataResponse{
+		Data: data,
+	}, nil
+}
+
+// EraseUserData deletes everything the service stores about the caller. Carts
+// and wishlists hold nothing that has to be retained, so they are simply
+// deleted.
+func (s *CartServiceServer) EraseUserData(ctx context.Context, req *cartpb.EraseUserDataRequest) (*cartpb.EraseUserDataResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	err := s.repo.EraseUserData(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to erase user data: %v", err)
+	}
+
+	return &cartpb.EraseUserDataResponse{
+		Message: "User data erased successfully",
+	}, nil
+}
@@ -0,0 +1,442 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxWishlistNameLength = 100
+
+func (s *CartServiceServer) CreateWishlist(ctx context.Context, req *cartpb.CreateWishlistRequest) (*cartpb.CreateWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	name, err := validateWishlistName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist := &models.Wishlist{
+		UserID: userID,
+		Name:   name,
+	}
+
+	err = s.repo.CreateWishlist(ctx, wishlist)
+	if err != nil {
+		if err == repository.ErrWishlistNameTaken {
+			return nil, status.Errorf(codes.AlreadyExists, "a wishlist with this name already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create wishlist: %v", err)
+	}
+
+	return &cartpb.CreateWishlistResponse{
+		Wishlist: mapWishlistToProto(wishlist),
+	}, nil
+}
+
+// ListWishlists returns the caller's wishlists without their items; use
+// GetWishlist for those.
+func (s *CartServiceServer) ListWishlists(ctx context.Context, req *cartpb.ListWishlistsRequest) (*cartpb.ListWishlistsResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlists, err := s.repo.ListWishlists(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list wishlists: %v", err)
+	}
+
+	resp := &cartpb.ListWishlistsResponse{}
+	for _, wishlist := range wishlists {
+		resp.Wishlists = append(resp.Wishlists, mapWishlistToProto(wishlist))
+	}
+
+	return resp, nil
+}
+
+func (s *CartServiceServer) GetWishlist(ctx context.Context, req *cartpb.GetWishlistRequest) (*cartpb.GetWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlist, err := s.getWishlist(ctx, req.WishlistId, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &cartpb.GetWishlistResponse{
+		Wishlist: mapWishlistToProto(wishlist),
+	}, nil
+}
+
+func (s *CartServiceServer) RenameWishlist(ctx context.Context, req *cartpb.RenameWishlistRequest) (*cartpb.RenameWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlistID, err := strconv.Atoi(req.WishlistId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid wishlist ID")
+	}
+
+	name, err := validateWishlistName(req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RenameWishlist(ctx, wishlistID, userID, name)
+	if err != nil {
+		switch err {
+		case repository.ErrWishlistNotFound:
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		case repository.ErrWishlistNameTaken:
+			return nil, status.Errorf(codes.AlreadyExists, "a wishlist with this name already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to rename wishlist: %v", err)
+	}
+
+	return &cartpb.RenameWishlistResponse{
+		Message: "Wishlist renamed successfully",
+	}, nil
+}
+
+func (s *CartServiceServer) DeleteWishlist(ctx context.Context, req *cartpb.DeleteWishlistRequest) (*cartpb.DeleteWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlistID, err := strconv.Atoi(req.WishlistId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid wishlist ID")
+	}
+
+	err = s.repo.DeleteWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		if err == repository.ErrWishlistNotFound {
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete wishlist: %v", err)
+	}
+
+	return &cartpb.DeleteWishlistResponse{
+		Message: "Wishlist deleted successfully",
+	}, nil
+}
+
+// AddWishlistItem adds a product to a wishlist. Stock is not checked, as the
+// product is not being bought yet.
+func (s *CartServiceServer) AddWishlistItem(ctx context.Context, req *cartpb.AddWishlistItemRequest) (*cartpb.AddWishlistItemResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	productID, err := strconv.Atoi(req.ProductId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+	}
+
+	quantity := req.Quantity
+	if quantity == 0 {
+		quantity = 1
+	}
+	if quantity < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "quantity must be greater than zero")
+	}
+
+	wishlist, err := s.getWishlist(ctx, req.WishlistId, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.getProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+
+	err = s.repo.AddWishlistItem(ctx, wishlist.WishlistID, productID, quantity, s.cfg.MaxItemQuantity)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to add item to wishlist: %v", err)
+	}
+
+	return &cartpb.AddWishlistItemResponse{
+		Message: "Item added to wishlist successfully",
+	}, nil
+}
+
+func (s *CartServiceServer) RemoveWishlistItem(ctx context.Context, req *cartpb.RemoveWishlistItemRequest) (*cartpb.RemoveWishlistItemResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	productID, err := strconv.Atoi(req.ProductId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+	}
+
+	wishlist, err := s.getWishlist(ctx, req.WishlistId, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.RemoveWishlistItem(ctx, wishlist.WishlistID, productID)
+	if err != nil {
+		if err == repository.ErrWishlistItemNotFound {
+			return nil, status.Errorf(codes.NotFound, "item not found in wishlist")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to remove item from wishlist: %v", err)
+	}
+
+	return &cartpb.RemoveWishlistItemResponse{
+		Message: "Item removed from wishlist successfully",
+	}, nil
+}
+
+// MoveWishlistItemToCart moves a product with its quantity from a wishlist to
+// the caller's cart, subject to the same checks as AddItem.
+func (s *CartServiceServer) MoveWishlistItemToCart(ctx context.Context, req *cartpb.MoveWishlistItemToCartRequest) (*cartpb.MoveWishlistItemToCartResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	productID, err := strconv.Atoi(req.ProductId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+	}
+
+	wishlist, err := s.getWishlist(ctx, req.WishlistId, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var item *models.WishlistItem
+	for _, wishlistItem := range wishlist.Items {
+		if wishlistItem.ProductID == productID {
+			item = wishlistItem
+		}
+	}
+	if item == nil {
+		return nil, status.Errorf(codes.NotFound, "item not found in wishlist")
+	}
+
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	existingQuantity, err := s.repo.GetItemQuantity(ctx, owner, productID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get cart item: %v", err)
+	}
+
+	product, err := s.checkItemAvailable(ctx, productID, existingQuantity+item.Quantity)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.MoveWishlistItemToCart(ctx, wishlist.WishlistID, owner, productID, product.Price)
+	if err != nil {
+		if err == repository.ErrWishlistItemNotFound {
+			return nil, status.Errorf(codes.NotFound, "item not found in wishlist")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to move item to cart: %v", err)
+	}
+
+	return &cartpb.MoveWishlistItemToCartResponse{
+		Message: "Item moved to cart successfully",
+	}, nil
+}
+
+// MoveCartItemToWishlist saves a product in the caller's cart for later,
+// moving it with its quantity to a wishlist.
+func (s *CartServiceServer) MoveCartItemToWishlist(ctx context.Context, req *cartpb.MoveCartItemToWishlistRequest) (*cartpb.MoveCartItemToWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	productID, err := strconv.Atoi(req.ProductId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+	}
+
+	wishlist, err := s.getWishlist(ctx, req.WishlistId, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	owner, err := s.userCartOwner(ctx, req.CartId)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.MoveCartItemToWishlist(ctx, owner, wishlist.WishlistID, productID, s.cfg.MaxItemQuantity)
+	if err != nil {
+		if err.Error() == "item not found in cart" {
+			return nil, status.Errorf(codes.NotFound, "item not found in cart")
+		}
+		if err == repository.ErrItemQuantityExceeded {
+			return nil, preconditionFailure(violationQuantityLimit, "product:"+req.ProductId,
+				fmt.Sprintf("at most %d of this product can be in the wishlist", s.cfg.MaxItemQuantity))
+		}
+		return nil, status.Errorf(codes.Internal, "failed to move item to wishlist: %v", err)
+	}
+
+	return &cartpb.MoveCartItemToWishlistResponse{
+		Message: "Item moved to wishlist successfully",
+	}, nil
+}
+
+// ShareWishlist returns a new token the wishlist can be viewed with through
+// GetSharedWishlist. Only its hash is stored, so sharing again replaces it.
+func (s *CartServiceServer) ShareWishlist(ctx context.Context, req *cartpb.ShareWishlistRequest) (*cartpb.ShareWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlistID, err := strconv.Atoi(req.WishlistId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid wishlist ID")
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate share token")
+	}
+
+	err = s.repo.SetWishlistShareToken(ctx, wishlistID, userID, utils.HashToken(token))
+	if err != nil {
+		if err == repository.ErrWishlistNotFound {
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to share wishlist: %v", err)
+	}
+
+	return &cartpb.ShareWishlistResponse{
+		ShareToken: token,
+	}, nil
+}
+
+func (s *CartServiceServer) UnshareWishlist(ctx context.Context, req *cartpb.UnshareWishlistRequest) (*cartpb.UnshareWishlistResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	wishlistID, err := strconv.Atoi(req.WishlistId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid wishlist ID")
+	}
+
+	err = s.repo.SetWishlistShareToken(ctx, wishlistID, userID, "")
+	if err != nil {
+		if err == repository.ErrWishlistNotFound {
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to unshare wishlist: %v", err)
+	}
+
+	return &cartpb.UnshareWishlistResponse{
+		Message: "Wishlist is no longer shared",
+	}, nil
+}
+
+// GetSharedWishlist returns a wishlist to anyone holding its share token.
+func (s *CartServiceServer) GetSharedWishlist(ctx context.Context, req *cartpb.GetSharedWishlistRequest) (*cartpb.GetSharedWishlistResponse, error) {
+	if req.ShareToken == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "share token is required")
+	}
+
+	wishlist, err := s.repo.GetWishlistByShareToken(ctx, utils.HashToken(req.ShareToken))
+	if err != nil {
+		if err == repository.ErrWishlistNotFound {
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get wishlist: %v", err)
+	}
+
+	wishlistProto := mapWishlistToProto(wishlist)
+	wishlistProto.Shared = false
+
+	return &cartpb.GetSharedWishlistResponse{
+		Wishlist: wishlistProto,
+	}, nil
+}
+
+// getWishlist returns one of the user's wishlists, failing with NotFound when
+// it belongs to someone else.
+func (s *CartServiceServer) getWishlist(ctx context.Context, id string, userID int) (*models.Wishlist, error) {
+	wishlistID, err := strconv.Atoi(id)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid wishlist ID")
+	}
+
+	wishlist, err := s.repo.GetWishlist(ctx, wishlistID, userID)
+	if err != nil {
+		if err == repository.ErrWishlistNotFound {
+			return nil, status.Errorf(codes.NotFound, "wishlist not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get wishlist: %v", err)
+	}
+	return wishlist, nil
+}
+
+func validateWishlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", status.Errorf(codes.InvalidArgument, "wishlist name is required")
+	}
+	if utf8.RuneCountInString(name) > maxWishlistNameLength {
+		return "", status.Errorf(codes.InvalidArgument, "wishlist name must be at most %d characters", maxWishlistNameLength)
+	}
+	return name, nil
+}
+
+func mapWishlistToProto(wishlist *models.Wishlist) *cartpb.Wishlist {
+	wishlistProto := &cartpb.Wishlist{
+		WishlistId: strconv.Itoa(wishlist.WishlistID),
+		Name:       wishlist.Name,
+		Shared:     wishlist.Shared,
+		CreatedAt:  wishlist.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:  wishlist.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, item := range wishlist.Items {
+		wishlistProto.Items = append(wishlistProto.Items, &cartpb.WishlistItem{
+			ProductId: strconv.Itoa(item.ProductID),
+			Quantity:  item.Quantity,
+			AddedAt:   item.AddedAt.Format(time.RFC3339),
+		})
+	}
+	return wishlistProto
+}
@@ -0,0 +1,194 @@
+package handlers
+
+import (
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var wishlistColumns = []string{"wishlist_id", "user_id", "name", "shared", "created_at", "updated_at"}
+
+var wishlistItemColumns = []string{"product_id", "quantity", "added_at"}
+
+func TestCreateWishlistNameTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("INSERT INTO wishlists").
+		WithArgs(1, "Birthday").
+		WillReturnError(&pq.Error{Code: "23505"})
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.CreateWishlist(userContext(1), &cartpb.CreateWishlistRequest{Name: "  Birthday "})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %v", err)
+	}
+}
+
+func TestGetWishlistOfAnotherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("FROM wishlists").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows(wishlistColumns))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.GetWishlist(userContext(1), &cartpb.GetWishlistRequest{WishlistId: "3"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestMoveWishlistItemToCart(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM wishlists").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows(wishlistColumns).AddRow(3, 1, "Later", false, now, now))
+	mock.ExpectQuery("FROM wishlist_items").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(wishlistItemColumns).AddRow(10, 2, now))
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(1))
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM wishlist_items").
+		WithArgs(3, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(2))
+	mock.ExpectExec("INSERT INTO cart_items").
+		WithArgs(primaryCartID, 10, 2, 20.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 3},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.MoveWishlistItemToCart(userContext(1), &cartpb.MoveWishlistItemToCartRequest{WishlistId: "3", ProductId: "10"})
+	if err != nil {
+		t.Fatalf("MoveWishlistItemToCart failed: %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestMoveWishlistItemToCartChecksStock(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM wishlists").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows(wishlistColumns).AddRow(3, 1, "Later", false, now, now))
+	mock.ExpectQuery("FROM wishlist_items").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(wishlistItemColumns).AddRow(10, 2, now))
+	expectPrimaryCart(mock, 1)
+	mock.ExpectQuery("SELECT quantity FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(2))
+
+	productClient := &fakeProductClient{products: map[string]*productpb.Product{
+		"10": {ProductId: "10", Name: "Lamp", Price: 20, Quantity: 3},
+	}}
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, productClient, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.MoveWishlistItemToCart(userContext(1), &cartpb.MoveWishlistItemToCartRequest{WishlistId: "3", ProductId: "10"})
+	if violation := preconditionViolation(t, err); violation != violationInsufficientStock {
+		t.Errorf("Expected %s, got %s", violationInsufficientStock, violation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestGetSharedWishlist(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM wishlists").
+		WithArgs(utils.HashToken("share-token")).
+		WillReturnRows(sqlmock.NewRows(wishlistColumns).AddRow(3, 1, "Birthday", true, now, now))
+	mock.ExpectQuery("FROM wishlist_items").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(wishlistItemColumns).AddRow(10, 1, now).AddRow(11, 2, now))
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	resp, err := handler.GetSharedWishlist(userContext(2), &cartpb.GetSharedWishlistRequest{ShareToken: "share-token"})
+	if err != nil {
+		t.Fatalf("GetSharedWishlist failed: %v", err)
+	}
+	if resp.Wishlist.Name != "Birthday" || len(resp.Wishlist.Items) != 2 || resp.Wishlist.Shared {
+		t.Errorf("Unexpected wishlist: %+v", resp.Wishlist)
+	}
+}
+
+func TestMoveCartItemToWishlistOverLimit(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM wishlists").
+		WithArgs(3, 1).
+		WillReturnRows(sqlmock.NewRows(wishlistColumns).AddRow(3, 1, "Later", false, now, now))
+	mock.ExpectQuery("FROM wishlist_items").
+		WithArgs(3).
+		WillReturnRows(sqlmock.NewRows(wishlistItemColumns).AddRow(10, 8, now))
+	expectPrimaryCart(mock, 1)
+	mock.ExpectBegin()
+	mock.ExpectQuery("DELETE FROM cart_items").
+		WithArgs(primaryCartID, 10).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	// 8 + 3 is more than the limit of 10, so nothing is updated.
+	mock.ExpectExec("INSERT INTO wishlist_items .* WHERE wishlist_items.quantity \\+ EXCLUDED.quantity <= \\$4").
+		WithArgs(3, 10, 3, 10).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	// Rolling back leaves the item in the cart.
+	mock.ExpectRollback()
+
+	handler := NewCartServiceServer(repository.NewCartRepository(db), "secret", nil, &fakeProductClient{}, &fakeOrderClient{}, testConfig)
+
+	_, err = handler.MoveCartItemToWishlist(userContext(1), &cartpb.MoveCartItemToWishlistRequest{WishlistId: "3", ProductId: "10"})
+	if violation := preconditionViolation(t, err); violation != violationQuantityLimit {
+		t.Errorf("Expected %s, got %s", violationQuantityLimit, violation)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -1,7 +1,131 @@
 package models
 
+import (
+	"fmt"
+	"time"
+)
+
 type CartItem struct {
 	UserID    int
 	ProductID int
 	Quantity  int32
+	// AddedPrice is the price when the item was put in the cart or when the
+	// shopper last acknowledged a price change, nil if it was not recorded.
+	AddedPrice *float64
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+// CartOwner identifies a cart and who it belongs to: one of a signed-in
+// user's carts, which is their primary cart when Primary is set, or a guest
+// cart when UserID is 0.
+type CartOwner struct {
+	CartID  int
+	UserID  int
+	Primary bool
+}
+
+func (o CartOwner) IsGuest() bool {
+	return o.UserID == 0
+}
+
+func (o CartOwner) String() string {
+	if o.IsGuest() {
+		return fmt.Sprintf("guest cart %d", o.CartID)
+	}
+	return fmt.Sprintf("cart %d of user %d", o.CartID, o.UserID)
+}
+
+// Cart is one of a user's carts: their primary cart, which has no name, or a
+// named cart they keep besides it, such as one per project. Items is only
+// filled in when the cart is loaded with them.
+type Cart struct {
+	CartID        int
+	UserID        int
+	Name          string
+	Primary       bool
+	ItemCount     int
+	TotalQuantity int
+	CreatedAt     time.Time
+	Items         []*CartItem
+}
+
+// Owner returns the CartOwner identifying the cart.
+func (c *Cart) Owner() CartOwner {
+	return CartOwner{CartID: c.CartID, UserID: c.UserID, Primary: c.Primary}
+}
+
+// GuestCart is a cart created by an anonymous shopper. It is identified by an
+// opaque token of which only the hash is stored.
+type GuestCart struct {
+	CartID    int
+	TokenHash string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+}
+
+// MergeStrategy decides the quantity of a product that is in both the user's
+// cart and a guest cart being merged into it.
+type MergeStrategy string
+
+const (
+	// MergeStrategySum adds the two quantities together.
+	MergeStrategySum MergeStrategy = "sum"
+	// MergeStrategyMax keeps the larger of the two quantities.
+	MergeStrategyMax MergeStrategy = "max"
+	// MergeStrategyKeepUser keeps the quantity in the user's cart.
+	MergeStrategyKeepUser MergeStrategy = "keep_user"
+	// MergeStrategyKeepGuest takes the quantity from the guest cart.
+	MergeStrategyKeepGuest MergeStrategy = "keep_guest"
+)
+
+func (s MergeStrategy) Valid() bool {
+	switch s {
+	case MergeStrategySum, MergeStrategyMax, MergeStrategyKeepUser, MergeStrategyKeepGuest:
+		return true
+	}
+	return false
+}
+
+// Resolve returns the merged quantity of a product the user already has
+// userQuantity of, capped at maxQuantity.
+func (s MergeStrategy) Resolve(userQuantity, guestQuantity, maxQuantity int32) int32 {
+	var quantity int32
+	switch s {
+	case MergeStrategyMax:
+		quantity = max(userQuantity, guestQuantity)
+	case MergeStrategyKeepUser:
+		quantity = userQuantity
+	case MergeStrategyKeepGuest:
+		quantity = guestQuantity
+	default:
+		quantity = userQuantity + guestQuantity
+	}
+	return min(quantity, maxQuantity)
+}
+
+// MergeResult summarises the merge of a guest cart into a user's cart.
+type MergeResult struct {
+	// ItemsAdded counts products that were only in the guest cart.
+	ItemsAdded int
+	// Conflicts counts products that were in both carts.
+	Conflicts int
+}
+
+// AbandonedCart is one of a user's carts that holds items but has not
+// changed since LastActivityAt.
+type AbandonedCart struct {
+	CartID         int
+	UserID         int
+	Primary        bool
+	ItemCount      int32
+	TotalQuantity  int32
+	LastActivityAt time.Time
+}
+
+// AbandonedCartEvent records that a cart was found abandoned.
+type AbandonedCartEvent struct {
+	EventID int64
+	AbandonedCart
+	DetectedAt time.Time
 }
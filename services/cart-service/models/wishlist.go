@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// Wishlist is a named list of products a user has parked without buying.
+type Wishlist struct {
+	WishlistID int
+	UserID     int
+	Name       string
+	// Shared is true while the wishlist can be viewed through a share token.
+	Shared    bool
+	Items     []*WishlistItem
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type WishlistItem struct {
+	ProductID int
+	Quantity  int32
+	AddedAt   time.Time
+}
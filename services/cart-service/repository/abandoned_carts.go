@@ -0,0 +1,96 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+// A cart's last activity is the last time one of its items was added or
+// changed. Removing an item does not count, as the row is gone. Guest carts
+// have nobody to remind, so they are never abandoned.
+
+// ListAbandonedCarts returns the carts that have not changed since idleSince,
+// ordered by cart ID and starting after afterCartID.
+func (r *cartRepository) ListAbandonedCarts(ctx context.Context, idleSince time.Time, afterCartID, limit int) ([]*models.AbandonedCart, error) {
+	query := `
+		SELECT c.cart_id, c.user_id, c.is_primary, COUNT(*), SUM(i.quantity), MAX(i.updated_at)
+		FROM carts c
+		JOIN cart_items i ON i.cart_id = c.cart_id
+		WHERE c.user_id IS NOT NULL AND c.cart_id > $2
+		GROUP BY c.cart_id
+		HAVING MAX(i.updated_at) < $1
+		ORDER BY c.cart_id
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, idleSince, afterCartID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var carts []*models.AbandonedCart
+	for rows.Next() {
+		cart := &models.AbandonedCart{}
+		err := rows.Scan(&cart.CartID, &cart.UserID, &cart.Primary, &cart.ItemCount, &cart.TotalQuantity, &cart.LastActivityAt)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, cart)
+	}
+
+	return carts, rows.Err()
+}
+
+// RecordAbandonedCarts records an event for every cart that has not changed
+// since idleSince and has no event for its current idle period yet, and
+// returns how many were recorded.
+func (r *cartRepository) RecordAbandonedCarts(ctx context.Context, idleSince time.Time) (int64, error) {
+	query := `
+		INSERT INTO abandoned_cart_events (cart_id, user_id, is_primary, item_count, total_quantity, last_activity_at)
+		SELECT c.cart_id, c.user_id, c.is_primary, COUNT(*), SUM(i.quantity), MAX(i.updated_at)
+		FROM carts c
+		JOIN cart_items i ON i.cart_id = c.cart_id
+		WHERE c.user_id IS NOT NULL
+		GROUP BY c.cart_id
+		HAVING MAX(i.updated_at) < $1
+		ON CONFLICT (cart_id, last_activity_at) DO NOTHING
+	`
+
+	result, err := r.db.ExecContext(ctx, query, idleSince)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
+// ListAbandonedCartEvents returns the events after afterEventID, oldest first.
+func (r *cartRepository) ListAbandonedCartEvents(ctx context.Context, afterEventID int64, limit int) ([]*models.AbandonedCartEvent, error) {
+	query := `
+		SELECT event_id, COALESCE(cart_id, 0), user_id, is_primary, item_count, total_quantity, last_activity_at, detected_at
+		FROM abandoned_cart_events
+		WHERE event_id > $1
+		ORDER BY event_id
+		LIMIT $2
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, afterEventID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AbandonedCartEvent
+	for rows.Next() {
+		event := &models.AbandonedCartEvent{}
+		err := rows.Scan(&event.EventID, &event.CartID, &event.UserID, &event.Primary, &event.ItemCount, &event.TotalQuantity, &event.LastActivityAt, &event.DetectedAt)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -4,16 +4,53 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
 	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
 )
 
 type CartRepository interface {
-	AddItem(ctx context.Context, item *models.CartItem) error
-	GetCart(ctx context.Context, userID int) ([]*models.CartItem, error)
-	UpdateItemQuantity(ctx context.Context, item *models.CartItem) error
-	RemoveItem(ctx context.Context, userID, productID int) error
-	ClearCart(ctx context.Context, userID int) error
+	AddItem(ctx context.Context, owner models.CartOwner, productID int, quantity, maxQuantity int32, price float64) error
+	GetCart(ctx context.Context, owner models.CartOwner) ([]*models.CartItem, error)
+	GetItemQuantity(ctx context.Context, owner models.CartOwner, productID int) (int32, error)
+	UpdateItemQuantity(ctx context.Context, owner models.CartOwner, productID int, quantity int32) error
+	RemoveItem(ctx context.Context, owner models.CartOwner, productID int) error
+	ClearCart(ctx context.Context, owner models.CartOwner) error
+	AcknowledgePrices(ctx context.Context, owner models.CartOwner, prices map[int]float64) (int64, error)
+
+	CreateGuestCart(ctx context.Context, cart *models.GuestCart) error
+	GetGuestCartByToken(ctx context.Context, tokenHash string) (*models.GuestCart, error)
+	MergeGuestCart(ctx context.Context, guestCartID int, owner models.CartOwner, strategy models.MergeStrategy, maxQuantity int32) (*models.MergeResult, error)
+	DeleteExpiredGuestCarts(ctx context.Context) (int64, error)
+
+	ListAbandonedCarts(ctx context.Context, idleSince time.Time, afterCartID, limit int) ([]*models.AbandonedCart, error)
+	RecordAbandonedCarts(ctx context.Context, idleSince time.Time) (int64, error)
+	ListAbandonedCartEvents(ctx context.Context, afterEventID int64, limit int) ([]*models.AbandonedCartEvent, error)
+
+	CreateWishlist(ctx context.Context, wishlist *models.Wishlist) error
+	ListWishlists(ctx context.Context, userID int) ([]*models.Wishlist, error)
+	GetWishlist(ctx context.Context, wishlistID, userID int) (*models.Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, tokenHash string) (*models.Wishlist, error)
+	RenameWishlist(ctx context.Context, wishlistID, userID int, name string) error
+	DeleteWishlist(ctx context.Context, wishlistID, userID int) error
+	SetWishlistShareToken(ctx context.Context, wishlistID, userID int, tokenHash string) error
+	AddWishlistItem(ctx context.Context, wishlistID, productID int, quantity, maxQuantity int32) error
+	RemoveWishlistItem(ctx context.Context, wishlistID, productID int) error
+	MoveWishlistItemToCart(ctx context.Context, wishlistID int, owner models.CartOwner, productID int, price float64) error
+	MoveCartItemToWishlist(ctx context.Context, owner models.CartOwner, wishlistID, productID int, maxQuantity int32) error
+
+	RecordCouponAttempt(ctx context.Context, userID int, window time.Duration) (int, error)
+	AddCartCoupon(ctx context.Context, owner models.CartOwner, code string) error
+	RemoveCartCoupon(ctx context.Context, owner models.CartOwner, code string) error
+	ListCartCoupons(ctx context.Context, owner models.CartOwner) ([]string, error)
+
+	GetPrimaryCart(ctx context.Context, userID int) (*models.Cart, error)
+	CreateNamedCart(ctx context.Context, cart *models.Cart, maxNamedCarts int) error
+	ListCarts(ctx context.Context, userID int) ([]*models.Cart, error)
+	GetUserCart(ctx context.Context, cartID, userID int) (*models.Cart, error)
+	DeleteNamedCart(ctx context.Context, cartID, userID int) error
+
+	EraseUserData(ctx context.Context, userID int) error
 }
 
 type cartRepository struct {
@@ -24,37 +61,49 @@ func NewCartRepository(db *sql.DB) CartRepository {
 	return &cartRepository{db: db}
 }
 
-func (r *cartRepository) AddItem(ctx context.Context, item *models.CartItem) error {
+// ErrItemQuantityExceeded is returned by AddItem when the cart would hold more
+// of the product than allowed.
+var ErrItemQuantityExceeded = errors.New("item quantity limit exceeded")
+
+// AddItem adds quantity of a product to the cart. price is recorded as the
+// added price when the product is not in the cart yet; adding more of it
+// keeps the price it was first added at. It is a single upsert, so
+// concurrent adds of the same product all count, and none of them can take
+// the quantity past maxQuantity.
+func (r *cartRepository) AddItem(ctx context.Context, owner models.CartOwner, productID int, quantity, maxQuantity int32, price float64) error {
+	if quantity > maxQuantity {
+		return ErrItemQuantityExceeded
+	}
+
 	query := `
-		SELECT quantity FROM cart_items WHERE user_id = $1 AND product_id = $2
+		INSERT INTO cart_items (cart_id, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, product_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+		WHERE cart_items.quantity + EXCLUDED.quantity <= $5
 	`
 
-	var existingQuantity int32
-	err := r.db.QueryRowContext(ctx, query, item.UserID, item.ProductID).Scan(&existingQuantity)
-	if err == sql.ErrNoRows {
-		insertQuery := `
-			INSERT INTO cart_items (user_id, product_id, quantity)
-			VALUES ($1, $2, $3)
-		`
-		_, err := r.db.ExecContext(ctx, insertQuery, item.UserID, item.ProductID, item.Quantity)
-		return err
-	} else if err != nil {
+	result, err := r.db.ExecContext(ctx, query, owner.CartID, productID, quantity, price, maxQuantity)
+	if err != nil {
 		return err
 	}
 
-	updateQuery := `
-		UPDATE cart_items SET quantity = $1 WHERE user_id = $2 AND product_id = $3
-	`
-	_, err = r.db.ExecContext(ctx, updateQuery, existingQuantity+item.Quantity, item.UserID, item.ProductID)
-	return err
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAffected == 0 {
+		return ErrItemQuantityExceeded
+	}
+	return nil
 }
 
-func (r *cartRepository) GetCart(ctx context.Context, userID int) ([]*models.CartItem, error) {
+func (r *cartRepository) GetCart(ctx context.Context, owner models.CartOwner) ([]*models.CartItem, error) {
 	query := `
-		SELECT product_id, quantity FROM cart_items WHERE user_id = $1
+		SELECT product_id, quantity, added_price, created_at, updated_at FROM cart_items WHERE cart_id = $1
 	`
 
-	rows, err := r.db.QueryContext(ctx, query, userID)
+	rows, err := r.db.QueryContext(ctx, query, owner.CartID)
 	if err != nil {
 		return nil, err
 	}
@@ -62,23 +111,42 @@ func (r *cartRepository) GetCart(ctx context.Context, userID int) ([]*models.Car
 
 	var items []*models.CartItem
 	for rows.Next() {
-		item := &models.CartItem{UserID: userID}
-		err := rows.Scan(&item.ProductID, &item.Quantity)
+		item := &models.CartItem{UserID: owner.UserID}
+		var addedPrice sql.NullFloat64
+		err := rows.Scan(&item.ProductID, &item.Quantity, &addedPrice, &item.CreatedAt, &item.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
+		if addedPrice.Valid {
+			item.AddedPrice = &addedPrice.Float64
+		}
 		items = append(items, item)
 	}
 
 	return items, nil
 }
 
-func (r *cartRepository) UpdateItemQuantity(ctx context.Context, item *models.CartItem) error {
+// GetItemQuantity returns the quantity of a product in the cart, or 0 when it
+// is not in the cart.
+func (r *cartRepository) GetItemQuantity(ctx context.Context, owner models.CartOwner, productID int) (int32, error) {
 	query := `
-		UPDATE cart_items SET quantity = $1 WHERE user_id = $2 AND product_id = $3
+		SELECT quantity FROM cart_items WHERE cart_id = $1 AND product_id = $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, item.Quantity, item.UserID, item.ProductID)
+	var quantity int32
+	err := r.db.QueryRowContext(ctx, query, owner.CartID, productID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	return quantity, err
+}
+
+func (r *cartRepository) UpdateItemQuantity(ctx context.Context, owner models.CartOwner, productID int, quantity int32) error {
+	query := `
+		UPDATE cart_items SET quantity = $1, updated_at = NOW() WHERE cart_id = $2 AND product_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, quantity, owner.CartID, productID)
 	if err != nil {
 		return err
 	}
@@ -91,12 +159,12 @@ func (r *cartRepository) UpdateItemQuantity(ctx context.Context, item *models.Ca
 	return nil
 }
 
-func (r *cartRepository) RemoveItem(ctx context.Context, userID, productID int) error {
+func (r *cartRepository) RemoveItem(ctx context.Context, owner models.CartOwner, productID int) error {
 	query := `
-		DELETE FROM cart_items WHERE user_id = $1 AND product_id = $2
+		DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2
 	`
 
-	result, err := r.db.ExecContext(ctx, query, userID, productID)
+	result, err := r.db.ExecContext(ctx, query, owner.CartID, productID)
 	if err != nil {
 		return err
 	}
@@ -109,11 +177,73 @@ func (r *cartRepository) RemoveItem(ctx context.Context, userID, productID int)
 	return nil
 }
 
-func (r *cartRepository) ClearCart(ctx context.Context, userID int) error {
+// ClearCart removes the items of the cart along with its coupons, so a
+// cleared cart starts afresh.
+func (r *cartRepository) ClearCart(ctx context.Context, owner models.CartOwner) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, query := range []string{
+		`DELETE FROM cart_items WHERE cart_id = $1`,
+		`DELETE FROM cart_coupons WHERE cart_id = $1`,
+	} {
+		if _, err := tx.ExecContext(ctx, query, owner.CartID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// AcknowledgePrices sets the added price of the products in prices, for
+// products that are still in the cart, and returns how many were updated.
+func (r *cartRepository) AcknowledgePrices(ctx context.Context, owner models.CartOwner, prices map[int]float64) (int64, error) {
 	query := `
-		DELETE FROM cart_items WHERE user_id = $1
+		UPDATE cart_items SET added_price = $1 WHERE cart_id = $2 AND product_id = $3
 	`
 
-	_, err := r.db.ExecContext(ctx, query, userID)
-	return err
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	var updated int64
+	for productID, price := range prices {
+		result, err := tx.ExecContext(ctx, query, price, owner.CartID, productID)
+		if err != nil {
+			return 0, err
+		}
+		rowsAffected, _ := result.RowsAffected()
+		updated += rowsAffected
+	}
+
+	return updated, tx.Commit()
+}
+
+// EraseUserData deletes the user's carts along with their items and coupons,
+// and the user's wishlists, abandoned-cart events and coupon attempts.
+func (r *cartRepository) EraseUserData(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	queries := []string{
+		`DELETE FROM carts WHERE user_id = $1`,
+		`DELETE FROM wishlists WHERE user_id = $1`,
+		`DELETE FROM abandoned_cart_events WHERE user_id = $1`,
+		`DELETE FROM coupon_attempts WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
 }
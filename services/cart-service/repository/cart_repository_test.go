@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	_ "github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+const concurrentAdds = 50
+
+// addConcurrently calls AddItem for one of the same product from many
+// goroutines at once, allowing up to maxQuantity in the cart, and returns how
+// many of the adds were rejected for exceeding it. Any other error fails the
+// test.
+func addConcurrently(t *testing.T, repo CartRepository, owner models.CartOwner, productID int, maxQuantity int32) int {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, concurrentAdds)
+	for i := 0; i < concurrentAdds; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			errs <- repo.AddItem(context.Background(), owner, productID, 1, maxQuantity, 9.99)
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	rejected := 0
+	for err := range errs {
+		if err == ErrItemQuantityExceeded {
+			rejected++
+		} else if err != nil {
+			t.Fatalf("AddItem failed: %v", err)
+		}
+	}
+	return rejected
+}
+
+func TestAddItemIsOneStatement(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// Any read before the write would leave a window for a concurrent add
+	// to be lost, so nothing but the upsert is expected.
+	mock.MatchExpectationsInOrder(false)
+	for i := 0; i < concurrentAdds; i++ {
+		mock.ExpectExec("INSERT INTO cart_items .* ON CONFLICT \\(cart_id, product_id\\) DO UPDATE SET quantity = cart_items.quantity \\+ EXCLUDED.quantity, .* WHERE cart_items.quantity \\+ EXCLUDED.quantity <= \\$5").
+			WithArgs(1, 10, 1, 9.99, concurrentAdds).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+
+	if rejected := addConcurrently(t, NewCartRepository(db), models.CartOwner{CartID: 1, UserID: 1, Primary: true}, 10, concurrentAdds); rejected != 0 {
+		t.Errorf("Expected no adds to be rejected, got %d", rejected)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+// TestAddItemConcurrently runs against a real database, as only the database
+// can show that concurrent upserts neither lose increments nor collide on
+// the primary key or the quantity limit. It needs CART_TEST_DB_SOURCE to
+// point at a disposable Postgres database, which CI provides; elsewhere it is
+// skipped when the variable is unset.
+func TestAddItemConcurrently(t *testing.T) {
+	dbSource := os.Getenv("CART_TEST_DB_SOURCE")
+	if dbSource == "" {
+		if os.Getenv("CI") != "" {
+			t.Fatal("CART_TEST_DB_SOURCE must be set in CI")
+		}
+		t.Skip("CART_TEST_DB_SOURCE not set")
+	}
+
+	db, err := sql.Open("postgres", dbSource)
+	if err != nil {
+		t.Fatalf("Failed to open database: %v", err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(concurrentAdds)
+
+	migrate(t, db)
+
+	ctx := context.Background()
+	repo := NewCartRepository(db)
+
+	guestCart := &models.GuestCart{TokenHash: "concurrency-test", ExpiresAt: time.Now().Add(time.Hour)}
+	if _, err := db.ExecContext(ctx, `DELETE FROM carts WHERE cart_id IN (SELECT cart_id FROM guest_carts WHERE token_hash = $1)`, guestCart.TokenHash); err != nil {
+		t.Fatalf("Failed to clean up guest cart: %v", err)
+	}
+	if err := repo.CreateGuestCart(ctx, guestCart); err != nil {
+		t.Fatalf("Failed to create guest cart: %v", err)
+	}
+
+	primaryCart, err := repo.GetPrimaryCart(ctx, 1000001)
+	if err != nil {
+		t.Fatalf("Failed to get primary cart: %v", err)
+	}
+
+	for _, owner := range []models.CartOwner{primaryCart.Owner(), {CartID: guestCart.CartID}} {
+		if err := repo.ClearCart(ctx, owner); err != nil {
+			t.Fatalf("Failed to clear %s: %v", owner, err)
+		}
+
+		// The first round races to insert the row, the second to update it.
+		for round := 1; round <= 2; round++ {
+			if rejected := addConcurrently(t, repo, owner, 10, 2*concurrentAdds); rejected != 0 {
+				t.Errorf("Expected no adds to be rejected for %s, got %d", owner, rejected)
+			}
+
+			quantity, err := repo.GetItemQuantity(ctx, owner, 10)
+			if err != nil {
+				t.Fatalf("Failed to get quantity for %s: %v", owner, err)
+			}
+			if want := int32(round * concurrentAdds); quantity != want {
+				t.Errorf("Expected quantity %d for %s, got %d", want, owner, quantity)
+			}
+		}
+
+		// The third round would go past the limit, so every add is rejected.
+		if rejected := addConcurrently(t, repo, owner, 10, 2*concurrentAdds); rejected != concurrentAdds {
+			t.Errorf("Expected all %d adds past the limit to be rejected for %s, got %d", concurrentAdds, owner, rejected)
+		}
+
+		if err := repo.ClearCart(ctx, owner); err != nil {
+			t.Fatalf("Failed to clear %s: %v", owner, err)
+		}
+	}
+}
+
+// migrate applies the service's migrations, which are all idempotent.
+func migrate(t *testing.T, db *sql.DB) {
+	t.Helper()
+
+	files, err := filepath.Glob(filepath.Join("..", "db", "migrations", "*.up.sql"))
+	if err != nil {
+		t.Fatalf("Failed to list migrations: %v", err)
+	}
+	sort.Strings(files)
+
+	for _, file := range files {
+		migration, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("Failed to read %s: %v", file, err)
+		}
+		if _, err := db.Exec(string(migration)); err != nil {
+			t.Fatalf("Failed to apply %s: %v", file, err)
+		}
+	}
+}
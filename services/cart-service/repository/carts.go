@@ -0,0 +1,164 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+var (
+	// ErrCartNotFound is returned when a cart does not exist or belongs to
+	// another user.
+	ErrCartNotFound = errors.New("cart not found")
+	// ErrCartNameTaken is returned when the user already has a named cart
+	// with the requested name.
+	ErrCartNameTaken = errors.New("cart name already in use")
+	// ErrTooManyCarts is returned when the user already has as many named
+	// carts as allowed.
+	ErrTooManyCarts = errors.New("too many carts")
+)
+
+const cartColumns = `cart_id, user_id, name, is_primary, created_at`
+
+func scanCart(row interface{ Scan(...any) error }) (*models.Cart, error) {
+	cart := &models.Cart{}
+	err := row.Scan(&cart.CartID, &cart.UserID, &cart.Name, &cart.Primary, &cart.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// upsertPrimaryCart returns the user's primary cart, creating it if it does
+// not exist yet. The row stays locked until the end of the transaction.
+const upsertPrimaryCart = `
+	INSERT INTO carts (user_id, name, is_primary)
+	VALUES ($1, '', TRUE)
+	ON CONFLICT (user_id) WHERE is_primary
+	DO UPDATE SET is_primary = EXCLUDED.is_primary
+	RETURNING ` + cartColumns
+
+// GetPrimaryCart returns the user's primary cart, creating it the first time
+// it is needed.
+func (r *cartRepository) GetPrimaryCart(ctx context.Context, userID int) (*models.Cart, error) {
+	query := `
+		SELECT ` + cartColumns + `
+		FROM carts
+		WHERE user_id = $1 AND is_primary
+	`
+
+	cart, err := scanCart(r.db.QueryRowContext(ctx, query, userID))
+	if err != ErrCartNotFound {
+		return cart, err
+	}
+
+	return scanCart(r.db.QueryRowContext(ctx, upsertPrimaryCart, userID))
+}
+
+// CreateNamedCart creates a named cart unless the user already has
+// maxNamedCarts of them.
+func (r *cartRepository) CreateNamedCart(ctx context.Context, cart *models.Cart, maxNamedCarts int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// Locking the primary cart makes concurrent creates for the same user
+	// take turns, so they cannot both pass the count below.
+	if _, err := scanCart(tx.QueryRowContext(ctx, upsertPrimaryCart, cart.UserID)); err != nil {
+		return err
+	}
+
+	var count int
+	err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM carts WHERE user_id = $1 AND NOT is_primary
+	`, cart.UserID).Scan(&count)
+	if err != nil {
+		return err
+	}
+	if count >= maxNamedCarts {
+		return ErrTooManyCarts
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO carts (user_id, name)
+		VALUES ($1, $2)
+		RETURNING cart_id, created_at
+	`, cart.UserID, cart.Name).Scan(&cart.CartID, &cart.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrCartNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// ListCarts returns the user's carts, primary cart first, with their item
+// counts but without their items.
+func (r *cartRepository) ListCarts(ctx context.Context, userID int) ([]*models.Cart, error) {
+	query := `
+		SELECT c.cart_id, c.user_id, c.name, c.is_primary, COUNT(i.product_id), COALESCE(SUM(i.quantity), 0), c.created_at
+		FROM carts c
+		LEFT JOIN cart_items i ON i.cart_id = c.cart_id
+		WHERE c.user_id = $1
+		GROUP BY c.cart_id
+		ORDER BY c.is_primary DESC, c.cart_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var carts []*models.Cart
+	for rows.Next() {
+		cart := &models.Cart{}
+		err := rows.Scan(&cart.CartID, &cart.UserID, &cart.Name, &cart.Primary, &cart.ItemCount, &cart.TotalQuantity, &cart.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		carts = append(carts, cart)
+	}
+
+	return carts, rows.Err()
+}
+
+// GetUserCart returns one of the user's carts without its items.
+func (r *cartRepository) GetUserCart(ctx context.Context, cartID, userID int) (*models.Cart, error) {
+	query := `
+		SELECT ` + cartColumns + `
+		FROM carts
+		WHERE cart_id = $1 AND user_id = $2
+	`
+
+	return scanCart(r.db.QueryRowContext(ctx, query, cartID, userID))
+}
+
+// DeleteNamedCart deletes a named cart along with its items and coupons. The
+// primary cart is never deleted.
+func (r *cartRepository) DeleteNamedCart(ctx context.Context, cartID, userID int) error {
+	query := `
+		DELETE FROM carts WHERE cart_id = $1 AND user_id = $2 AND NOT is_primary
+	`
+
+	result, err := r.db.ExecContext(ctx, query, cartID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrCartNotFound
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+var ErrCouponNotFound = errors.New("coupon not found in cart")
+
+// Coupons are only kept on carts of signed-in users. Whether a coupon is
+// valid is up to Order Service; a cart just remembers the codes.
+
+// RecordCouponAttempt counts an attempt by the user to apply a coupon and
+// returns how many they have made in the current window. A window starts
+// with the first attempt after the previous one has lasted window.
+func (r *cartRepository) RecordCouponAttempt(ctx context.Context, userID int, window time.Duration) (int, error) {
+	query := `
+		INSERT INTO coupon_attempts (user_id, attempts, window_started_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (user_id) DO UPDATE SET
+			attempts = CASE
+				WHEN coupon_attempts.window_started_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE coupon_attempts.attempts + 1
+			END,
+			window_started_at = CASE
+				WHEN coupon_attempts.window_started_at < NOW() - make_interval(secs => $2) THEN NOW()
+				ELSE coupon_attempts.window_started_at
+			END
+		RETURNING attempts
+	`
+
+	var attempts int
+	err := r.db.QueryRowContext(ctx, query, userID, window.Seconds()).Scan(&attempts)
+	return attempts, err
+}
+
+// AddCartCoupon adds a coupon code to the cart. Adding a code that is already
+// there does nothing.
+func (r *cartRepository) AddCartCoupon(ctx context.Context, owner models.CartOwner, code string) error {
+	query := `
+		INSERT INTO cart_coupons (cart_id, code) VALUES ($1, $2)
+		ON CONFLICT (cart_id, code) DO NOTHING
+	`
+
+	_, err := r.db.ExecContext(ctx, query, owner.CartID, code)
+	return err
+}
+
+func (r *cartRepository) RemoveCartCoupon(ctx context.Context, owner models.CartOwner, code string) error {
+	query := `
+		DELETE FROM cart_coupons WHERE cart_id = $1 AND code = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, owner.CartID, code)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrCouponNotFound
+	}
+
+	return nil
+}
+
+// ListCartCoupons returns the coupon codes on the cart in the order they were
+// added.
+func (r *cartRepository) ListCartCoupons(ctx context.Context, owner models.CartOwner) ([]string, error) {
+	query := `
+		SELECT code FROM cart_coupons WHERE cart_id = $1 ORDER BY added_at, code
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, owner.CartID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var codes []string
+	for rows.Next() {
+		var code string
+		if err := rows.Scan(&code); err != nil {
+			return nil, err
+		}
+		codes = append(codes, code)
+	}
+	return codes, rows.Err()
+}
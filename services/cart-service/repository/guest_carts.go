@@ -0,0 +1,148 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+// ErrGuestCartNotFound is returned when no unexpired guest cart matches a
+// cart token.
+var ErrGuestCartNotFound = errors.New("guest cart not found")
+
+// CreateGuestCart creates a cart without a user, which the token hashed into
+// cart.TokenHash identifies until cart.ExpiresAt.
+func (r *cartRepository) CreateGuestCart(ctx context.Context, cart *models.GuestCart) error {
+	query := `
+		WITH cart AS (
+			INSERT INTO carts (name) VALUES ('')
+			RETURNING cart_id
+		)
+		INSERT INTO guest_carts (cart_id, token_hash, expires_at)
+		SELECT cart_id, $1, $2 FROM cart
+		RETURNING cart_id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query, cart.TokenHash, cart.ExpiresAt).Scan(&cart.CartID, &cart.CreatedAt)
+}
+
+func (r *cartRepository) GetGuestCartByToken(ctx context.Context, tokenHash string) (*models.GuestCart, error) {
+	query := `
+		SELECT cart_id, token_hash, created_at, expires_at
+		FROM guest_carts
+		WHERE token_hash = $1 AND expires_at > NOW()
+	`
+
+	cart := &models.GuestCart{}
+	err := r.db.QueryRowContext(ctx, query, tokenHash).Scan(&cart.CartID, &cart.TokenHash, &cart.CreatedAt, &cart.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrGuestCartNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cart, nil
+}
+
+// MergeGuestCart moves the items of a guest cart into one of the user's carts
+// and deletes the guest cart. Products already in the user's cart get the
+// quantity strategy resolves them to and keep their added price.
+func (r *cartRepository) MergeGuestCart(ctx context.Context, guestCartID int, owner models.CartOwner, strategy models.MergeStrategy, maxQuantity int32) (*models.MergeResult, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Deleting the guest cart's items first locks them, so a concurrent
+	// merge of the same cart finds nothing to merge.
+	rows, err := tx.QueryContext(ctx, `
+		DELETE FROM cart_items WHERE cart_id = $1
+		RETURNING product_id, quantity, added_price
+	`, guestCartID)
+	if err != nil {
+		return nil, err
+	}
+
+	var guestItems []*models.CartItem
+	for rows.Next() {
+		item := &models.CartItem{}
+		var addedPrice sql.NullFloat64
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &addedPrice); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if addedPrice.Valid {
+			item.AddedPrice = &addedPrice.Float64
+		}
+		guestItems = append(guestItems, item)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM carts WHERE cart_id = $1`, guestCartID); err != nil {
+		return nil, err
+	}
+
+	result := &models.MergeResult{}
+	for _, item := range guestItems {
+		// A product added to the user's cart concurrently makes the insert
+		// conflict instead of fail, and is then resolved like any other.
+		res, err := tx.ExecContext(ctx, `
+			INSERT INTO cart_items (cart_id, product_id, quantity, added_price)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (cart_id, product_id) DO NOTHING
+		`, owner.CartID, item.ProductID, min(item.Quantity, maxQuantity), item.AddedPrice)
+		if err != nil {
+			return nil, err
+		}
+		inserted, err := res.RowsAffected()
+		if err != nil {
+			return nil, err
+		}
+		if inserted == 1 {
+			result.ItemsAdded++
+			continue
+		}
+
+		var userQuantity int32
+		err = tx.QueryRowContext(ctx, `
+			SELECT quantity FROM cart_items WHERE cart_id = $1 AND product_id = $2 FOR UPDATE
+		`, owner.CartID, item.ProductID).Scan(&userQuantity)
+		if err != nil {
+			return nil, err
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			UPDATE cart_items SET quantity = $1, updated_at = NOW() WHERE cart_id = $2 AND product_id = $3
+		`, strategy.Resolve(userQuantity, item.Quantity, maxQuantity), owner.CartID, item.ProductID)
+		if err != nil {
+			return nil, err
+		}
+		result.Conflicts++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// DeleteExpiredGuestCarts deletes expired guest carts along with their items
+// and returns how many were deleted.
+func (r *cartRepository) DeleteExpiredGuestCarts(ctx context.Context) (int64, error) {
+	query := `
+		DELETE FROM carts
+		WHERE cart_id IN (SELECT cart_id FROM guest_carts WHERE expires_at <= NOW())
+	`
+
+	result, err := r.db.ExecContext(ctx, query)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
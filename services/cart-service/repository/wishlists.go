@@ -0,0 +1,316 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/models"
+)
+
+var (
+	// ErrWishlistNotFound is returned when a wishlist does not exist or
+	// belongs to another user.
+	ErrWishlistNotFound = errors.New("wishlist not found")
+	// ErrWishlistNameTaken is returned when the user already has a wishlist
+	// with the requested name.
+	ErrWishlistNameTaken = errors.New("wishlist name already in use")
+	// ErrWishlistItemNotFound is returned when a product is not in a wishlist.
+	ErrWishlistItemNotFound = errors.New("item not found in wishlist")
+)
+
+const wishlistColumns = `wishlist_id, user_id, name, share_token_hash IS NOT NULL, created_at, updated_at`
+
+func scanWishlist(row interface{ Scan(...any) error }) (*models.Wishlist, error) {
+	wishlist := &models.Wishlist{}
+	err := row.Scan(&wishlist.WishlistID, &wishlist.UserID, &wishlist.Name, &wishlist.Shared, &wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrWishlistNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (r *cartRepository) CreateWishlist(ctx context.Context, wishlist *models.Wishlist) error {
+	query := `
+		INSERT INTO wishlists (user_id, name)
+		VALUES ($1, $2)
+		RETURNING wishlist_id, created_at, updated_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query, wishlist.UserID, wishlist.Name).Scan(&wishlist.WishlistID, &wishlist.CreatedAt, &wishlist.UpdatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrWishlistNameTaken
+	}
+	return err
+}
+
+// ListWishlists returns the user's wishlists without their items.
+func (r *cartRepository) ListWishlists(ctx context.Context, userID int) ([]*models.Wishlist, error) {
+	query := `
+		SELECT ` + wishlistColumns + `
+		FROM wishlists
+		WHERE user_id = $1
+		ORDER BY wishlist_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var wishlists []*models.Wishlist
+	for rows.Next() {
+		wishlist, err := scanWishlist(rows)
+		if err != nil {
+			return nil, err
+		}
+		wishlists = append(wishlists, wishlist)
+	}
+
+	return wishlists, rows.Err()
+}
+
+// GetWishlist returns one of the user's wishlists with its items.
+func (r *cartRepository) GetWishlist(ctx context.Context, wishlistID, userID int) (*models.Wishlist, error) {
+	query := `
+		SELECT ` + wishlistColumns + `
+		FROM wishlists
+		WHERE wishlist_id = $1 AND user_id = $2
+	`
+
+	wishlist, err := scanWishlist(r.db.QueryRowContext(ctx, query, wishlistID, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist.Items, err = r.getWishlistItems(ctx, wishlist.WishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+// GetWishlistByShareToken returns the wishlist shared under a token, with its
+// items.
+func (r *cartRepository) GetWishlistByShareToken(ctx context.Context, tokenHash string) (*models.Wishlist, error) {
+	query := `
+		SELECT ` + wishlistColumns + `
+		FROM wishlists
+		WHERE share_token_hash = $1
+	`
+
+	wishlist, err := scanWishlist(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil {
+		return nil, err
+	}
+
+	wishlist.Items, err = r.getWishlistItems(ctx, wishlist.WishlistID)
+	if err != nil {
+		return nil, err
+	}
+	return wishlist, nil
+}
+
+func (r *cartRepository) getWishlistItems(ctx context.Context, wishlistID int) ([]*models.WishlistItem, error) {
+	query := `
+		SELECT product_id, quantity, added_at
+		FROM wishlist_items
+		WHERE wishlist_id = $1
+		ORDER BY added_at, product_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []*models.WishlistItem
+	for rows.Next() {
+		item := &models.WishlistItem{}
+		if err := rows.Scan(&item.ProductID, &item.Quantity, &item.AddedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+
+	return items, rows.Err()
+}
+
+func (r *cartRepository) RenameWishlist(ctx context.Context, wishlistID, userID int, name string) error {
+	query := `
+		UPDATE wishlists SET name = $1, updated_at = NOW()
+		WHERE wishlist_id = $2 AND user_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, name, wishlistID, userID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrWishlistNameTaken
+		}
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWishlistNotFound
+	}
+
+	return nil
+}
+
+func (r *cartRepository) DeleteWishlist(ctx context.Context, wishlistID, userID int) error {
+	query := `
+		DELETE FROM wishlists WHERE wishlist_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, wishlistID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWishlistNotFound
+	}
+
+	return nil
+}
+
+// SetWishlistShareToken sets the hash of the token the wishlist is shared
+// under, replacing any earlier one. An empty hash stops sharing it.
+func (r *cartRepository) SetWishlistShareToken(ctx context.Context, wishlistID, userID int, tokenHash string) error {
+	query := `
+		UPDATE wishlists SET share_token_hash = NULLIF($1, ''), updated_at = NOW()
+		WHERE wishlist_id = $2 AND user_id = $3
+	`
+
+	result, err := r.db.ExecContext(ctx, query, tokenHash, wishlistID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWishlistNotFound
+	}
+
+	return nil
+}
+
+// AddWishlistItem adds quantity of a product to a wishlist, adding to the
+// quantity already there, capped at maxQuantity.
+func (r *cartRepository) AddWishlistItem(ctx context.Context, wishlistID, productID int, quantity, maxQuantity int32) error {
+	query := `
+		INSERT INTO wishlist_items (wishlist_id, product_id, quantity)
+		VALUES ($1, $2, LEAST($3, $4))
+		ON CONFLICT (wishlist_id, product_id)
+		DO UPDATE SET quantity = LEAST(wishlist_items.quantity + EXCLUDED.quantity, $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, wishlistID, productID, quantity, maxQuantity)
+	return err
+}
+
+func (r *cartRepository) RemoveWishlistItem(ctx context.Context, wishlistID, productID int) error {
+	query := `
+		DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, wishlistID, productID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrWishlistItemNotFound
+	}
+
+	return nil
+}
+
+// MoveWishlistItemToCart removes a product from a wishlist and adds its
+// quantity to one of the user's carts, with price as its added price if it
+// was not in the cart yet.
+func (r *cartRepository) MoveWishlistItemToCart(ctx context.Context, wishlistID int, owner models.CartOwner, productID int, price float64) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var quantity int32
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM wishlist_items WHERE wishlist_id = $1 AND product_id = $2
+		RETURNING quantity
+	`, wishlistID, productID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return ErrWishlistItemNotFound
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO cart_items (cart_id, product_id, quantity, added_price)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (cart_id, product_id)
+		DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity, updated_at = NOW()
+	`, owner.CartID, productID, quantity, price)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// MoveCartItemToWishlist removes a product from one of the user's carts and
+// adds its quantity to a wishlist. It returns ErrItemQuantityExceeded, and
+// leaves the cart as it was, when the wishlist would hold more than
+// maxQuantity of the product.
+func (r *cartRepository) MoveCartItemToWishlist(ctx context.Context, owner models.CartOwner, wishlistID, productID int, maxQuantity int32) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var quantity int32
+	err = tx.QueryRowContext(ctx, `
+		DELETE FROM cart_items WHERE cart_id = $1 AND product_id = $2
+		RETURNING quantity
+	`, owner.CartID, productID).Scan(&quantity)
+	if err == sql.ErrNoRows {
+		return errors.New("item not found in cart")
+	}
+	if err != nil {
+		return err
+	}
+	if quantity > maxQuantity {
+		return ErrItemQuantityExceeded
+	}
+
+	result, err := tx.ExecContext(ctx, `
+		INSERT INTO wishlist_items (wishlist_id, product_id, quantity)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (wishlist_id, product_id)
+		DO UPDATE SET quantity = wishlist_items.quantity + EXCLUDED.quantity
+		WHERE wishlist_items.quantity + EXCLUDED.quantity <= $4
+	`, wishlistID, productID, quantity, maxQuantity)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrItemQuantityExceeded
+	}
+
+	return tx.Commit()
+}
@@ -0,0 +1,59 @@
+package sweeper
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+)
+
+// Sweeper periodically deletes expired guest carts and records events for
+// carts that have become abandoned.
+type Sweeper struct {
+	repo     repository.CartRepository
+	interval time.Duration
+	// abandonedAfter is how long a cart must be idle to count as abandoned.
+	abandonedAfter time.Duration
+}
+
+func New(repo repository.CartRepository, interval, abandonedAfter time.Duration) *Sweeper {
+	return &Sweeper{
+		repo:           repo,
+		interval:       interval,
+		abandonedAfter: abandonedAfter,
+	}
+}
+
+// Run sweeps every interval until ctx is cancelled.
+func (s *Sweeper) Run(ctx context.Context) {
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	for {
+		s.Sweep(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Sweep runs a single sweep. Failures are logged and retried on the next one.
+func (s *Sweeper) Sweep(ctx context.Context) {
+	deleted, err := s.repo.DeleteExpiredGuestCarts(ctx)
+	if err != nil {
+		log.Printf("Failed to delete expired guest carts: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Deleted %d expired guest carts", deleted)
+	}
+
+	recorded, err := s.repo.RecordAbandonedCarts(ctx, time.Now().Add(-s.abandonedAfter))
+	if err != nil {
+		log.Printf("Failed to record abandoned carts: %v", err)
+	} else if recorded > 0 {
+		log.Printf("Recorded %d abandoned carts", recorded)
+	}
+}
@@ -0,0 +1,33 @@
+package sweeper
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/cart-service/repository"
+)
+
+func TestSweep(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// A failure to delete guest carts does not stop abandoned carts from
+	// being recorded.
+	mock.ExpectExec("DELETE FROM carts WHERE cart_id IN \\(SELECT cart_id FROM guest_carts WHERE expires_at").
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectExec("INSERT INTO abandoned_cart_events").
+		WithArgs(sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+
+	New(repository.NewCartRepository(db), time.Minute, 24*time.Hour).Sweep(context.Background())
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,24 @@
+package auth
+
+import (
+	"context"
+
+	"google.golang.org/grpc/metadata"
+)
+
+// ForwardAuthorization copies the caller's credentials, a bearer token or an
+// API key, onto the outgoing context, so that calls to other services are
+// made on behalf of the same user.
+func ForwardAuthorization(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	for _, header := range []string{"authorization", APIKeyHeader} {
+		if values := md.Get(header); len(values) > 0 {
+			ctx = metadata.AppendToOutgoingContext(ctx, header, values[0])
+		}
+	}
+	return ctx
+}
@@ -11,7 +11,11 @@ import (
 
 type AuthClaims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID       int
+	Role         string
+	TokenVersion int
+	// ImpersonatorID is set on tokens an admin obtained with ImpersonateUser.
+	ImpersonatorID int `json:",omitempty"`
 }
 
 func ExtractTokenFromMetadata(ctx context.Context) (string, error) {
@@ -0,0 +1,32 @@
+package auth
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// checkSession asks User Service whether a token is still valid: whether the
+// session it was issued for is still active and no password change has
+// revoked it since, so that both take effect here too. Disabled accounts are
+// refused as well. The response carries the user's current state.
+func checkSession(ctx context.Context, userClient userpb.UserServiceClient, claims *AuthClaims) (*userpb.CheckSessionResponse, error) {
+	resp, err := userClient.CheckSession(ctx, &userpb.CheckSessionRequest{
+		SessionId:    claims.ID,
+		UserId:       strconv.Itoa(claims.UserID),
+		TokenVersion: int32(claims.TokenVersion),
+	})
+	if err != nil {
+		if status.Code(err) == codes.Unauthenticated {
+			return nil, status.Errorf(codes.Unauthenticated, "token has been revoked")
+		}
+		if status.Code(err) == codes.PermissionDenied {
+			return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+		}
+		return nil, status.Errorf(codes.Unavailable, "failed to check session: %v", err)
+	}
+	return resp, nil
+}
@@ -13,6 +13,7 @@ import (
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/productpb"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/userpb"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/utils"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -37,8 +38,6 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", cfg.Port, err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey)))
-
 	// Consul client
 	consulConfig := consulapi.DefaultConfig()
 	consulConfig.Address = cfg.ConsulAddress
@@ -60,7 +59,27 @@ func main() {
 	defer productConn.Close()
 	productClient := productpb.NewProductServiceClient(productConn)
 
-	orderpb.RegisterOrderServiceServer(grpcServer, handlers.NewOrderServiceServer(orderRepo, cfg.JWTSecretKey, cfg.StripeAPIKey, consulClient, productClient))
+	userServiceAddress, err := utils.GetServiceAddress(consulClient, "user-service")
+	if err != nil {
+		log.Fatalf("Failed to get User Service address: %v", err)
+	}
+
+	// gRPC connection with User Service
+	userConn, err := grpc.NewClient(userServiceAddress, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		log.Fatalf("Failed to connect to User Service: %v", err)
+	}
+	defer userConn.Close()
+	userClient := userpb.NewUserServiceClient(userConn)
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey, userClient)))
+
+	requireVerifiedEmail, err := strconv.ParseBool(cfg.RequireVerifiedEmail)
+	if err != nil {
+		log.Fatalf("Invalid REQUIRE_VERIFIED_EMAIL value: %v", err)
+	}
+
+	orderpb.RegisterOrderServiceServer(grpcServer, handlers.NewOrderServiceServer(orderRepo, cfg.JWTSecretKey, cfg.StripeAPIKey, consulClient, productClient, userClient, requireVerifiedEmail))
 
 	healthServer := health.NewServer()
 	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
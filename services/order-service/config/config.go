@@ -15,6 +15,8 @@ type Config struct {
 	ConsulAddress string
 	ServiceName   string
 	ServiceHost   string
+
+	RequireVerifiedEmail string
 }
 
 func LoadConfig() *Config {
@@ -30,6 +32,8 @@ func LoadConfig() *Config {
 		ConsulAddress: getEnv("CONSUL_ADDRESS", "consul:8500"),
 		ServiceName:   getEnv("SERVICE_NAME", "user-service"),
 		ServiceHost:   getEnv("SERVICE_HOST", "user-service"),
+
+		RequireVerifiedEmail: getEnv("REQUIRE_VERIFIED_EMAIL", "false"),
 	}
 
 	return config
@@ -0,0 +1,9 @@
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS shipping_full_name VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_line1 VARCHAR(200) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_line2 VARCHAR(200) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_city VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_region VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_postcode VARCHAR(20) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_country VARCHAR(2) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_phone VARCHAR(30) NOT NULL DEFAULT '';
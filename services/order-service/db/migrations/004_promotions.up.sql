@@ -0,0 +1,34 @@
+CREATE TABLE IF NOT EXISTS promotions (
+  promotion_id SERIAL PRIMARY KEY,
+  -- NULL for promotions that apply without a coupon.
+  code VARCHAR(50) UNIQUE,
+  description TEXT NOT NULL DEFAULT '',
+  type VARCHAR(20) NOT NULL,
+  value NUMERIC(10, 2) NOT NULL DEFAULT 0,
+  product_id INT,
+  buy_quantity INT NOT NULL DEFAULT 0,
+  get_quantity INT NOT NULL DEFAULT 0,
+  min_subtotal NUMERIC(10, 2) NOT NULL DEFAULT 0,
+  max_uses INT NOT NULL DEFAULT 0,
+  max_uses_per_user INT NOT NULL DEFAULT 0,
+  starts_at TIMESTAMP,
+  ends_at TIMESTAMP,
+  stackable BOOLEAN NOT NULL DEFAULT FALSE,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS order_discounts (
+  order_id INT NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+  promotion_id INT NOT NULL REFERENCES promotions (promotion_id),
+  code VARCHAR(50) NOT NULL DEFAULT '',
+  description TEXT NOT NULL DEFAULT '',
+  amount NUMERIC(10, 2) NOT NULL,
+  PRIMARY KEY (order_id, promotion_id)
+);
+
+-- Usage of a promotion is counted from the orders it was applied to.
+CREATE INDEX IF NOT EXISTS idx_order_discounts_promotion_id ON order_discounts (promotion_id);
+
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS discount_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
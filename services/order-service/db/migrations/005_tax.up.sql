@@ -0,0 +1,31 @@
+CREATE TABLE IF NOT EXISTS tax_rules (
+  tax_rule_id SERIAL PRIMARY KEY,
+  country VARCHAR(2) NOT NULL,
+  -- Empty for rules that apply to the whole country.
+  region VARCHAR(100) NOT NULL DEFAULT '',
+  -- Empty for rules that apply to every product tax category.
+  tax_category VARCHAR(50) NOT NULL DEFAULT '',
+  name VARCHAR(100) NOT NULL,
+  rate NUMERIC(6, 3) NOT NULL,
+  -- Inclusive rates are already part of the product prices.
+  inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (country, region, tax_category)
+);
+
+-- Tax lines are a record of what was charged, so they keep the rule's name
+-- and rate and outlive the rule.
+CREATE TABLE IF NOT EXISTS order_tax_lines (
+  order_id INT NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+  tax_rule_id INT REFERENCES tax_rules (tax_rule_id) ON DELETE SET NULL,
+  name VARCHAR(100) NOT NULL,
+  rate NUMERIC(6, 3) NOT NULL,
+  inclusive BOOLEAN NOT NULL,
+  taxable_amount NUMERIC(10, 2) NOT NULL,
+  amount NUMERIC(10, 2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_tax_lines_order_id ON order_tax_lines (order_id);
+
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS tax_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
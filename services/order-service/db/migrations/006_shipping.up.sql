@@ -0,0 +1,38 @@
+CREATE TABLE IF NOT EXISTS shipping_zones (
+  zone_id SERIAL PRIMARY KEY,
+  name VARCHAR(100) NOT NULL UNIQUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- A country belongs to at most one zone.
+CREATE TABLE IF NOT EXISTS shipping_zone_countries (
+  country VARCHAR(2) PRIMARY KEY,
+  zone_id INT NOT NULL REFERENCES shipping_zones (zone_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS shipping_methods (
+  method_id SERIAL PRIMARY KEY,
+  carrier VARCHAR(100) NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  min_delivery_days INT NOT NULL DEFAULT 0,
+  max_delivery_days INT NOT NULL DEFAULT 0,
+  active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- The rate table of a method: the price of a parcel to a zone, by weight
+-- bracket.
+CREATE TABLE IF NOT EXISTS shipping_rates (
+  method_id INT NOT NULL REFERENCES shipping_methods (method_id) ON DELETE CASCADE,
+  zone_id INT NOT NULL REFERENCES shipping_zones (zone_id) ON DELETE CASCADE,
+  -- 0 for no upper limit.
+  max_weight_grams INT NOT NULL,
+  price NUMERIC(10, 2) NOT NULL,
+  PRIMARY KEY (method_id, zone_id, max_weight_grams)
+);
+
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS shipping_method_id INT REFERENCES shipping_methods (method_id),
+  ADD COLUMN IF NOT EXISTS shipping_carrier VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_method VARCHAR(100) NOT NULL DEFAULT '',
+  ADD COLUMN IF NOT EXISTS shipping_amount NUMERIC(10, 2) NOT NULL DEFAULT 0;
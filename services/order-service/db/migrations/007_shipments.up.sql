@@ -0,0 +1,30 @@
+CREATE TABLE IF NOT EXISTS shipments (
+  shipment_id SERIAL PRIMARY KEY,
+  order_id INT NOT NULL REFERENCES orders (order_id) ON DELETE CASCADE,
+  carrier VARCHAR(100) NOT NULL DEFAULT '',
+  tracking_number VARCHAR(100) NOT NULL DEFAULT '',
+  status VARCHAR(20) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments (order_id);
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+  shipment_id INT NOT NULL REFERENCES shipments (shipment_id) ON DELETE CASCADE,
+  product_id INT NOT NULL,
+  quantity INT NOT NULL,
+  PRIMARY KEY (shipment_id, product_id)
+);
+
+-- The tracking history of a shipment, one event per status change.
+CREATE TABLE IF NOT EXISTS shipment_events (
+  event_id SERIAL PRIMARY KEY,
+  shipment_id INT NOT NULL REFERENCES shipments (shipment_id) ON DELETE CASCADE,
+  status VARCHAR(20) NOT NULL,
+  description TEXT NOT NULL DEFAULT '',
+  location VARCHAR(200) NOT NULL DEFAULT '',
+  occurred_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_events_shipment_id ON shipment_events (shipment_id);
@@ -0,0 +1,5 @@
+-- Orders are saved as pending before the customer is charged, so that
+-- promotion usage is reserved first; the payment intent is recorded once the
+-- charge succeeds.
+ALTER TABLE orders
+  ADD COLUMN IF NOT EXISTS payment_intent_id VARCHAR(255) NOT NULL DEFAULT '';
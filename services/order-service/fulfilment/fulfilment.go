@@ -0,0 +1,62 @@
+// Package fulfilment derives an order's status from what its shipments hold.
+package fulfilment
+
+import "github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+
+// Shippable reports whether an order in status can have shipments, which is
+// once it has been paid for.
+func Shippable(status string) bool {
+	switch status {
+	case models.OrderConfirmed, models.OrderPartiallyShipped, models.OrderShipped, models.OrderDelivered:
+		return true
+	}
+	return false
+}
+
+// Remaining returns how many units of each product of items are not in any
+// of shipments yet. Products that are fully shipped are left out.
+func Remaining(items []models.OrderItem, shipments []*models.Shipment) map[int]int32 {
+	remaining := make(map[int]int32)
+	for _, item := range items {
+		remaining[item.ProductID] += item.Quantity
+	}
+	for _, shipment := range shipments {
+		for _, item := range shipment.Items {
+			remaining[item.ProductID] -= item.Quantity
+		}
+	}
+
+	for productID, quantity := range remaining {
+		if quantity <= 0 {
+			delete(remaining, productID)
+		}
+	}
+	return remaining
+}
+
+// OrderStatus returns the status of an order of items given its shipments.
+// A shipment counts once it has left, that is once it is no longer pending.
+func OrderStatus(items []models.OrderItem, shipments []*models.Shipment) string {
+	var departed []*models.Shipment
+	allDelivered := true
+	for _, shipment := range shipments {
+		if shipment.Status == models.ShipmentPending {
+			continue
+		}
+		departed = append(departed, shipment)
+		if shipment.Status != models.ShipmentDelivered {
+			allDelivered = false
+		}
+	}
+
+	switch {
+	case len(departed) == 0:
+		return models.OrderConfirmed
+	case len(Remaining(items, departed)) > 0:
+		return models.OrderPartiallyShipped
+	case allDelivered:
+		return models.OrderDelivered
+	default:
+		return models.OrderShipped
+	}
+}
@@ -0,0 +1,72 @@
+package fulfilment
+
+import (
+	"testing"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var items = []models.OrderItem{
+	{ProductID: 1, Quantity: 3},
+	{ProductID: 2, Quantity: 1},
+}
+
+func shipment(status string, shipped ...models.ShipmentItem) *models.Shipment {
+	return &models.Shipment{Status: status, Items: shipped}
+}
+
+func TestShippable(t *testing.T) {
+	for _, status := range []string{models.OrderConfirmed, models.OrderPartiallyShipped} {
+		if !Shippable(status) {
+			t.Errorf("Expected a %q order to be shippable", status)
+		}
+	}
+	for _, status := range []string{models.OrderPendingPayment, models.OrderPaymentFailed} {
+		if Shippable(status) {
+			t.Errorf("Expected a %q order not to be shippable", status)
+		}
+	}
+}
+
+func TestRemaining(t *testing.T) {
+	shipments := []*models.Shipment{
+		shipment(models.ShipmentPending, models.ShipmentItem{ProductID: 1, Quantity: 2}),
+		shipment(models.ShipmentInTransit, models.ShipmentItem{ProductID: 2, Quantity: 1}),
+	}
+
+	remaining := Remaining(items, shipments)
+	if len(remaining) != 1 || remaining[1] != 1 {
+		t.Errorf("Expected 1 unit of product 1 left, got %v", remaining)
+	}
+}
+
+func TestOrderStatus(t *testing.T) {
+	all := []models.ShipmentItem{{ProductID: 1, Quantity: 3}, {ProductID: 2, Quantity: 1}}
+
+	tests := []struct {
+		name      string
+		shipments []*models.Shipment
+		want      string
+	}{
+		{"no shipments", nil, models.OrderConfirmed},
+		{"only pending", []*models.Shipment{shipment(models.ShipmentPending, all...)}, models.OrderConfirmed},
+		{"one parcel of two left", []*models.Shipment{
+			shipment(models.ShipmentInTransit, all[0]),
+			shipment(models.ShipmentPending, all[1]),
+		}, models.OrderPartiallyShipped},
+		{"everything left", []*models.Shipment{
+			shipment(models.ShipmentDelivered, all[0]),
+			shipment(models.ShipmentOutForDelivery, all[1]),
+		}, models.OrderShipped},
+		{"everything delivered", []*models.Shipment{
+			shipment(models.ShipmentDelivered, all[0]),
+			shipment(models.ShipmentDelivered, all[1]),
+		}, models.OrderDelivered},
+	}
+
+	for _, tt := range tests {
+		if got := OrderStatus(items, tt.shipments); got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
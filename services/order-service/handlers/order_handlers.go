@@ -3,6 +3,9 @@ package handlers
 import (
 	"context"
 	"database/sql"
+	"fmt"
+	"log"
+	"math"
 	"strconv"
 	"time"
 
@@ -11,8 +14,12 @@ import (
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
 	productpb "github.com/metal-oopa/EcomMicroservices/services/order-service/productpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/promotions"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/tax"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/userpb"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -24,9 +31,14 @@ type OrderServiceServer struct {
 	stripeAPIKey  string
 	consulClient  *consulapi.Client
 	productClient productpb.ProductServiceClient
+	userClient    userpb.UserServiceClient
+
+	// requireVerifiedEmail rejects CreateOrder for accounts whose email
+	// address has not been verified.
+	requireVerifiedEmail bool
 }
 
-func NewOrderServiceServer(repo repository.OrderRepository, jwtSecretKey, stripeAPIKey string, consulClient *consulapi.Client, productClient productpb.ProductServiceClient) orderpb.OrderServiceServer {
+func NewOrderServiceServer(repo repository.OrderRepository, jwtSecretKey, stripeAPIKey string, consulClient *consulapi.Client, productClient productpb.ProductServiceClient, userClient userpb.UserServiceClient, requireVerifiedEmail bool) orderpb.OrderServiceServer {
 	utils.InitializeStripe(stripeAPIKey)
 
 	return &OrderServiceServer{
@@ -35,6 +47,9 @@ func NewOrderServiceServer(repo repository.OrderRepository, jwtSecretKey, stripe
 		stripeAPIKey:  stripeAPIKey,
 		consulClient:  consulClient,
 		productClient: productClient,
+		userClient:    userClient,
+
+		requireVerifiedEmail: requireVerifiedEmail,
 	}
 }
 
@@ -49,14 +64,41 @@ func (s *OrderServiceServer) CreateOrder(ctx context.Context, req *orderpb.Creat
 		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
 	}
 
+	if s.requireVerifiedEmail {
+		if verified, _ := ctx.Value(auth.EmailVerifiedKey).(bool); !verified {
+			return nil, status.Errorf(codes.FailedPrecondition, "email address must be verified before placing an order")
+		}
+	}
+
+	// Tax is charged by the jurisdiction the order ships to, so every order
+	// needs an address.
+	if req.ShippingAddressId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "shipping address ID is required")
+	}
+
+	address, err := s.getShippingAddress(ctx, req.ShippingAddressId)
+	if err != nil {
+		return nil, err
+	}
+	shippingAddress := *address
+
 	// product prices from Product Service
-	var totalAmount float64
 	var orderItems []models.OrderItem
+	var lines []promotions.Line
+	var taxableLines []tax.Line
+	var weight int64
+	var priceChanges []*errdetails.PreconditionFailure_Violation
 
 	for _, item := range req.Items {
 		productID, err := strconv.Atoi(item.ProductId)
-		if err != nil {
-			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+		if err != nil || item.Quantity <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID or quantity")
+		}
+
+		// Without the price the shopper saw, a price change could be
+		// charged unnoticed.
+		if item.ExpectedUnitPrice <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "expected unit price is required for every item")
 		}
 
 		productResp, err := s.productClient.GetProduct(ctx, &productpb.GetProductRequest{
@@ -76,8 +118,26 @@ func (s *OrderServiceServer) CreateOrder(ctx context.Context, req *orderpb.Creat
 			return nil, status.Errorf(codes.InvalidArgument, "product %s is out of stock", product.Name)
 		}
 
-		itemTotal := product.Price * float64(item.Quantity)
-		totalAmount += itemTotal
+		if math.Round(item.ExpectedUnitPrice*100) != math.Round(product.Price*100) {
+			priceChanges = append(priceChanges, &errdetails.PreconditionFailure_Violation{
+				Type:        "PRICE_CHANGED",
+				Subject:     "product:" + item.ProductId,
+				Description: fmt.Sprintf("the price of %s changed from %.2f to %.2f", product.Name, item.ExpectedUnitPrice, product.Price),
+			})
+		}
+
+		lines = append(lines, promotions.Line{
+			ProductID: productID,
+			Quantity:  item.Quantity,
+			UnitPrice: product.Price,
+		})
+
+		taxableLines = append(taxableLines, tax.Line{
+			TaxCategory: product.TaxCategory,
+			Amount:      promotions.RoundPrice(product.Price * float64(item.Quantity)),
+		})
+
+		weight += int64(product.WeightGrams) * int64(item.Quantity)
 
 		orderItem := models.OrderItem{
 			ProductID: productID,
@@ -86,32 +146,105 @@ func (s *OrderServiceServer) CreateOrder(ctx context.Context, req *orderpb.Creat
 		orderItems = append(orderItems, orderItem)
 	}
 
-	// Process payment with Stripe
-	amountInCents := int64(totalAmount * 100) // Convert to cents
-	_, err := utils.CreatePaymentIntent(amountInCents, "usd", req.PaymentMethodId)
+	// The shopper has to agree to the new prices, by sending them, before
+	// being charged them.
+	if len(priceChanges) > 0 {
+		return nil, preconditionFailure("prices have changed since they were shown: "+priceChanges[0].Description, priceChanges)
+	}
+
+	// Coupons are all or nothing: an order is never placed without a
+	// discount the customer asked for.
+	discounts, rejected, err := s.applyPromotions(ctx, userID, lines, req.CouponCodes)
 	if err != nil {
-		return nil, status.Errorf(codes.Internal, "payment failed: %v", err)
+		return nil, err
+	}
+	if len(rejected) > 0 {
+		return nil, couponsRejectedError(rejected)
+	}
+
+	discountAmount := promotions.Total(discounts)
+
+	// Tax is levied on what is paid after discounts, by the rules of the
+	// jurisdiction the order ships to.
+	taxLines, err := s.calculateTax(ctx, shippingAddress, taxableLines, discountAmount)
+	if err != nil {
+		return nil, err
 	}
 
+	// Every order ships to an address, so it has to say how; an order
+	// without a method would otherwise ship for free.
+	if req.ShippingMethodId == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "shipping method ID is required")
+	}
+
+	orderShipping, err := s.chooseShipping(ctx, req.ShippingMethodId, shippingAddress, weight)
+	if err != nil {
+		return nil, err
+	}
+
+	totalAmount := promotions.RoundPrice(promotions.Subtotal(lines) - discountAmount + tax.Exclusive(taxLines) + orderShipping.Amount)
+
+	// The order is saved before the customer is charged, so that promotion
+	// usage is checked and reserved under lock first; a customer is never
+	// charged for an order that cannot be placed.
 	order := &models.Order{
-		UserID:      userID,
-		TotalAmount: totalAmount,
-		Status:      "Confirmed",
-		CreatedAt:   time.Now(),
-		Items:       orderItems,
+		UserID:          userID,
+		TotalAmount:     totalAmount,
+		DiscountAmount:  discountAmount,
+		Discounts:       discounts,
+		TaxAmount:       tax.Total(taxLines),
+		TaxLines:        taxLines,
+		Status:          models.OrderPendingPayment,
+		CreatedAt:       time.Now(),
+		Items:           orderItems,
+		ShippingAddress: shippingAddress,
+		Shipping:        orderShipping,
 	}
 
 	err = s.repo.CreateOrder(ctx, order)
 	if err != nil {
+		if err == repository.ErrPromotionLimitReached {
+			return nil, status.Errorf(codes.FailedPrecondition, "a promotion on this order has reached its usage limit")
+		}
 		return nil, status.Errorf(codes.Internal, "failed to create order: %v", err)
 	}
 
+	// Process payment with Stripe
+	amountInCents := int64(math.Round(totalAmount * 100)) // Convert to cents
+	paymentIntent, err := utils.CreatePaymentIntent(amountInCents, "usd", req.PaymentMethodId)
+	if err != nil {
+		if failErr := s.repo.FailOrderPayment(ctx, order.OrderID); failErr != nil {
+			log.Printf("Failed to mark order %d as unpaid: %v", order.OrderID, failErr)
+		}
+		return nil, status.Errorf(codes.Internal, "payment failed: %v", err)
+	}
+
+	err = s.repo.ConfirmOrderPayment(ctx, order.OrderID, paymentIntent.ID)
+	if err != nil {
+		// The customer must not be charged for an order that is not
+		// confirmed.
+		if refundErr := utils.RefundPaymentIntent(paymentIntent.ID); refundErr != nil {
+			log.Printf("Failed to refund payment intent %s of order %d: %v", paymentIntent.ID, order.OrderID, refundErr)
+		}
+		if failErr := s.repo.FailOrderPayment(ctx, order.OrderID); failErr != nil {
+			log.Printf("Failed to mark order %d as unpaid: %v", order.OrderID, failErr)
+		}
+		return nil, status.Errorf(codes.Internal, "failed to confirm order: %v", err)
+	}
+	order.Status = models.OrderConfirmed
+
 	return &orderpb.CreateOrderResponse{
 		Order: mapOrderToProto(order),
 	}, nil
 }
 
 func (s *OrderServiceServer) GetOrder(ctx context.Context, req *orderpb.GetOrderRequest) (*orderpb.GetOrderResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
 	orderID, err := strconv.Atoi(req.OrderId)
 	if err != nil {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid order ID")
@@ -125,6 +258,12 @@ func (s *OrderServiceServer) GetOrder(ctx context.Context, req *orderpb.GetOrder
 		return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
 	}
 
+	// Other customers' orders are reported as missing, so that order IDs
+	// cannot be probed; only admins may look at any order.
+	if order.UserID != userID && requireAdmin(ctx) != nil {
+		return nil, status.Errorf(codes.NotFound, "order not found")
+	}
+
 	return &orderpb.GetOrderResponse{
 		Order: mapOrderToProto(order),
 	}, nil
@@ -152,6 +291,45 @@ func (s *OrderServiceServer) ListOrders(ctx context.Context, req *orderpb.ListOr
 	}, nil
 }
 
+// getShippingAddress loads an address from the caller's address book in User
+// Service so it can be snapshotted onto the order.
+func (s *OrderServiceServer) getShippingAddress(ctx context.Context, addressID string) (*models.ShippingAddress, error) {
+	resp, err := s.userClient.GetAddress(auth.ForwardAuthorization(ctx), &userpb.GetAddressRequest{
+		AddressId: addressID,
+	})
+	if err != nil {
+		grpcErr, ok := status.FromError(err)
+		if ok && (grpcErr.Code() == codes.NotFound || grpcErr.Code() == codes.InvalidArgument) {
+			return nil, status.Errorf(codes.InvalidArgument, "shipping address not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to fetch shipping address: %v", err)
+	}
+
+	address := resp.Address
+	return &models.ShippingAddress{
+		FullName: address.FullName,
+		Line1:    address.Line1,
+		Line2:    address.Line2,
+		City:     address.City,
+		Region:   address.Region,
+		Postcode: address.Postcode,
+		Country:  address.Country,
+		Phone:    address.Phone,
+	}, nil
+}
+
+// preconditionFailure returns a FailedPrecondition error carrying violations
+// as PreconditionFailure details.
+func preconditionFailure(description string, violations []*errdetails.PreconditionFailure_Violation) error {
+	st, err := status.New(codes.FailedPrecondition, description).WithDetails(&errdetails.PreconditionFailure{
+		Violations: violations,
+	})
+	if err != nil {
+		return status.Errorf(codes.FailedPrecondition, "%s", description)
+	}
+	return st.Err()
+}
+
 func mapOrderToProto(order *models.Order) *orderpb.Order {
 	var items []*orderpb.OrderItem
 	for _, item := range order.Items {
@@ -161,12 +339,46 @@ func mapOrderToProto(order *models.Order) *orderpb.Order {
 		})
 	}
 
-	return &orderpb.Order{
+	orderProto := &orderpb.Order{
 		OrderId:     strconv.Itoa(order.OrderID),
 		UserId:      strconv.Itoa(order.UserID),
 		Items:       items,
 		TotalAmount: order.TotalAmount,
 		Status:      order.Status,
 		CreatedAt:   order.CreatedAt.Format(time.RFC3339),
+
+		SubtotalAmount: promotions.RoundPrice(order.TotalAmount + order.DiscountAmount - tax.Exclusive(order.TaxLines) - order.Shipping.Amount),
+		DiscountAmount: order.DiscountAmount,
+		Discounts:      mapDiscountsToProto(order.Discounts),
+		TaxAmount:      order.TaxAmount,
+		TaxLines:       mapTaxLinesToProto(order.TaxLines),
 	}
+
+	if address := order.ShippingAddress; address.Country != "" {
+		orderProto.ShippingAddress = &orderpb.ShippingAddress{
+			FullName: address.FullName,
+			Line1:    address.Line1,
+			Line2:    address.Line2,
+			City:     address.City,
+			Region:   address.Region,
+			Postcode: address.Postcode,
+			Country:  address.Country,
+			Phone:    address.Phone,
+		}
+	}
+
+	for _, shipment := range order.Shipments {
+		orderProto.Shipments = append(orderProto.Shipments, mapShipmentToProto(shipment))
+	}
+
+	if shipping := order.Shipping; shipping.MethodID != 0 {
+		orderProto.Shipping = &orderpb.OrderShipping{
+			ShippingMethodId: strconv.Itoa(shipping.MethodID),
+			Carrier:          shipping.Carrier,
+			Name:             shipping.Method,
+			Amount:           shipping.Amount,
+		}
+	}
+
+	return orderProto
 }
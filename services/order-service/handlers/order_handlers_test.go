@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/userpb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var orderColumns = []string{
+	"order_id", "user_id", "total_amount", "discount_amount", "tax_amount", "status", "created_at",
+	"shipping_full_name", "shipping_line1", "shipping_line2", "shipping_city",
+	"shipping_region", "shipping_postcode", "shipping_country", "shipping_phone",
+	"shipping_method_id", "shipping_carrier", "shipping_method", "shipping_amount",
+}
+
+// fakeUserClient serves the caller's address book from User Service.
+type fakeUserClient struct {
+	userpb.UserServiceClient
+}
+
+func (c *fakeUserClient) GetAddress(ctx context.Context, in *userpb.GetAddressRequest, opts ...grpc.CallOption) (*userpb.GetAddressResponse, error) {
+	return &userpb.GetAddressResponse{
+		Address: &userpb.Address{AddressId: in.AddressId, FullName: "Test User", Line1: "1 Main St", City: "Springfield", Country: "US"},
+	}, nil
+}
+
+// expectGetOrder sets up GetOrderByID loading order 5, placed by ownerID
+// with a single item.
+func expectGetOrder(mock sqlmock.Sqlmock, ownerID int) {
+	mock.ExpectQuery("FROM orders WHERE order_id = \\$1").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(orderColumns).AddRow(5, ownerID, 40.0, 0.0, 0.0, models.OrderConfirmed, time.Now(),
+			"", "", "", "", "", "", "", "", 0, "", "", 0.0))
+	mock.ExpectQuery("FROM order_items").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "product_id", "quantity"}).AddRow(5, 10, 2))
+	mock.ExpectQuery("FROM order_discounts").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "promotion_id", "code", "description", "amount"}))
+	mock.ExpectQuery("FROM order_tax_lines").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "tax_rule_id", "name", "rate", "inclusive", "taxable_amount", "amount"}))
+	mock.ExpectQuery("FROM shipments").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows([]string{"shipment_id", "order_id", "carrier", "tracking_number", "status", "created_at", "updated_at"}))
+}
+
+func TestGetOrder(t *testing.T) {
+	tests := []struct {
+		name     string
+		userID   int
+		role     string
+		wantCode codes.Code
+	}{
+		{"owner", 1, "customer", codes.OK},
+		{"other customer", 2, "customer", codes.NotFound},
+		{"admin", 2, models.RoleAdmin, codes.OK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatalf("Failed to create sqlmock: %v", err)
+			}
+			defer db.Close()
+
+			expectGetOrder(mock, 1)
+
+			handler := NewOrderServiceServer(repository.NewOrderRepository(db), "", "", nil, nil, nil, false)
+			ctx := context.WithValue(context.Background(), auth.UserIDKey, tt.userID)
+			ctx = context.WithValue(ctx, auth.RoleKey, tt.role)
+
+			resp, err := handler.GetOrder(ctx, &orderpb.GetOrderRequest{OrderId: "5"})
+			if status.Code(err) != tt.wantCode {
+				t.Fatalf("Expected %v, got %v", tt.wantCode, err)
+			}
+			if tt.wantCode == codes.OK && (resp.Order.OrderId != "5" || len(resp.Order.Items) != 1) {
+				t.Errorf("Unexpected order: %+v", resp.Order)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Errorf("Unfulfilled expectations: %v", err)
+			}
+		})
+	}
+}
+
+func TestCreateOrderRejectsInvalidQuantity(t *testing.T) {
+	// There is no product client: the quantity must be rejected before the
+	// product is looked up.
+	handler := NewOrderServiceServer(nil, "", "", nil, nil, &fakeUserClient{}, false)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	for _, quantity := range []int32{0, -1} {
+		_, err := handler.CreateOrder(ctx, &orderpb.CreateOrderRequest{
+			Items:             []*orderpb.OrderItem{{ProductId: "10", Quantity: quantity, ExpectedUnitPrice: 20}},
+			PaymentMethodId:   "pm_card_visa",
+			ShippingAddressId: "7",
+			ShippingMethodId:  "1",
+		})
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Expected InvalidArgument for quantity %d, got %v", quantity, err)
+		}
+	}
+}
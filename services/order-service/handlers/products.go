@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"context"
+	"slices"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/productpb"
+)
+
+// productBatchSize is the most products Product Service's GetProducts looks up
+// in one call.
+const productBatchSize = 100
+
+// getProducts fetches the given products from Product Service, in batches it
+// accepts. Products that do not exist are absent from the result.
+func (s *OrderServiceServer) getProducts(ctx context.Context, productIDs []string) ([]*productpb.Product, error) {
+	var products []*productpb.Product
+	for batch := range slices.Chunk(productIDs, productBatchSize) {
+		resp, err := s.productClient.GetProducts(ctx, &productpb.GetProductsRequest{
+			ProductIds: batch,
+		})
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, resp.Products...)
+	}
+	return products, nil
+}
@@ -0,0 +1,390 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/promotions"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxCouponCodeLength = 50
+
+// rejectedCoupon is a coupon that cannot be applied to an order.
+type rejectedCoupon struct {
+	code   string
+	reason string
+}
+
+func (s *OrderServiceServer) PreviewDiscounts(ctx context.Context, req *orderpb.PreviewDiscountsRequest) (*orderpb.PreviewDiscountsResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	lines, err := s.priceItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	discounts, rejected, err := s.applyPromotions(ctx, userID, lines, req.CouponCodes)
+	if err != nil {
+		return nil, err
+	}
+
+	subtotal := promotions.Subtotal(lines)
+	discountAmount := promotions.Total(discounts)
+	resp := &orderpb.PreviewDiscountsResponse{
+		SubtotalAmount: subtotal,
+		DiscountAmount: discountAmount,
+		TotalAmount:    promotions.RoundPrice(subtotal - discountAmount),
+		Discounts:      mapDiscountsToProto(discounts),
+	}
+
+	// A coupon that qualifies can still lose out to a better promotion it
+	// does not stack with. Orders accept it, but the customer should know.
+	applied := make(map[string]bool)
+	for _, discount := range discounts {
+		applied[discount.Code] = true
+	}
+	for _, code := range req.CouponCodes {
+		code = normaliseCouponCode(code)
+		if code != "" && !applied[code] && !isRejected(rejected, code) {
+			rejected = append(rejected, rejectedCoupon{code: code, reason: "a better promotion already applies"})
+			applied[code] = true
+		}
+	}
+
+	for _, coupon := range rejected {
+		resp.RejectedCoupons = append(resp.RejectedCoupons, &orderpb.RejectedCoupon{
+			Code:   coupon.code,
+			Reason: coupon.reason,
+		})
+	}
+
+	return resp, nil
+}
+
+// priceItems prices items at the current product prices. Products that no
+// longer exist are left out, as they cannot be ordered.
+func (s *OrderServiceServer) priceItems(ctx context.Context, items []*orderpb.OrderItem) ([]promotions.Line, error) {
+	quantities := make(map[int]int32)
+	var productIDs []string
+	for _, item := range items {
+		productID, err := strconv.Atoi(item.ProductId)
+		if err != nil || item.Quantity <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID or quantity")
+		}
+		if _, ok := quantities[productID]; !ok {
+			productIDs = append(productIDs, item.ProductId)
+		}
+		quantities[productID] += item.Quantity
+	}
+
+	if len(productIDs) == 0 {
+		return nil, nil
+	}
+
+	products, err := s.getProducts(ctx, productIDs)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to fetch products: %v", err)
+	}
+
+	var lines []promotions.Line
+	for _, product := range products {
+		productID, err := strconv.Atoi(product.ProductId)
+		if err != nil {
+			continue
+		}
+		lines = append(lines, promotions.Line{
+			ProductID: productID,
+			Quantity:  quantities[productID],
+			UnitPrice: product.Price,
+		})
+	}
+	return lines, nil
+}
+
+// applyPromotions works out the discounts the user gets on lines: the
+// automatic promotions plus the given coupons, subject to their rules and
+// stacking. Coupons that do not exist or do not qualify are returned as
+// rejected.
+func (s *OrderServiceServer) applyPromotions(ctx context.Context, userID int, lines []promotions.Line, couponCodes []string) ([]models.OrderDiscount, []rejectedCoupon, error) {
+	var normalisedCodes []string
+	seen := make(map[string]bool)
+	for _, code := range couponCodes {
+		code = normaliseCouponCode(code)
+		if code != "" && !seen[code] {
+			seen[code] = true
+			normalisedCodes = append(normalisedCodes, code)
+		}
+	}
+
+	coupons, err := s.repo.GetPromotionsByCodes(ctx, normalisedCodes)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to get coupons: %v", err)
+	}
+
+	automatic, err := s.repo.ListAutomaticPromotions(ctx)
+	if err != nil {
+		return nil, nil, status.Errorf(codes.Internal, "failed to get promotions: %v", err)
+	}
+
+	found := make(map[string]bool)
+	for _, coupon := range coupons {
+		found[coupon.Code] = true
+	}
+
+	var rejected []rejectedCoupon
+	for _, code := range normalisedCodes {
+		if !found[code] {
+			rejected = append(rejected, rejectedCoupon{code: code, reason: "coupon does not exist"})
+		}
+	}
+
+	now := time.Now()
+	var eligible []*models.Promotion
+	for _, promotion := range append(coupons, automatic...) {
+		usage, err := s.repo.GetPromotionUsage(ctx, promotion.PromotionID, userID)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.Internal, "failed to get promotion usage: %v", err)
+		}
+
+		if reason := promotions.Check(promotion, lines, usage, now); reason != "" {
+			if promotion.Code != "" {
+				rejected = append(rejected, rejectedCoupon{code: promotion.Code, reason: reason})
+			}
+			continue
+		}
+		eligible = append(eligible, promotion)
+	}
+
+	return promotions.Apply(eligible, lines), rejected, nil
+}
+
+func (s *OrderServiceServer) CreatePromotion(ctx context.Context, req *orderpb.CreatePromotionRequest) (*orderpb.CreatePromotionResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	promotion, err := promotionFromProto(req.Promotion)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreatePromotion(ctx, promotion)
+	if err != nil {
+		if err == repository.ErrPromotionCodeTaken {
+			return nil, status.Errorf(codes.AlreadyExists, "a promotion with this code already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create promotion: %v", err)
+	}
+
+	return &orderpb.CreatePromotionResponse{
+		Promotion: mapPromotionToProto(promotion),
+	}, nil
+}
+
+func (s *OrderServiceServer) ListPromotions(ctx context.Context, req *orderpb.ListPromotionsRequest) (*orderpb.ListPromotionsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	promotionList, err := s.repo.ListPromotions(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list promotions: %v", err)
+	}
+
+	resp := &orderpb.ListPromotionsResponse{}
+	for _, promotion := range promotionList {
+		resp.Promotions = append(resp.Promotions, mapPromotionToProto(promotion))
+	}
+
+	return resp, nil
+}
+
+func (s *OrderServiceServer) SetPromotionActive(ctx context.Context, req *orderpb.SetPromotionActiveRequest) (*orderpb.SetPromotionActiveResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	promotionID, err := strconv.Atoi(req.PromotionId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid promotion ID")
+	}
+
+	err = s.repo.SetPromotionActive(ctx, promotionID, req.Active)
+	if err != nil {
+		if err == repository.ErrPromotionNotFound {
+			return nil, status.Errorf(codes.NotFound, "promotion not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update promotion: %v", err)
+	}
+
+	return &orderpb.SetPromotionActiveResponse{
+		Message: "Promotion updated successfully",
+	}, nil
+}
+
+// promotionFromProto validates a promotion sent to CreatePromotion. New
+// promotions are always active.
+func promotionFromProto(p *orderpb.Promotion) (*models.Promotion, error) {
+	if p == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "promotion is required")
+	}
+
+	promotion := &models.Promotion{
+		Code:           normaliseCouponCode(p.Code),
+		Description:    strings.TrimSpace(p.Description),
+		Type:           p.Type,
+		Value:          p.Value,
+		BuyQuantity:    p.BuyQuantity,
+		GetQuantity:    p.GetQuantity,
+		MinSubtotal:    p.MinSubtotal,
+		MaxUses:        int(p.MaxUses),
+		MaxUsesPerUser: int(p.MaxUsesPerUser),
+		Stackable:      p.Stackable,
+		Active:         true,
+	}
+
+	if len(promotion.Code) > maxCouponCodeLength {
+		return nil, status.Errorf(codes.InvalidArgument, "code must be at most %d characters", maxCouponCodeLength)
+	}
+
+	if p.ProductId != "" {
+		productID, err := strconv.Atoi(p.ProductId)
+		if err != nil || productID <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
+		}
+		promotion.ProductID = productID
+	}
+
+	switch promotion.Type {
+	case models.PromotionPercentage:
+		if promotion.Value <= 0 || promotion.Value > 100 {
+			return nil, status.Errorf(codes.InvalidArgument, "percentage must be between 0 and 100")
+		}
+	case models.PromotionFixed:
+		if promotion.Value <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "amount must be greater than zero")
+		}
+	case models.PromotionBuyXGetY:
+		if promotion.ProductID == 0 || promotion.BuyQuantity <= 0 || promotion.GetQuantity <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "buy_x_get_y needs a product, buy quantity and get quantity")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid promotion type %q", promotion.Type)
+	}
+
+	if promotion.MinSubtotal < 0 || promotion.MaxUses < 0 || promotion.MaxUsesPerUser < 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "minimum subtotal and usage limits cannot be negative")
+	}
+
+	for _, window := range []struct {
+		value string
+		field **time.Time
+	}{
+		{p.StartsAt, &promotion.StartsAt},
+		{p.EndsAt, &promotion.EndsAt},
+	} {
+		if window.value == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, window.value)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid validity window: %v", err)
+		}
+		*window.field = &t
+	}
+	if promotion.StartsAt != nil && promotion.EndsAt != nil && !promotion.EndsAt.After(*promotion.StartsAt) {
+		return nil, status.Errorf(codes.InvalidArgument, "ends_at must be after starts_at")
+	}
+
+	return promotion, nil
+}
+
+func mapPromotionToProto(promotion *models.Promotion) *orderpb.Promotion {
+	promotionProto := &orderpb.Promotion{
+		PromotionId:    strconv.Itoa(promotion.PromotionID),
+		Code:           promotion.Code,
+		Description:    promotion.Description,
+		Type:           promotion.Type,
+		Value:          promotion.Value,
+		BuyQuantity:    promotion.BuyQuantity,
+		GetQuantity:    promotion.GetQuantity,
+		MinSubtotal:    promotion.MinSubtotal,
+		MaxUses:        int32(promotion.MaxUses),
+		MaxUsesPerUser: int32(promotion.MaxUsesPerUser),
+		Stackable:      promotion.Stackable,
+		Active:         promotion.Active,
+		CreatedAt:      promotion.CreatedAt.Format(time.RFC3339),
+	}
+	if promotion.ProductID != 0 {
+		promotionProto.ProductId = strconv.Itoa(promotion.ProductID)
+	}
+	if promotion.StartsAt != nil {
+		promotionProto.StartsAt = promotion.StartsAt.Format(time.RFC3339)
+	}
+	if promotion.EndsAt != nil {
+		promotionProto.EndsAt = promotion.EndsAt.Format(time.RFC3339)
+	}
+	return promotionProto
+}
+
+func mapDiscountsToProto(discounts []models.OrderDiscount) []*orderpb.Discount {
+	var discountProtos []*orderpb.Discount
+	for _, discount := range discounts {
+		discountProtos = append(discountProtos, &orderpb.Discount{
+			PromotionId: strconv.Itoa(discount.PromotionID),
+			Code:        discount.Code,
+			Description: discount.Description,
+			Amount:      discount.Amount,
+		})
+	}
+	return discountProtos
+}
+
+// couponsRejectedError reports coupons an order cannot use, one
+// PreconditionFailure violation per coupon.
+func couponsRejectedError(rejected []rejectedCoupon) error {
+	var violations []*errdetails.PreconditionFailure_Violation
+	for _, coupon := range rejected {
+		violations = append(violations, &errdetails.PreconditionFailure_Violation{
+			Type:        "COUPON_REJECTED",
+			Subject:     "coupon:" + coupon.code,
+			Description: coupon.reason,
+		})
+	}
+
+	return preconditionFailure("coupon "+rejected[0].code+" cannot be applied: "+rejected[0].reason, violations)
+}
+
+func isRejected(rejected []rejectedCoupon, code string) bool {
+	for _, coupon := range rejected {
+		if coupon.code == code {
+			return true
+		}
+	}
+	return false
+}
+
+func normaliseCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+func requireAdmin(ctx context.Context) error {
+	role, _ := ctx.Value(auth.RoleKey).(string)
+	if role != models.RoleAdmin {
+		return status.Errorf(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
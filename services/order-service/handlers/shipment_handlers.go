@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	maxTrackingNumberLength  = 100
+	maxShipmentEventLocation = 200
+)
+
+var shipmentStatuses = map[string]bool{
+	models.ShipmentPending:        true,
+	models.ShipmentInTransit:      true,
+	models.ShipmentOutForDelivery: true,
+	models.ShipmentDelivered:      true,
+	models.ShipmentException:      true,
+}
+
+func (s *OrderServiceServer) CreateShipment(ctx context.Context, req *orderpb.CreateShipmentRequest) (*orderpb.CreateShipmentResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	orderID, err := strconv.Atoi(req.OrderId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid order ID")
+	}
+
+	shipment := &models.Shipment{
+		OrderID:        orderID,
+		Carrier:        strings.TrimSpace(req.Carrier),
+		TrackingNumber: strings.TrimSpace(req.TrackingNumber),
+		Status:         req.Status,
+	}
+	if shipment.Status == "" {
+		shipment.Status = models.ShipmentPending
+	}
+	if !shipmentStatuses[shipment.Status] {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid shipment status %q", shipment.Status)
+	}
+	if len(shipment.Carrier) > maxShippingNameLength || len(shipment.TrackingNumber) > maxTrackingNumberLength {
+		return nil, status.Errorf(codes.InvalidArgument, "carrier or tracking number is too long")
+	}
+
+	// Quantities of a product listed twice are added up.
+	quantities := make(map[int]int32)
+	for _, item := range req.Items {
+		productID, err := strconv.Atoi(item.ProductId)
+		if err != nil || item.Quantity <= 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID or quantity")
+		}
+		if _, ok := quantities[productID]; !ok {
+			shipment.Items = append(shipment.Items, models.ShipmentItem{ProductID: productID})
+		}
+		quantities[productID] += item.Quantity
+	}
+	for i := range shipment.Items {
+		shipment.Items[i].Quantity = quantities[shipment.Items[i].ProductID]
+	}
+
+	if shipment.Carrier == "" {
+		order, err := s.repo.GetOrderByID(ctx, orderID)
+		if err != nil {
+			if err == sql.ErrNoRows {
+				return nil, status.Errorf(codes.NotFound, "order not found")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to get order: %v", err)
+		}
+		shipment.Carrier = order.Shipping.Carrier
+	}
+
+	err = s.repo.CreateShipment(ctx, shipment)
+	if err != nil {
+		switch err {
+		case sql.ErrNoRows:
+			return nil, status.Errorf(codes.NotFound, "order not found")
+		case repository.ErrShipmentQuantityExceeded:
+			return nil, status.Errorf(codes.FailedPrecondition, "shipment holds more of a product than is left to ship")
+		case repository.ErrOrderFullyShipped:
+			return nil, status.Errorf(codes.FailedPrecondition, "order is already fully shipped")
+		case repository.ErrOrderNotShippable:
+			return nil, status.Errorf(codes.FailedPrecondition, "order has not been paid for")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create shipment: %v", err)
+	}
+
+	return &orderpb.CreateShipmentResponse{
+		Shipment: mapShipmentToProto(shipment),
+	}, nil
+}
+
+func (s *OrderServiceServer) UpdateShipment(ctx context.Context, req *orderpb.UpdateShipmentRequest) (*orderpb.UpdateShipmentResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	shipmentID, err := strconv.Atoi(req.ShipmentId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid shipment ID")
+	}
+
+	carrier := strings.TrimSpace(req.Carrier)
+	trackingNumber := strings.TrimSpace(req.TrackingNumber)
+	if len(carrier) > maxShippingNameLength || len(trackingNumber) > maxTrackingNumberLength {
+		return nil, status.Errorf(codes.InvalidArgument, "carrier or tracking number is too long")
+	}
+
+	var event *models.ShipmentEvent
+	if req.Status != "" {
+		if !shipmentStatuses[req.Status] {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid shipment status %q", req.Status)
+		}
+
+		event = &models.ShipmentEvent{
+			Status:      req.Status,
+			Description: strings.TrimSpace(req.Description),
+			Location:    strings.TrimSpace(req.Location),
+			OccurredAt:  time.Now(),
+		}
+		if len(event.Location) > maxShipmentEventLocation {
+			return nil, status.Errorf(codes.InvalidArgument, "location must be at most %d characters", maxShipmentEventLocation)
+		}
+		if req.OccurredAt != "" {
+			event.OccurredAt, err = time.Parse(time.RFC3339, req.OccurredAt)
+			if err != nil {
+				return nil, status.Errorf(codes.InvalidArgument, "invalid occurred_at: %v", err)
+			}
+		}
+	} else if carrier == "" && trackingNumber == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "nothing to update")
+	}
+
+	shipment, err := s.repo.UpdateShipment(ctx, shipmentID, carrier, trackingNumber, event)
+	if err != nil {
+		switch err {
+		case repository.ErrShipmentNotFound:
+			return nil, status.Errorf(codes.NotFound, "shipment not found")
+		case repository.ErrShipmentDelivered:
+			return nil, status.Errorf(codes.FailedPrecondition, "shipment has already been delivered")
+		case repository.ErrOrderNotShippable:
+			return nil, status.Errorf(codes.FailedPrecondition, "order has not been paid for")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update shipment: %v", err)
+	}
+
+	return &orderpb.UpdateShipmentResponse{
+		Shipment: mapShipmentToProto(shipment),
+	}, nil
+}
+
+func mapShipmentToProto(shipment *models.Shipment) *orderpb.Shipment {
+	shipmentProto := &orderpb.Shipment{
+		ShipmentId:     strconv.Itoa(shipment.ShipmentID),
+		OrderId:        strconv.Itoa(shipment.OrderID),
+		Carrier:        shipment.Carrier,
+		TrackingNumber: shipment.TrackingNumber,
+		Status:         shipment.Status,
+		CreatedAt:      shipment.CreatedAt.Format(time.RFC3339),
+		UpdatedAt:      shipment.UpdatedAt.Format(time.RFC3339),
+	}
+	for _, item := range shipment.Items {
+		shipmentProto.Items = append(shipmentProto.Items, &orderpb.ShipmentItem{
+			ProductId: strconv.Itoa(item.ProductID),
+			Quantity:  item.Quantity,
+		})
+	}
+	for _, event := range shipment.Events {
+		shipmentProto.Events = append(shipmentProto.Events, &orderpb.ShipmentEvent{
+			Status:      event.Status,
+			Description: event.Description,
+			Location:    event.Location,
+			OccurredAt:  event.OccurredAt.Format(time.RFC3339),
+		})
+	}
+	return shipmentProto
+}
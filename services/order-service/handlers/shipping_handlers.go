@@ -0,0 +1,379 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/shipping"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxShippingNameLength = 100
+
+func (s *OrderServiceServer) GetShippingQuotes(ctx context.Context, req *orderpb.GetShippingQuotesRequest) (*orderpb.GetShippingQuotesResponse, error) {
+	_, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	var country string
+	switch {
+	case req.ShippingAddressId != "":
+		address, err := s.getShippingAddress(ctx, req.ShippingAddressId)
+		if err != nil {
+			return nil, err
+		}
+		country = address.Country
+	case req.Country != "":
+		country = strings.ToUpper(strings.TrimSpace(req.Country))
+		if !countryPattern.MatchString(country) {
+			return nil, status.Errorf(codes.InvalidArgument, "country must be a two-letter ISO 3166-1 code")
+		}
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "shipping address or country is required")
+	}
+
+	weight, err := s.weighItems(ctx, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	quotes, err := s.quoteShipping(ctx, country, weight)
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &orderpb.GetShippingQuotesResponse{
+		TotalWeightGrams: weight,
+	}
+	for _, quote := range quotes {
+		resp.Quotes = append(resp.Quotes, &orderpb.ShippingQuote{
+			ShippingMethodId: strconv.Itoa(quote.Method.MethodID),
+			Carrier:          quote.Method.Carrier,
+			Name:             quote.Method.Name,
+			Amount:           quote.Amount,
+			MinDeliveryDays:  quote.Method.MinDeliveryDays,
+			MaxDeliveryDays:  quote.Method.MaxDeliveryDays,
+		})
+	}
+
+	return resp, nil
+}
+
+// weighItems returns the shipping weight of items in grams. Products that no
+// longer exist are left out, as they cannot be ordered.
+func (s *OrderServiceServer) weighItems(ctx context.Context, items []*orderpb.OrderItem) (int64, error) {
+	quantities := make(map[string]int32)
+	var productIDs []string
+	for _, item := range items {
+		if _, err := strconv.Atoi(item.ProductId); err != nil || item.Quantity <= 0 {
+			return 0, status.Errorf(codes.InvalidArgument, "invalid product ID or quantity")
+		}
+		if _, ok := quantities[item.ProductId]; !ok {
+			productIDs = append(productIDs, item.ProductId)
+		}
+		quantities[item.ProductId] += item.Quantity
+	}
+
+	if len(productIDs) == 0 {
+		return 0, nil
+	}
+
+	products, err := s.getProducts(ctx, productIDs)
+	if err != nil {
+		return 0, status.Errorf(codes.Unavailable, "failed to fetch products: %v", err)
+	}
+
+	var weight int64
+	for _, product := range products {
+		weight += int64(product.WeightGrams) * int64(quantities[product.ProductId])
+	}
+	return weight, nil
+}
+
+// quoteShipping returns what the active shipping methods charge to ship
+// weight grams to country. Countries outside every zone get no quotes.
+func (s *OrderServiceServer) quoteShipping(ctx context.Context, country string, weight int64) ([]models.ShippingQuote, error) {
+	zoneID, err := s.repo.GetShippingZoneIDByCountry(ctx, strings.ToUpper(country))
+	if err != nil {
+		if err == repository.ErrShippingZoneNotFound {
+			return nil, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get shipping zone: %v", err)
+	}
+
+	methods, err := s.repo.ListShippingMethods(ctx, true)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get shipping methods: %v", err)
+	}
+
+	return shipping.Quotes(methods, zoneID, weight), nil
+}
+
+// chooseShipping prices the shipping method the customer chose for an order
+// of weight grams to address.
+func (s *OrderServiceServer) chooseShipping(ctx context.Context, shippingMethodID string, address models.ShippingAddress, weight int64) (models.OrderShipping, error) {
+	methodID, err := strconv.Atoi(shippingMethodID)
+	if err != nil {
+		return models.OrderShipping{}, status.Errorf(codes.InvalidArgument, "invalid shipping method ID")
+	}
+	if address.Country == "" {
+		return models.OrderShipping{}, status.Errorf(codes.InvalidArgument, "a shipping address is required to choose a shipping method")
+	}
+
+	quotes, err := s.quoteShipping(ctx, address.Country, weight)
+	if err != nil {
+		return models.OrderShipping{}, err
+	}
+
+	for _, quote := range quotes {
+		if quote.Method.MethodID == methodID {
+			return models.OrderShipping{
+				MethodID: methodID,
+				Carrier:  quote.Method.Carrier,
+				Method:   quote.Method.Name,
+				Amount:   quote.Amount,
+			}, nil
+		}
+	}
+
+	description := fmt.Sprintf("shipping method %s does not ship this order to %s", shippingMethodID, address.Country)
+	return models.OrderShipping{}, preconditionFailure(description, []*errdetails.PreconditionFailure_Violation{{
+		Type:        "SHIPPING_UNAVAILABLE",
+		Subject:     "shipping_method:" + shippingMethodID,
+		Description: description,
+	}})
+}
+
+func (s *OrderServiceServer) CreateShippingZone(ctx context.Context, req *orderpb.CreateShippingZoneRequest) (*orderpb.CreateShippingZoneResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	zone, err := shippingZoneFromProto(req.Zone)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateShippingZone(ctx, zone)
+	if err != nil {
+		switch err {
+		case repository.ErrShippingZoneNameTaken:
+			return nil, status.Errorf(codes.AlreadyExists, "a shipping zone with this name already exists")
+		case repository.ErrShippingCountryTaken:
+			return nil, status.Errorf(codes.AlreadyExists, "a country is already in another shipping zone")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create shipping zone: %v", err)
+	}
+
+	return &orderpb.CreateShippingZoneResponse{
+		Zone: mapShippingZoneToProto(zone),
+	}, nil
+}
+
+func (s *OrderServiceServer) ListShippingZones(ctx context.Context, req *orderpb.ListShippingZonesRequest) (*orderpb.ListShippingZonesResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	zones, err := s.repo.ListShippingZones(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list shipping zones: %v", err)
+	}
+
+	resp := &orderpb.ListShippingZonesResponse{}
+	for _, zone := range zones {
+		resp.Zones = append(resp.Zones, mapShippingZoneToProto(zone))
+	}
+
+	return resp, nil
+}
+
+func (s *OrderServiceServer) CreateShippingMethod(ctx context.Context, req *orderpb.CreateShippingMethodRequest) (*orderpb.CreateShippingMethodResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	method, err := shippingMethodFromProto(req.Method)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateShippingMethod(ctx, method)
+	if err != nil {
+		if err == repository.ErrShippingZoneNotFound {
+			return nil, status.Errorf(codes.InvalidArgument, "shipping zone not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create shipping method: %v", err)
+	}
+
+	return &orderpb.CreateShippingMethodResponse{
+		Method: mapShippingMethodToProto(method),
+	}, nil
+}
+
+func (s *OrderServiceServer) ListShippingMethods(ctx context.Context, req *orderpb.ListShippingMethodsRequest) (*orderpb.ListShippingMethodsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	methods, err := s.repo.ListShippingMethods(ctx, false)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list shipping methods: %v", err)
+	}
+
+	resp := &orderpb.ListShippingMethodsResponse{}
+	for _, method := range methods {
+		resp.Methods = append(resp.Methods, mapShippingMethodToProto(method))
+	}
+
+	return resp, nil
+}
+
+func (s *OrderServiceServer) SetShippingMethodActive(ctx context.Context, req *orderpb.SetShippingMethodActiveRequest) (*orderpb.SetShippingMethodActiveResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	methodID, err := strconv.Atoi(req.ShippingMethodId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid shipping method ID")
+	}
+
+	err = s.repo.SetShippingMethodActive(ctx, methodID, req.Active)
+	if err != nil {
+		if err == repository.ErrShippingMethodNotFound {
+			return nil, status.Errorf(codes.NotFound, "shipping method not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update shipping method: %v", err)
+	}
+
+	return &orderpb.SetShippingMethodActiveResponse{
+		Message: "Shipping method updated successfully",
+	}, nil
+}
+
+// shippingZoneFromProto validates a zone sent to CreateShippingZone.
+func shippingZoneFromProto(z *orderpb.ShippingZone) (*models.ShippingZone, error) {
+	if z == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "zone is required")
+	}
+
+	zone := &models.ShippingZone{
+		Name: strings.TrimSpace(z.Name),
+	}
+	if zone.Name == "" || len(zone.Name) > maxShippingNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required and must be at most %d characters", maxShippingNameLength)
+	}
+
+	seen := make(map[string]bool)
+	for _, country := range z.Countries {
+		country = strings.ToUpper(strings.TrimSpace(country))
+		if !countryPattern.MatchString(country) {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid country %q", country)
+		}
+		if !seen[country] {
+			seen[country] = true
+			zone.Countries = append(zone.Countries, country)
+		}
+	}
+	if len(zone.Countries) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one country is required")
+	}
+
+	return zone, nil
+}
+
+// shippingMethodFromProto validates a method sent to CreateShippingMethod.
+// New methods are always active.
+func shippingMethodFromProto(m *orderpb.ShippingMethod) (*models.ShippingMethod, error) {
+	if m == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "method is required")
+	}
+
+	method := &models.ShippingMethod{
+		Carrier:         strings.TrimSpace(m.Carrier),
+		Name:            strings.TrimSpace(m.Name),
+		MinDeliveryDays: m.MinDeliveryDays,
+		MaxDeliveryDays: m.MaxDeliveryDays,
+		Active:          true,
+	}
+
+	if method.Carrier == "" || len(method.Carrier) > maxShippingNameLength || method.Name == "" || len(method.Name) > maxShippingNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "carrier and name are required and must be at most %d characters", maxShippingNameLength)
+	}
+	if method.MinDeliveryDays < 0 || method.MaxDeliveryDays < method.MinDeliveryDays {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid delivery days")
+	}
+	if len(m.Rates) == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "at least one rate is required")
+	}
+
+	type bracket struct {
+		zoneID    int
+		maxWeight int32
+	}
+	seen := make(map[bracket]bool)
+	for _, r := range m.Rates {
+		zoneID, err := strconv.Atoi(r.ZoneId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid zone ID")
+		}
+		if r.MaxWeightGrams < 0 || r.Price < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "weights and prices cannot be negative")
+		}
+
+		b := bracket{zoneID, r.MaxWeightGrams}
+		if seen[b] {
+			return nil, status.Errorf(codes.InvalidArgument, "duplicate rate for zone %d up to %d grams", zoneID, r.MaxWeightGrams)
+		}
+		seen[b] = true
+
+		method.Rates = append(method.Rates, models.ShippingRate{
+			ZoneID:         zoneID,
+			MaxWeightGrams: r.MaxWeightGrams,
+			Price:          r.Price,
+		})
+	}
+
+	return method, nil
+}
+
+func mapShippingZoneToProto(zone *models.ShippingZone) *orderpb.ShippingZone {
+	return &orderpb.ShippingZone{
+		ZoneId:    strconv.Itoa(zone.ZoneID),
+		Name:      zone.Name,
+		Countries: zone.Countries,
+		CreatedAt: zone.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func mapShippingMethodToProto(method *models.ShippingMethod) *orderpb.ShippingMethod {
+	methodProto := &orderpb.ShippingMethod{
+		ShippingMethodId: strconv.Itoa(method.MethodID),
+		Carrier:          method.Carrier,
+		Name:             method.Name,
+		MinDeliveryDays:  method.MinDeliveryDays,
+		MaxDeliveryDays:  method.MaxDeliveryDays,
+		Active:           method.Active,
+		CreatedAt:        method.CreatedAt.Format(time.RFC3339),
+	}
+	for _, rate := range method.Rates {
+		methodProto.Rates = append(methodProto.Rates, &orderpb.ShippingRate{
+			ZoneId:         strconv.Itoa(rate.ZoneID),
+			MaxWeightGrams: rate.MaxWeightGrams,
+			Price:          rate.Price,
+		})
+	}
+	return methodProto
+}
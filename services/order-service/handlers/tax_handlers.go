@@ -0,0 +1,169 @@
+package handlers
+
+import (
+	"context"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/tax"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxTaxRuleNameLength = 100
+
+var (
+	countryPattern     = regexp.MustCompile(`^[A-Z]{2}$`)
+	taxCategoryPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+)
+
+func (s *OrderServiceServer) CreateTaxRule(ctx context.Context, req *orderpb.CreateTaxRuleRequest) (*orderpb.CreateTaxRuleResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	rule, err := taxRuleFromProto(req.TaxRule)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.repo.CreateTaxRule(ctx, rule)
+	if err != nil {
+		if err == repository.ErrTaxRuleExists {
+			return nil, status.Errorf(codes.AlreadyExists, "a tax rule for this country, region and category already exists")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create tax rule: %v", err)
+	}
+
+	return &orderpb.CreateTaxRuleResponse{
+		TaxRule: mapTaxRuleToProto(rule),
+	}, nil
+}
+
+func (s *OrderServiceServer) ListTaxRules(ctx context.Context, req *orderpb.ListTaxRulesRequest) (*orderpb.ListTaxRulesResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	rules, err := s.repo.ListTaxRules(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list tax rules: %v", err)
+	}
+
+	resp := &orderpb.ListTaxRulesResponse{}
+	for _, rule := range rules {
+		resp.TaxRules = append(resp.TaxRules, mapTaxRuleToProto(rule))
+	}
+
+	return resp, nil
+}
+
+func (s *OrderServiceServer) DeleteTaxRule(ctx context.Context, req *orderpb.DeleteTaxRuleRequest) (*orderpb.DeleteTaxRuleResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	taxRuleID, err := strconv.Atoi(req.TaxRuleId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tax rule ID")
+	}
+
+	err = s.repo.DeleteTaxRule(ctx, taxRuleID)
+	if err != nil {
+		if err == repository.ErrTaxRuleNotFound {
+			return nil, status.Errorf(codes.NotFound, "tax rule not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete tax rule: %v", err)
+	}
+
+	return &orderpb.DeleteTaxRuleResponse{
+		Message: "Tax rule deleted successfully",
+	}, nil
+}
+
+// calculateTax returns the tax lines of an order shipping to address, with
+// discount spread over lines. The address decides the jurisdiction, so an
+// order without one cannot be taxed and is rejected rather than let through
+// tax-free.
+func (s *OrderServiceServer) calculateTax(ctx context.Context, address models.ShippingAddress, lines []tax.Line, discount float64) ([]models.OrderTaxLine, error) {
+	if address.Country == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "a shipping address is required to calculate tax")
+	}
+
+	rules, err := s.repo.ListTaxRulesForCountry(ctx, strings.ToUpper(address.Country))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get tax rules: %v", err)
+	}
+
+	return tax.Calculate(rules, address.Country, address.Region, tax.Allocate(lines, discount)), nil
+}
+
+// taxRuleFromProto validates a rule sent to CreateTaxRule.
+func taxRuleFromProto(r *orderpb.TaxRule) (*models.TaxRule, error) {
+	if r == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "tax rule is required")
+	}
+
+	rule := &models.TaxRule{
+		Country:     strings.ToUpper(strings.TrimSpace(r.Country)),
+		Region:      strings.TrimSpace(r.Region),
+		TaxCategory: strings.TrimSpace(r.TaxCategory),
+		Name:        strings.TrimSpace(r.Name),
+		Rate:        r.Rate,
+		Inclusive:   r.Inclusive,
+	}
+
+	if !countryPattern.MatchString(rule.Country) {
+		return nil, status.Errorf(codes.InvalidArgument, "country must be a two-letter ISO 3166-1 code")
+	}
+	if len(rule.Region) > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "region must be at most 100 characters")
+	}
+	if rule.TaxCategory != "" && !taxCategoryPattern.MatchString(rule.TaxCategory) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tax category")
+	}
+	if rule.Name == "" || len(rule.Name) > maxTaxRuleNameLength {
+		return nil, status.Errorf(codes.InvalidArgument, "name is required and must be at most %d characters", maxTaxRuleNameLength)
+	}
+	if rule.Rate < 0 || rule.Rate > 100 {
+		return nil, status.Errorf(codes.InvalidArgument, "rate must be between 0 and 100")
+	}
+
+	return rule, nil
+}
+
+func mapTaxRuleToProto(rule *models.TaxRule) *orderpb.TaxRule {
+	return &orderpb.TaxRule{
+		TaxRuleId:   strconv.Itoa(rule.TaxRuleID),
+		Country:     rule.Country,
+		Region:      rule.Region,
+		TaxCategory: rule.TaxCategory,
+		Name:        rule.Name,
+		Rate:        rule.Rate,
+		Inclusive:   rule.Inclusive,
+		CreatedAt:   rule.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func mapTaxLinesToProto(taxLines []models.OrderTaxLine) []*orderpb.TaxLine {
+	var taxLineProtos []*orderpb.TaxLine
+	for _, line := range taxLines {
+		taxLineProto := &orderpb.TaxLine{
+			Name:          line.Name,
+			Rate:          line.Rate,
+			Inclusive:     line.Inclusive,
+			TaxableAmount: line.TaxableAmount,
+			Amount:        line.Amount,
+		}
+		if line.TaxRuleID != 0 {
+			taxLineProto.TaxRuleId = strconv.Itoa(line.TaxRuleID)
+		}
+		taxLineProtos = append(taxLineProtos, taxLineProto)
+	}
+	return taxLineProtos
+}
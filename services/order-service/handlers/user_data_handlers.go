@@ -0,0 +1,60 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/orderpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// ExportUserData returns the caller's orders as JSON for inclusion in a
+// data-subject export assembled by User Service.
+func (s *OrderServiceServer) ExportUserData(ctx context.Context, req *orderpb.ExportUserDataRequest) (*orderpb.ExportUserDataResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	orders, err := s.repo.ListOrdersByUserID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list orders: %v", err)
+	}
+
+	export := &orderpb.ListOrdersResponse{}
+	for _, order := range orders {
+		export.Orders = append(export.Orders, mapOrderToProto(order))
+	}
+
+	data, err := protojson.Marshal(export)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode orders: %v", err)
+	}
+
+	return &orderpb.ExportUserDataResponse{
+		Data: data,
+	}, nil
+}
+
+// EraseUserData anonymises the caller's orders. Order records are required
+// for accounting, so they are kept with the personal details removed rather
+// than deleted.
+func (s *OrderServiceServer) EraseUserData(ctx context.Context, req *orderpb.EraseUserDataRequest) (*orderpb.EraseUserDataResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	err := s.repo.AnonymiseOrders(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to anonymise orders: %v", err)
+	}
+
+	return &orderpb.EraseUserDataResponse{
+		Message: "User data erased successfully",
+	}, nil
+}
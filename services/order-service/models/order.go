@@ -2,13 +2,34 @@ package models
 
 import "time"
 
+// Order statuses. Orders are pending until the customer has been charged,
+// then confirmed, and then follow their shipments.
+const (
+	OrderPendingPayment   = "Pending Payment"
+	OrderPaymentFailed    = "Payment Failed"
+	OrderConfirmed        = "Confirmed"
+	OrderPartiallyShipped = "Partially Shipped"
+	OrderShipped          = "Shipped"
+	OrderDelivered        = "Delivered"
+)
+
 type Order struct {
-	OrderID     int
-	UserID      int
-	Items       []OrderItem
-	TotalAmount float64
-	Status      string
-	CreatedAt   time.Time
+	OrderID int
+	UserID  int
+	Items   []OrderItem
+	// TotalAmount is what was charged: the subtotal less DiscountAmount,
+	// plus the tax of exclusive tax lines and the shipping amount.
+	TotalAmount    float64
+	DiscountAmount float64
+	Discounts      []OrderDiscount
+	// TaxAmount is the tax of all TaxLines, inclusive or not.
+	TaxAmount       float64
+	TaxLines        []OrderTaxLine
+	Status          string
+	CreatedAt       time.Time
+	ShippingAddress ShippingAddress
+	Shipping        OrderShipping
+	Shipments       []*Shipment
 }
 
 type OrderItem struct {
@@ -16,3 +37,16 @@ type OrderItem struct {
 	ProductID int
 	Quantity  int32
 }
+
+// ShippingAddress is copied onto the order when it is placed. Orders placed
+// without an address have an empty Country.
+type ShippingAddress struct {
+	FullName string
+	Line1    string
+	Line2    string
+	City     string
+	Region   string
+	Postcode string
+	Country  string
+	Phone    string
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Promotion types.
+const (
+	PromotionPercentage = "percentage"
+	PromotionFixed      = "fixed"
+	PromotionBuyXGetY   = "buy_x_get_y"
+)
+
+// Promotion is a discount rule. Promotions with a Code are coupons that only
+// apply when the code is given; the others apply automatically.
+type Promotion struct {
+	PromotionID int
+	Code        string
+	Description string
+	Type        string
+	// Value is the percent off for PromotionPercentage and the amount off for
+	// PromotionFixed.
+	Value float64
+	// ProductID restricts the promotion to one product; 0 applies it to the
+	// whole order.
+	ProductID   int
+	BuyQuantity int32
+	GetQuantity int32
+	MinSubtotal float64
+	// Usage limits; 0 means unlimited.
+	MaxUses        int
+	MaxUsesPerUser int
+	StartsAt       *time.Time
+	EndsAt         *time.Time
+	Stackable      bool
+	Active         bool
+	CreatedAt      time.Time
+}
+
+// PromotionUsage is how many orders a promotion has been applied to.
+type PromotionUsage struct {
+	Total  int
+	ByUser int
+}
+
+// OrderDiscount is a promotion applied to an order.
+type OrderDiscount struct {
+	PromotionID int
+	Code        string
+	Description string
+	Amount      float64
+}
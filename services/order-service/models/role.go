@@ -0,0 +1,4 @@
+package models
+
+// RoleAdmin is the role User Service grants administrators.
+const RoleAdmin = "admin"
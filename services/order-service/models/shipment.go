@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Shipment statuses. A delivered shipment cannot change status any more.
+const (
+	ShipmentPending        = "pending"
+	ShipmentInTransit      = "in_transit"
+	ShipmentOutForDelivery = "out_for_delivery"
+	ShipmentDelivered      = "delivered"
+	ShipmentException      = "exception"
+)
+
+// Shipment is a parcel with some of the items of an order.
+type Shipment struct {
+	ShipmentID     int
+	OrderID        int
+	Carrier        string
+	TrackingNumber string
+	Status         string
+	Items          []ShipmentItem
+	// Events are the shipment's status changes, oldest first.
+	Events    []ShipmentEvent
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type ShipmentItem struct {
+	ProductID int
+	Quantity  int32
+}
+
+// ShipmentEvent records a shipment reaching a status, such as a carrier
+// scan.
+type ShipmentEvent struct {
+	Status      string
+	Description string
+	Location    string
+	OccurredAt  time.Time
+}
@@ -0,0 +1,48 @@
+package models
+
+import "time"
+
+// ShippingZone groups the countries that share shipping rates.
+type ShippingZone struct {
+	ZoneID int
+	Name   string
+	// Countries are ISO 3166-1 alpha-2 codes. A country is in at most one
+	// zone.
+	Countries []string
+	CreatedAt time.Time
+}
+
+// ShippingMethod is a service of a carrier, such as "Express" by "DHL".
+type ShippingMethod struct {
+	MethodID        int
+	Carrier         string
+	Name            string
+	MinDeliveryDays int32
+	MaxDeliveryDays int32
+	Active          bool
+	Rates           []ShippingRate
+	CreatedAt       time.Time
+}
+
+// ShippingRate is the price of a parcel of up to MaxWeightGrams to a zone.
+// A MaxWeightGrams of 0 has no upper limit.
+type ShippingRate struct {
+	ZoneID         int
+	MaxWeightGrams int32
+	Price          float64
+}
+
+// ShippingQuote is what a method charges to ship an order.
+type ShippingQuote struct {
+	Method *ShippingMethod
+	Amount float64
+}
+
+// OrderShipping is the shipping method chosen for an order. Orders placed
+// without one have a zero MethodID.
+type OrderShipping struct {
+	MethodID int
+	Carrier  string
+	Method   string
+	Amount   float64
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// DefaultTaxCategory is the tax category of products that do not name one.
+const DefaultTaxCategory = "standard"
+
+// TaxRule is the tax rate of a jurisdiction, optionally narrowed to a region
+// of the country and to one product tax category.
+type TaxRule struct {
+	TaxRuleID int
+	// Country is an ISO 3166-1 alpha-2 code.
+	Country string
+	// Region is empty for rules that apply to the whole country.
+	Region string
+	// TaxCategory is empty for rules that apply to every category.
+	TaxCategory string
+	Name        string
+	// Rate is a percentage.
+	Rate float64
+	// Inclusive rates are already part of product prices, so they are not
+	// added to the order total.
+	Inclusive bool
+	CreatedAt time.Time
+}
+
+// OrderTaxLine is the tax one rule levied on an order.
+type OrderTaxLine struct {
+	// TaxRuleID is 0 once the rule has been deleted.
+	TaxRuleID     int
+	Name          string
+	Rate          float64
+	Inclusive     bool
+	TaxableAmount float64
+	Amount        float64
+}
@@ -0,0 +1,153 @@
+// Package promotions decides which promotions apply to an order and how much
+// they take off. It only does arithmetic; loading promotions and counting
+// their usage is left to the caller.
+package promotions
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+// Line is one product of an order, priced at its current price.
+type Line struct {
+	ProductID int
+	Quantity  int32
+	UnitPrice float64
+}
+
+// Subtotal returns the price of lines before discounts.
+func Subtotal(lines []Line) float64 {
+	var subtotal float64
+	for _, line := range lines {
+		subtotal += line.UnitPrice * float64(line.Quantity)
+	}
+	return RoundPrice(subtotal)
+}
+
+// Check returns why p cannot be applied to lines, or "" when it can.
+func Check(p *models.Promotion, lines []Line, usage models.PromotionUsage, now time.Time) string {
+	switch {
+	case !p.Active:
+		return "promotion is no longer active"
+	case p.StartsAt != nil && now.Before(*p.StartsAt):
+		return "promotion has not started yet"
+	case p.EndsAt != nil && !now.Before(*p.EndsAt):
+		return "promotion has ended"
+	case p.MaxUses > 0 && usage.Total >= p.MaxUses:
+		return "promotion has been fully redeemed"
+	case p.MaxUsesPerUser > 0 && usage.ByUser >= p.MaxUsesPerUser:
+		return "promotion has already been used"
+	}
+
+	if subtotal := Subtotal(lines); subtotal < p.MinSubtotal {
+		return fmt.Sprintf("order must be at least %.2f", p.MinSubtotal)
+	}
+	if Amount(p, lines) == 0 {
+		if p.ProductID != 0 {
+			return "order does not qualify for this promotion"
+		}
+		return "promotion gives no discount on this order"
+	}
+	return ""
+}
+
+// Amount returns what p takes off lines, never more than they cost.
+func Amount(p *models.Promotion, lines []Line) float64 {
+	base := Subtotal(lines)
+	var line *Line
+	if p.ProductID != 0 {
+		for i := range lines {
+			if lines[i].ProductID == p.ProductID {
+				line = &lines[i]
+			}
+		}
+		if line == nil {
+			return 0
+		}
+		base = RoundPrice(line.UnitPrice * float64(line.Quantity))
+	}
+
+	var amount float64
+	switch p.Type {
+	case models.PromotionPercentage:
+		amount = base * p.Value / 100
+	case models.PromotionFixed:
+		amount = p.Value
+	case models.PromotionBuyXGetY:
+		if line == nil || p.BuyQuantity <= 0 || p.GetQuantity <= 0 {
+			return 0
+		}
+		free := line.Quantity / (p.BuyQuantity + p.GetQuantity) * p.GetQuantity
+		amount = line.UnitPrice * float64(free)
+	}
+
+	return RoundPrice(math.Min(amount, base))
+}
+
+// Apply chooses among promotions that passed Check and returns the discounts
+// to give. Stackable promotions are combined; a non-stackable promotion is
+// only used, alone, when it beats them. The discounts never add up to more
+// than the subtotal.
+func Apply(eligible []*models.Promotion, lines []Line) []models.OrderDiscount {
+	var stackable []models.OrderDiscount
+	var stackableTotal float64
+	var best *models.OrderDiscount
+
+	for _, p := range eligible {
+		discount := models.OrderDiscount{
+			PromotionID: p.PromotionID,
+			Code:        p.Code,
+			Description: p.Description,
+			Amount:      Amount(p, lines),
+		}
+		if discount.Amount <= 0 {
+			continue
+		}
+
+		if p.Stackable {
+			stackable = append(stackable, discount)
+			stackableTotal += discount.Amount
+		} else if best == nil || discount.Amount > best.Amount {
+			best = &discount
+		}
+	}
+
+	if best != nil && best.Amount > stackableTotal {
+		return []models.OrderDiscount{*best}
+	}
+
+	// Largest first, so that trimming to the subtotal affects the smallest.
+	sort.SliceStable(stackable, func(i, j int) bool {
+		return stackable[i].Amount > stackable[j].Amount
+	})
+
+	remaining := Subtotal(lines)
+	var discounts []models.OrderDiscount
+	for _, discount := range stackable {
+		if remaining <= 0 {
+			break
+		}
+		discount.Amount = RoundPrice(math.Min(discount.Amount, remaining))
+		remaining = RoundPrice(remaining - discount.Amount)
+		discounts = append(discounts, discount)
+	}
+	return discounts
+}
+
+// Total returns the sum of discounts.
+func Total(discounts []models.OrderDiscount) float64 {
+	var total float64
+	for _, discount := range discounts {
+		total += discount.Amount
+	}
+	return RoundPrice(total)
+}
+
+// RoundPrice rounds an amount to whole cents.
+func RoundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
@@ -0,0 +1,83 @@
+package promotions
+
+import (
+	"testing"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var lines = []Line{
+	{ProductID: 1, Quantity: 5, UnitPrice: 10},
+	{ProductID: 2, Quantity: 1, UnitPrice: 50},
+}
+
+func TestAmount(t *testing.T) {
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		want      float64
+	}{
+		{"percentage of order", models.Promotion{Type: models.PromotionPercentage, Value: 10}, 10},
+		{"percentage of product", models.Promotion{Type: models.PromotionPercentage, Value: 10, ProductID: 2}, 5},
+		{"fixed", models.Promotion{Type: models.PromotionFixed, Value: 15}, 15},
+		{"fixed capped at product price", models.Promotion{Type: models.PromotionFixed, Value: 80, ProductID: 2}, 50},
+		{"buy 2 get 1", models.Promotion{Type: models.PromotionBuyXGetY, ProductID: 1, BuyQuantity: 2, GetQuantity: 1}, 10},
+		{"product not in order", models.Promotion{Type: models.PromotionFixed, Value: 5, ProductID: 3}, 0},
+	}
+
+	for _, tt := range tests {
+		if got := Amount(&tt.promotion, lines); got != tt.want {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+
+	tests := []struct {
+		name      string
+		promotion models.Promotion
+		usage     models.PromotionUsage
+		eligible  bool
+	}{
+		{"valid", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, StartsAt: &past, EndsAt: &future}, models.PromotionUsage{}, true},
+		{"inactive", models.Promotion{Type: models.PromotionFixed, Value: 5}, models.PromotionUsage{}, false},
+		{"not started", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, StartsAt: &future}, models.PromotionUsage{}, false},
+		{"ended", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, EndsAt: &past}, models.PromotionUsage{}, false},
+		{"global limit", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, MaxUses: 10}, models.PromotionUsage{Total: 10}, false},
+		{"per-user limit", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, MaxUsesPerUser: 1}, models.PromotionUsage{Total: 3, ByUser: 1}, false},
+		{"minimum spend", models.Promotion{Type: models.PromotionFixed, Value: 5, Active: true, MinSubtotal: 150}, models.PromotionUsage{}, false},
+	}
+
+	for _, tt := range tests {
+		reason := Check(&tt.promotion, lines, tt.usage, now)
+		if (reason == "") != tt.eligible {
+			t.Errorf("%s: expected eligible %v, got reason %q", tt.name, tt.eligible, reason)
+		}
+	}
+}
+
+func TestApplyStacking(t *testing.T) {
+	tenPercent := &models.Promotion{PromotionID: 1, Type: models.PromotionPercentage, Value: 10, Stackable: true}
+	fiveOff := &models.Promotion{PromotionID: 2, Type: models.PromotionFixed, Value: 5, Stackable: true}
+	twentyOff := &models.Promotion{PromotionID: 3, Type: models.PromotionFixed, Value: 20}
+
+	discounts := Apply([]*models.Promotion{fiveOff, tenPercent}, lines)
+	if len(discounts) != 2 || Total(discounts) != 15 || discounts[0].PromotionID != 1 {
+		t.Errorf("Expected both stackable promotions, largest first, got %+v", discounts)
+	}
+
+	discounts = Apply([]*models.Promotion{fiveOff, tenPercent, twentyOff}, lines)
+	if len(discounts) != 1 || discounts[0].PromotionID != 3 {
+		t.Errorf("Expected the better non-stackable promotion alone, got %+v", discounts)
+	}
+
+	everything := &models.Promotion{PromotionID: 4, Type: models.PromotionFixed, Value: 95, Stackable: true}
+	discounts = Apply([]*models.Promotion{everything, tenPercent}, lines)
+	if Total(discounts) != Subtotal(lines) {
+		t.Errorf("Expected discounts trimmed to the subtotal, got %+v", discounts)
+	}
+}
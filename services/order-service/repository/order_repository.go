@@ -4,6 +4,7 @@ import (
 	"context"
 	"database/sql"
 
+	"github.com/lib/pq"
 	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
 )
 
@@ -11,6 +12,31 @@ type OrderRepository interface {
 	CreateOrder(ctx context.Context, order *models.Order) error
 	GetOrderByID(ctx context.Context, orderID int) (*models.Order, error)
 	ListOrdersByUserID(ctx context.Context, userID int) ([]*models.Order, error)
+	AnonymiseOrders(ctx context.Context, userID int) error
+	ConfirmOrderPayment(ctx context.Context, orderID int, paymentIntentID string) error
+	FailOrderPayment(ctx context.Context, orderID int) error
+
+	CreatePromotion(ctx context.Context, promotion *models.Promotion) error
+	ListPromotions(ctx context.Context) ([]*models.Promotion, error)
+	ListAutomaticPromotions(ctx context.Context) ([]*models.Promotion, error)
+	GetPromotionsByCodes(ctx context.Context, codes []string) ([]*models.Promotion, error)
+	SetPromotionActive(ctx context.Context, promotionID int, active bool) error
+	GetPromotionUsage(ctx context.Context, promotionID, userID int) (models.PromotionUsage, error)
+
+	CreateTaxRule(ctx context.Context, rule *models.TaxRule) error
+	ListTaxRules(ctx context.Context) ([]*models.TaxRule, error)
+	ListTaxRulesForCountry(ctx context.Context, country string) ([]*models.TaxRule, error)
+	DeleteTaxRule(ctx context.Context, taxRuleID int) error
+
+	CreateShippingZone(ctx context.Context, zone *models.ShippingZone) error
+	ListShippingZones(ctx context.Context) ([]*models.ShippingZone, error)
+	GetShippingZoneIDByCountry(ctx context.Context, country string) (int, error)
+	CreateShippingMethod(ctx context.Context, method *models.ShippingMethod) error
+	ListShippingMethods(ctx context.Context, activeOnly bool) ([]*models.ShippingMethod, error)
+	SetShippingMethodActive(ctx context.Context, methodID int, active bool) error
+
+	CreateShipment(ctx context.Context, shipment *models.Shipment) error
+	UpdateShipment(ctx context.Context, shipmentID int, carrier, trackingNumber string, event *models.ShipmentEvent) (*models.Shipment, error)
 }
 
 type orderRepository struct {
@@ -21,6 +47,42 @@ func NewOrderRepository(db *sql.DB) OrderRepository {
 	return &orderRepository{db: db}
 }
 
+// orderColumns lists the columns scanned by scanOrder, in order.
+const orderColumns = `order_id, user_id, total_amount, discount_amount, tax_amount, status, created_at,
+	shipping_full_name, shipping_line1, shipping_line2, shipping_city,
+	shipping_region, shipping_postcode, shipping_country, shipping_phone,
+	COALESCE(shipping_method_id, 0), shipping_carrier, shipping_method, shipping_amount`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanOrder(row rowScanner) (*models.Order, error) {
+	order := &models.Order{}
+	err := row.Scan(
+		&order.OrderID,
+		&order.UserID,
+		&order.TotalAmount,
+		&order.DiscountAmount,
+		&order.TaxAmount,
+		&order.Status,
+		&order.CreatedAt,
+		&order.ShippingAddress.FullName,
+		&order.ShippingAddress.Line1,
+		&order.ShippingAddress.Line2,
+		&order.ShippingAddress.City,
+		&order.ShippingAddress.Region,
+		&order.ShippingAddress.Postcode,
+		&order.ShippingAddress.Country,
+		&order.ShippingAddress.Phone,
+		&order.Shipping.MethodID,
+		&order.Shipping.Carrier,
+		&order.Shipping.Method,
+		&order.Shipping.Amount,
+	)
+	return order, err
+}
+
 func (r *orderRepository) CreateOrder(ctx context.Context, order *models.Order) error {
 	tx, err := r.db.BeginTx(ctx, nil)
 	if err != nil {
@@ -28,11 +90,35 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *models.Order)
 	}
 
 	orderQuery := `
-		INSERT INTO orders (user_id, total_amount, status, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO orders (user_id, total_amount, discount_amount, tax_amount, status, created_at,
+			shipping_full_name, shipping_line1, shipping_line2, shipping_city,
+			shipping_region, shipping_postcode, shipping_country, shipping_phone,
+			shipping_method_id, shipping_carrier, shipping_method, shipping_amount)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14,
+			NULLIF($15, 0), $16, $17, $18)
 		RETURNING order_id
 	`
-	err = tx.QueryRowContext(ctx, orderQuery, order.UserID, order.TotalAmount, order.Status, order.CreatedAt).Scan(&order.OrderID)
+	address := order.ShippingAddress
+	err = tx.QueryRowContext(ctx, orderQuery,
+		order.UserID,
+		order.TotalAmount,
+		order.DiscountAmount,
+		order.TaxAmount,
+		order.Status,
+		order.CreatedAt,
+		address.FullName,
+		address.Line1,
+		address.Line2,
+		address.City,
+		address.Region,
+		address.Postcode,
+		address.Country,
+		address.Phone,
+		order.Shipping.MethodID,
+		order.Shipping.Carrier,
+		order.Shipping.Method,
+		order.Shipping.Amount,
+	).Scan(&order.OrderID)
 	if err != nil {
 		tx.Rollback()
 		return err
@@ -50,24 +136,27 @@ func (r *orderRepository) CreateOrder(ctx context.Context, order *models.Order)
 		}
 	}
 
+	if err := redeemPromotions(ctx, tx, order); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := saveTaxLines(ctx, tx, order); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	return tx.Commit()
 }
 
 func (r *orderRepository) GetOrderByID(ctx context.Context, orderID int) (*models.Order, error) {
 	orderQuery := `
-		SELECT order_id, user_id, total_amount, status, created_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE order_id = $1
 	`
 
-	order := &models.Order{}
-	err := r.db.QueryRowContext(ctx, orderQuery, orderID).Scan(
-		&order.OrderID,
-		&order.UserID,
-		&order.TotalAmount,
-		&order.Status,
-		&order.CreatedAt,
-	)
+	order, err := scanOrder(r.db.QueryRowContext(ctx, orderQuery, orderID))
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, sql.ErrNoRows
@@ -75,33 +164,16 @@ func (r *orderRepository) GetOrderByID(ctx context.Context, orderID int) (*model
 		return nil, err
 	}
 
-	itemsQuery := `
-		SELECT product_id, quantity
-		FROM order_items
-		WHERE order_id = $1
-	`
-
-	rows, err := r.db.QueryContext(ctx, itemsQuery, orderID)
-	if err != nil {
+	if err := r.loadOrderDetails(ctx, []*models.Order{order}); err != nil {
 		return nil, err
 	}
-	defer rows.Close()
-
-	for rows.Next() {
-		item := models.OrderItem{OrderID: orderID}
-		err := rows.Scan(&item.ProductID, &item.Quantity)
-		if err != nil {
-			return nil, err
-		}
-		order.Items = append(order.Items, item)
-	}
 
 	return order, nil
 }
 
 func (r *orderRepository) ListOrdersByUserID(ctx context.Context, userID int) ([]*models.Order, error) {
 	ordersQuery := `
-		SELECT order_id, total_amount, status, created_at
+		SELECT ` + orderColumns + `
 		FROM orders
 		WHERE user_id = $1
 		ORDER BY created_at DESC
@@ -115,35 +187,139 @@ func (r *orderRepository) ListOrdersByUserID(ctx context.Context, userID int) ([
 
 	var orders []*models.Order
 	for rows.Next() {
-		order := &models.Order{UserID: userID}
-		err := rows.Scan(&order.OrderID, &order.TotalAmount, &order.Status, &order.CreatedAt)
+		order, err := scanOrder(rows)
 		if err != nil {
 			return nil, err
 		}
+		orders = append(orders, order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 
-		itemsQuery := `
-			SELECT product_id, quantity
-			FROM order_items
-			WHERE order_id = $1
-		`
+	if err := r.loadOrderDetails(ctx, orders); err != nil {
+		return nil, err
+	}
+
+	return orders, nil
+}
+
+// loadOrderDetails fills in the items, discounts, tax lines and shipments of
+// orders, with the same number of queries however many orders there are.
+func (r *orderRepository) loadOrderDetails(ctx context.Context, orders []*models.Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
 
-		itemRows, err := r.db.QueryContext(ctx, itemsQuery, order.OrderID)
+	byID := make(map[int]*models.Order, len(orders))
+	var orderIDs []int
+	for _, order := range orders {
+		byID[order.OrderID] = order
+		orderIDs = append(orderIDs, order.OrderID)
+	}
+
+	items, err := queryOrderItems(ctx, r.db, orderIDs...)
+	if err != nil {
+		return err
+	}
+	for _, item := range items {
+		byID[item.OrderID].Items = append(byID[item.OrderID].Items, item)
+	}
+
+	discounts, err := r.getOrderDiscounts(ctx, orderIDs)
+	if err != nil {
+		return err
+	}
+	taxLines, err := r.getOrderTaxLines(ctx, orderIDs)
+	if err != nil {
+		return err
+	}
+	for _, order := range orders {
+		order.Discounts = discounts[order.OrderID]
+		order.TaxLines = taxLines[order.OrderID]
+	}
+
+	shipments, err := queryShipments(ctx, r.db, orderIDs...)
+	if err != nil {
+		return err
+	}
+	for _, shipment := range shipments {
+		byID[shipment.OrderID].Shipments = append(byID[shipment.OrderID].Shipments, shipment)
+	}
+
+	return nil
+}
+
+// ConfirmOrderPayment marks a pending order as paid by paymentIntentID.
+func (r *orderRepository) ConfirmOrderPayment(ctx context.Context, orderID int, paymentIntentID string) error {
+	return r.settleOrder(ctx, orderID, models.OrderConfirmed, paymentIntentID)
+}
+
+// FailOrderPayment marks a pending order whose charge failed. Its promotions
+// no longer count as used.
+func (r *orderRepository) FailOrderPayment(ctx context.Context, orderID int) error {
+	return r.settleOrder(ctx, orderID, models.OrderPaymentFailed, "")
+}
+
+func (r *orderRepository) settleOrder(ctx context.Context, orderID int, status, paymentIntentID string) error {
+	query := `
+		UPDATE orders SET status = $1, payment_intent_id = $2
+		WHERE order_id = $3 AND status = $4
+	`
+
+	result, err := r.db.ExecContext(ctx, query, status, paymentIntentID, orderID, models.OrderPendingPayment)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return sql.ErrNoRows
+	}
+
+	return nil
+}
+
+// AnonymiseOrders strips the personal parts of the shipping address from every
+// order placed by the user. The orders themselves, along with the region and
+// country needed for tax records, are kept for accounting.
+func (r *orderRepository) AnonymiseOrders(ctx context.Context, userID int) error {
+	query := `
+		UPDATE orders SET
+			shipping_full_name = '', shipping_line1 = '', shipping_line2 = '',
+			shipping_city = '', shipping_postcode = '', shipping_phone = '',
+			anonymised_at = NOW()
+		WHERE user_id = $1 AND anonymised_at IS NULL
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID)
+	return err
+}
+
+// queryOrderItems returns the items of the orders orderIDs.
+func queryOrderItems(ctx context.Context, q querier, orderIDs ...int) ([]models.OrderItem, error) {
+	itemsQuery := `
+		SELECT order_id, product_id, quantity
+		FROM order_items
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, product_id
+	`
+
+	rows, err := q.QueryContext(ctx, itemsQuery, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var items []models.OrderItem
+	for rows.Next() {
+		var item models.OrderItem
+		err := rows.Scan(&item.OrderID, &item.ProductID, &item.Quantity)
 		if err != nil {
 			return nil, err
 		}
-		defer itemRows.Close()
-
-		for itemRows.Next() {
-			item := models.OrderItem{OrderID: order.OrderID}
-			err := itemRows.Scan(&item.ProductID, &item.Quantity)
-			if err != nil {
-				return nil, err
-			}
-			order.Items = append(order.Items, item)
-		}
-
-		orders = append(orders, order)
+		items = append(items, item)
 	}
 
-	return orders, nil
+	return items, rows.Err()
 }
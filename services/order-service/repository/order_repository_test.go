@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+func TestListOrdersByUserIDLoadsDetailsTogether(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	orderRows := sqlmock.NewRows([]string{
+		"order_id", "user_id", "total_amount", "discount_amount", "tax_amount", "status", "created_at",
+		"shipping_full_name", "shipping_line1", "shipping_line2", "shipping_city",
+		"shipping_region", "shipping_postcode", "shipping_country", "shipping_phone",
+		"shipping_method_id", "shipping_carrier", "shipping_method", "shipping_amount",
+	})
+	for _, orderID := range []int{7, 5} {
+		orderRows.AddRow(orderID, 1, 40.0, 0.0, 0.0, models.OrderConfirmed, now,
+			"", "", "", "", "", "", "", "", 0, "", "", 0.0)
+	}
+	mock.ExpectQuery("FROM orders WHERE user_id = \\$1").
+		WithArgs(1).
+		WillReturnRows(orderRows)
+
+	// One query per kind of detail, whatever the number of orders.
+	mock.ExpectQuery("FROM order_items").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow(5, 10, 3).AddRow(7, 11, 1).AddRow(7, 12, 2))
+	mock.ExpectQuery("FROM order_discounts").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "promotion_id", "code", "description", "amount"}).
+			AddRow(7, 2, "SAVE10", "10% off", 4.0))
+	mock.ExpectQuery("FROM order_tax_lines").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "tax_rule_id", "name", "rate", "inclusive", "taxable_amount", "amount"}).
+			AddRow(5, 1, "VAT", 0.2, true, 40.0, 6.67))
+	mock.ExpectQuery("FROM shipments").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows(shipmentColumns).AddRow(3, 5, "DHL", "T1", models.ShipmentPending, now, now))
+	mock.ExpectQuery("FROM shipment_items").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows(shipmentItemColumns).AddRow(3, 10, 3))
+	mock.ExpectQuery("FROM shipment_events").
+		WithArgs("{7,5}").
+		WillReturnRows(sqlmock.NewRows(shipmentEventColumns))
+
+	orders, err := NewOrderRepository(db).ListOrdersByUserID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("ListOrdersByUserID failed: %v", err)
+	}
+	if len(orders) != 2 {
+		t.Fatalf("Expected 2 orders, got %d", len(orders))
+	}
+	if newest := orders[0]; newest.OrderID != 7 || len(newest.Items) != 2 || len(newest.Discounts) != 1 || len(newest.TaxLines) != 0 || len(newest.Shipments) != 0 {
+		t.Errorf("Unexpected order 7: %+v", newest)
+	}
+	if oldest := orders[1]; oldest.OrderID != 5 || len(oldest.Items) != 1 || len(oldest.TaxLines) != 1 || len(oldest.Shipments) != 1 || len(oldest.Shipments[0].Items) != 1 {
+		t.Errorf("Unexpected order 5: %+v", oldest)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
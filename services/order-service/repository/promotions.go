@@ -0,0 +1,249 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var (
+	// ErrPromotionNotFound is returned when no promotion has the given ID.
+	ErrPromotionNotFound = errors.New("promotion not found")
+	// ErrPromotionCodeTaken is returned when another promotion already uses
+	// a coupon code.
+	ErrPromotionCodeTaken = errors.New("promotion code already in use")
+	// ErrPromotionLimitReached is returned by CreateOrder when a promotion
+	// reached one of its usage limits while the order was being placed.
+	ErrPromotionLimitReached = errors.New("promotion usage limit reached")
+)
+
+// promotionColumns lists the columns scanned by scanPromotion, in order.
+const promotionColumns = `promotion_id, COALESCE(code, ''), description, type, value,
+	COALESCE(product_id, 0), buy_quantity, get_quantity, min_subtotal, max_uses,
+	max_uses_per_user, starts_at, ends_at, stackable, active, created_at`
+
+func scanPromotion(row rowScanner) (*models.Promotion, error) {
+	p := &models.Promotion{}
+	var startsAt, endsAt sql.NullTime
+	err := row.Scan(
+		&p.PromotionID,
+		&p.Code,
+		&p.Description,
+		&p.Type,
+		&p.Value,
+		&p.ProductID,
+		&p.BuyQuantity,
+		&p.GetQuantity,
+		&p.MinSubtotal,
+		&p.MaxUses,
+		&p.MaxUsesPerUser,
+		&startsAt,
+		&endsAt,
+		&p.Stackable,
+		&p.Active,
+		&p.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if startsAt.Valid {
+		p.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		p.EndsAt = &endsAt.Time
+	}
+	return p, nil
+}
+
+func (r *orderRepository) queryPromotions(ctx context.Context, query string, args ...interface{}) ([]*models.Promotion, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var promotions []*models.Promotion
+	for rows.Next() {
+		p, err := scanPromotion(rows)
+		if err != nil {
+			return nil, err
+		}
+		promotions = append(promotions, p)
+	}
+
+	return promotions, rows.Err()
+}
+
+func (r *orderRepository) CreatePromotion(ctx context.Context, p *models.Promotion) error {
+	query := `
+		INSERT INTO promotions (code, description, type, value, product_id,
+			buy_quantity, get_quantity, min_subtotal, max_uses, max_uses_per_user,
+			starts_at, ends_at, stackable, active)
+		VALUES (NULLIF($1, ''), $2, $3, $4, NULLIF($5, 0), $6, $7, $8, $9, $10, $11, $12, $13, $14)
+		RETURNING promotion_id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		p.Code,
+		p.Description,
+		p.Type,
+		p.Value,
+		p.ProductID,
+		p.BuyQuantity,
+		p.GetQuantity,
+		p.MinSubtotal,
+		p.MaxUses,
+		p.MaxUsesPerUser,
+		p.StartsAt,
+		p.EndsAt,
+		p.Stackable,
+		p.Active,
+	).Scan(&p.PromotionID, &p.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrPromotionCodeTaken
+	}
+	return err
+}
+
+func (r *orderRepository) ListPromotions(ctx context.Context) ([]*models.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		ORDER BY promotion_id
+	`
+
+	return r.queryPromotions(ctx, query)
+}
+
+// ListAutomaticPromotions returns the active promotions that apply without a
+// coupon.
+func (r *orderRepository) ListAutomaticPromotions(ctx context.Context) ([]*models.Promotion, error) {
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE code IS NULL AND active
+		ORDER BY promotion_id
+	`
+
+	return r.queryPromotions(ctx, query)
+}
+
+// GetPromotionsByCodes returns the promotions with the given coupon codes,
+// which must already be upper-cased. Unknown codes are left out.
+func (r *orderRepository) GetPromotionsByCodes(ctx context.Context, codes []string) ([]*models.Promotion, error) {
+	if len(codes) == 0 {
+		return nil, nil
+	}
+
+	query := `
+		SELECT ` + promotionColumns + `
+		FROM promotions
+		WHERE code = ANY($1)
+		ORDER BY promotion_id
+	`
+
+	return r.queryPromotions(ctx, query, pq.Array(codes))
+}
+
+func (r *orderRepository) SetPromotionActive(ctx context.Context, promotionID int, active bool) error {
+	query := `
+		UPDATE promotions SET active = $1 WHERE promotion_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, active, promotionID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrPromotionNotFound
+	}
+
+	return nil
+}
+
+func (r *orderRepository) GetPromotionUsage(ctx context.Context, promotionID, userID int) (models.PromotionUsage, error) {
+	return getPromotionUsage(ctx, r.db, promotionID, userID)
+}
+
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+func getPromotionUsage(ctx context.Context, db queryRower, promotionID, userID int) (models.PromotionUsage, error) {
+	query := `
+		SELECT COUNT(*), COUNT(*) FILTER (WHERE o.user_id = $2)
+		FROM order_discounts d
+		JOIN orders o ON o.order_id = d.order_id
+		WHERE d.promotion_id = $1 AND o.status <> $3
+	`
+
+	var usage models.PromotionUsage
+	err := db.QueryRowContext(ctx, query, promotionID, userID, models.OrderPaymentFailed).Scan(&usage.Total, &usage.ByUser)
+	return usage, err
+}
+
+// redeemPromotions records the discounts of an order. Each promotion is
+// locked and its usage limits checked again, so concurrent orders cannot
+// redeem it past them.
+func redeemPromotions(ctx context.Context, tx *sql.Tx, order *models.Order) error {
+	for _, discount := range order.Discounts {
+		var maxUses, maxUsesPerUser int
+		err := tx.QueryRowContext(ctx, `
+			SELECT max_uses, max_uses_per_user FROM promotions WHERE promotion_id = $1 FOR UPDATE
+		`, discount.PromotionID).Scan(&maxUses, &maxUsesPerUser)
+		if err != nil {
+			return err
+		}
+
+		usage, err := getPromotionUsage(ctx, tx, discount.PromotionID, order.UserID)
+		if err != nil {
+			return err
+		}
+		if (maxUses > 0 && usage.Total >= maxUses) || (maxUsesPerUser > 0 && usage.ByUser >= maxUsesPerUser) {
+			return ErrPromotionLimitReached
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO order_discounts (order_id, promotion_id, code, description, amount)
+			VALUES ($1, $2, $3, $4, $5)
+		`, order.OrderID, discount.PromotionID, discount.Code, discount.Description, discount.Amount)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOrderDiscounts returns the discounts of the orders orderIDs, keyed by
+// order ID.
+func (r *orderRepository) getOrderDiscounts(ctx context.Context, orderIDs []int) (map[int][]models.OrderDiscount, error) {
+	query := `
+		SELECT order_id, promotion_id, code, description, amount
+		FROM order_discounts
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, amount DESC, promotion_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	discounts := make(map[int][]models.OrderDiscount)
+	for rows.Next() {
+		var orderID int
+		var discount models.OrderDiscount
+		if err := rows.Scan(&orderID, &discount.PromotionID, &discount.Code, &discount.Description, &discount.Amount); err != nil {
+			return nil, err
+		}
+		discounts[orderID] = append(discounts[orderID], discount)
+	}
+
+	return discounts, rows.Err()
+}
@@ -0,0 +1,321 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sort"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/fulfilment"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var (
+	// ErrShipmentNotFound is returned when no shipment has the given ID.
+	ErrShipmentNotFound = errors.New("shipment not found")
+	// ErrShipmentQuantityExceeded is returned by CreateShipment when the
+	// shipment holds more of a product than is left to ship.
+	ErrShipmentQuantityExceeded = errors.New("shipment exceeds the quantity left to ship")
+	// ErrOrderFullyShipped is returned by CreateShipment when nothing is left
+	// to ship.
+	ErrOrderFullyShipped = errors.New("order is fully shipped")
+	// ErrOrderNotShippable is returned by CreateShipment and UpdateShipment
+	// when the order has not been paid for.
+	ErrOrderNotShippable = errors.New("order is not paid for")
+	// ErrShipmentDelivered is returned by UpdateShipment when the status of a
+	// delivered shipment would change.
+	ErrShipmentDelivered = errors.New("shipment already delivered")
+)
+
+type querier interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+// CreateShipment records a shipment of some of the items of a paid order, or
+// of everything left to ship when it has no items, and updates the order's
+// status. Returns sql.ErrNoRows if the order does not exist.
+func (r *orderRepository) CreateShipment(ctx context.Context, shipment *models.Shipment) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The order is locked so that concurrent shipments cannot both take the
+	// last units of a product.
+	var orderStatus string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM orders WHERE order_id = $1 FOR UPDATE
+	`, shipment.OrderID).Scan(&orderStatus)
+	if err != nil {
+		return err
+	}
+	if !fulfilment.Shippable(orderStatus) {
+		return ErrOrderNotShippable
+	}
+
+	items, err := queryOrderItems(ctx, tx, shipment.OrderID)
+	if err != nil {
+		return err
+	}
+	shipments, err := queryShipments(ctx, tx, shipment.OrderID)
+	if err != nil {
+		return err
+	}
+
+	remaining := fulfilment.Remaining(items, shipments)
+	if len(shipment.Items) == 0 {
+		for productID, quantity := range remaining {
+			shipment.Items = append(shipment.Items, models.ShipmentItem{ProductID: productID, Quantity: quantity})
+		}
+		sort.Slice(shipment.Items, func(i, j int) bool {
+			return shipment.Items[i].ProductID < shipment.Items[j].ProductID
+		})
+		if len(shipment.Items) == 0 {
+			return ErrOrderFullyShipped
+		}
+	}
+	for _, item := range shipment.Items {
+		if item.Quantity > remaining[item.ProductID] {
+			return ErrShipmentQuantityExceeded
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO shipments (order_id, carrier, tracking_number, status)
+		VALUES ($1, $2, $3, $4)
+		RETURNING shipment_id, created_at, updated_at
+	`, shipment.OrderID, shipment.Carrier, shipment.TrackingNumber, shipment.Status).Scan(&shipment.ShipmentID, &shipment.CreatedAt, &shipment.UpdatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range shipment.Items {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO shipment_items (shipment_id, product_id, quantity)
+			VALUES ($1, $2, $3)
+		`, shipment.ShipmentID, item.ProductID, item.Quantity)
+		if err != nil {
+			return err
+		}
+	}
+
+	event := models.ShipmentEvent{
+		Status:      shipment.Status,
+		Description: "Shipment created",
+		OccurredAt:  shipment.CreatedAt,
+	}
+	if err := insertShipmentEvent(ctx, tx, shipment.ShipmentID, event); err != nil {
+		return err
+	}
+	shipment.Events = []models.ShipmentEvent{event}
+
+	if err := updateOrderStatus(ctx, tx, shipment.OrderID, orderStatus, items, append(shipments, shipment)); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// UpdateShipment sets the carrier and tracking number of a shipment, when not
+// empty, and moves it to the status of event, when not nil, recording the
+// event. The order's status follows.
+func (r *orderRepository) UpdateShipment(ctx context.Context, shipmentID int, carrier, trackingNumber string, event *models.ShipmentEvent) (*models.Shipment, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var orderID int
+	var status string
+	err = tx.QueryRowContext(ctx, `
+		SELECT order_id, status FROM shipments WHERE shipment_id = $1 FOR UPDATE
+	`, shipmentID).Scan(&orderID, &status)
+	if err == sql.ErrNoRows {
+		return nil, ErrShipmentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if event != nil {
+		if status == models.ShipmentDelivered && event.Status != models.ShipmentDelivered {
+			return nil, ErrShipmentDelivered
+		}
+		status = event.Status
+	}
+
+	var orderStatus string
+	err = tx.QueryRowContext(ctx, `
+		SELECT status FROM orders WHERE order_id = $1 FOR UPDATE
+	`, orderID).Scan(&orderStatus)
+	if err != nil {
+		return nil, err
+	}
+	if !fulfilment.Shippable(orderStatus) {
+		return nil, ErrOrderNotShippable
+	}
+
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shipments SET
+			carrier = COALESCE(NULLIF($1, ''), carrier),
+			tracking_number = COALESCE(NULLIF($2, ''), tracking_number),
+			status = $3,
+			updated_at = NOW()
+		WHERE shipment_id = $4
+	`, carrier, trackingNumber, status, shipmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if event != nil {
+		if err := insertShipmentEvent(ctx, tx, shipmentID, *event); err != nil {
+			return nil, err
+		}
+	}
+
+	items, err := queryOrderItems(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+	shipments, err := queryShipments(ctx, tx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := updateOrderStatus(ctx, tx, orderID, orderStatus, items, shipments); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	for _, shipment := range shipments {
+		if shipment.ShipmentID == shipmentID {
+			return shipment, nil
+		}
+	}
+	return nil, ErrShipmentNotFound
+}
+
+func insertShipmentEvent(ctx context.Context, tx *sql.Tx, shipmentID int, event models.ShipmentEvent) error {
+	_, err := tx.ExecContext(ctx, `
+		INSERT INTO shipment_events (shipment_id, status, description, location, occurred_at)
+		VALUES ($1, $2, $3, $4, $5)
+	`, shipmentID, event.Status, event.Description, event.Location, event.OccurredAt)
+	return err
+}
+
+// updateOrderStatus sets the status of an order from its shipments, if it
+// changed. Orders that have not been paid for keep their status.
+func updateOrderStatus(ctx context.Context, tx *sql.Tx, orderID int, current string, items []models.OrderItem, shipments []*models.Shipment) error {
+	if !fulfilment.Shippable(current) {
+		return ErrOrderNotShippable
+	}
+
+	status := fulfilment.OrderStatus(items, shipments)
+	if status == current {
+		return nil
+	}
+
+	_, err := tx.ExecContext(ctx, `UPDATE orders SET status = $1 WHERE order_id = $2`, status, orderID)
+	return err
+}
+
+// queryShipments returns the shipments of the orders orderIDs, oldest first,
+// with their items and events.
+func queryShipments(ctx context.Context, q querier, orderIDs ...int) ([]*models.Shipment, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT shipment_id, order_id, carrier, tracking_number, status, created_at, updated_at
+		FROM shipments
+		WHERE order_id = ANY($1)
+		ORDER BY shipment_id
+	`, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var shipments []*models.Shipment
+	byID := make(map[int]*models.Shipment)
+	for rows.Next() {
+		shipment := &models.Shipment{}
+		err := rows.Scan(
+			&shipment.ShipmentID,
+			&shipment.OrderID,
+			&shipment.Carrier,
+			&shipment.TrackingNumber,
+			&shipment.Status,
+			&shipment.CreatedAt,
+			&shipment.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		shipments = append(shipments, shipment)
+		byID[shipment.ShipmentID] = shipment
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	if len(shipments) == 0 {
+		return nil, nil
+	}
+
+	itemRows, err := q.QueryContext(ctx, `
+		SELECT i.shipment_id, i.product_id, i.quantity
+		FROM shipment_items i
+		JOIN shipments s ON s.shipment_id = i.shipment_id
+		WHERE s.order_id = ANY($1)
+		ORDER BY i.shipment_id, i.product_id
+	`, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer itemRows.Close()
+
+	for itemRows.Next() {
+		var shipmentID int
+		var item models.ShipmentItem
+		if err := itemRows.Scan(&shipmentID, &item.ProductID, &item.Quantity); err != nil {
+			return nil, err
+		}
+		if shipment, ok := byID[shipmentID]; ok {
+			shipment.Items = append(shipment.Items, item)
+		}
+	}
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+	itemRows.Close()
+
+	eventRows, err := q.QueryContext(ctx, `
+		SELECT e.shipment_id, e.status, e.description, e.location, e.occurred_at
+		FROM shipment_events e
+		JOIN shipments s ON s.shipment_id = e.shipment_id
+		WHERE s.order_id = ANY($1)
+		ORDER BY e.occurred_at, e.event_id
+	`, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer eventRows.Close()
+
+	for eventRows.Next() {
+		var shipmentID int
+		var event models.ShipmentEvent
+		if err := eventRows.Scan(&shipmentID, &event.Status, &event.Description, &event.Location, &event.OccurredAt); err != nil {
+			return nil, err
+		}
+		if shipment, ok := byID[shipmentID]; ok {
+			shipment.Events = append(shipment.Events, event)
+		}
+	}
+
+	return shipments, eventRows.Err()
+}
@@ -0,0 +1,223 @@
+package repository
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var (
+	orderItemColumns     = []string{"order_id", "product_id", "quantity"}
+	shipmentColumns      = []string{"shipment_id", "order_id", "carrier", "tracking_number", "status", "created_at", "updated_at"}
+	shipmentItemColumns  = []string{"shipment_id", "product_id", "quantity"}
+	shipmentEventColumns = []string{"shipment_id", "status", "description", "location", "occurred_at"}
+)
+
+func expectLockOrder(mock sqlmock.Sqlmock, orderID int, status string) {
+	mock.ExpectQuery("SELECT status FROM orders WHERE order_id = \\$1 FOR UPDATE").
+		WithArgs(orderID).
+		WillReturnRows(sqlmock.NewRows([]string{"status"}).AddRow(status))
+}
+
+// expectShipments expects queryShipments to load shipment of order 5, which
+// holds 2 of product 10.
+func expectShipments(mock sqlmock.Sqlmock, shipmentID int, status string) {
+	now := time.Now()
+	mock.ExpectQuery("FROM shipments WHERE order_id = ANY\\(\\$1\\)").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(shipmentColumns).AddRow(shipmentID, 5, "DHL", "T1", status, now, now))
+	mock.ExpectQuery("FROM shipment_items").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(shipmentItemColumns).AddRow(shipmentID, 10, 2))
+	mock.ExpectQuery("FROM shipment_events").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(shipmentEventColumns).AddRow(shipmentID, status, "Shipment created", "", now))
+}
+
+func TestCreateShipment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectBegin()
+	expectLockOrder(mock, 5, models.OrderPartiallyShipped)
+	mock.ExpectQuery("FROM order_items").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow(5, 10, 3).AddRow(5, 11, 1))
+	expectShipments(mock, 1, models.ShipmentInTransit)
+	mock.ExpectQuery("INSERT INTO shipments").
+		WithArgs(5, "DHL", "T2", models.ShipmentInTransit).
+		WillReturnRows(sqlmock.NewRows([]string{"shipment_id", "created_at", "updated_at"}).AddRow(2, now, now))
+	mock.ExpectExec("INSERT INTO shipment_items").
+		WithArgs(2, 10, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO shipment_items").
+		WithArgs(2, 11, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO shipment_events").
+		WithArgs(2, models.ShipmentInTransit, "Shipment created", "", now).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// Everything has now left, so the order is shipped.
+	mock.ExpectExec("UPDATE orders SET status").
+		WithArgs(models.OrderShipped, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	// Without items, the shipment holds everything left to ship.
+	shipment := &models.Shipment{OrderID: 5, Carrier: "DHL", TrackingNumber: "T2", Status: models.ShipmentInTransit}
+	if err := NewOrderRepository(db).CreateShipment(context.Background(), shipment); err != nil {
+		t.Fatalf("CreateShipment failed: %v", err)
+	}
+	if shipment.ShipmentID != 2 || len(shipment.Items) != 2 || shipment.Items[0].Quantity != 1 {
+		t.Errorf("Unexpected shipment: %+v", shipment)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCreateShipmentQuantityExceeded(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	expectLockOrder(mock, 5, models.OrderPartiallyShipped)
+	mock.ExpectQuery("FROM order_items").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow(5, 10, 3))
+	expectShipments(mock, 1, models.ShipmentInTransit)
+	mock.ExpectRollback()
+
+	// 2 of the 3 units have already shipped.
+	shipment := &models.Shipment{
+		OrderID: 5,
+		Status:  models.ShipmentPending,
+		Items:   []models.ShipmentItem{{ProductID: 10, Quantity: 2}},
+	}
+	err = NewOrderRepository(db).CreateShipment(context.Background(), shipment)
+	if err != ErrShipmentQuantityExceeded {
+		t.Errorf("Expected ErrShipmentQuantityExceeded, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCreateShipmentForUnpaidOrder(t *testing.T) {
+	for _, status := range []string{models.OrderPendingPayment, models.OrderPaymentFailed} {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create sqlmock: %v", err)
+		}
+
+		mock.ExpectBegin()
+		expectLockOrder(mock, 5, status)
+		mock.ExpectRollback()
+
+		err = NewOrderRepository(db).CreateShipment(context.Background(), &models.Shipment{OrderID: 5, Status: models.ShipmentPending})
+		if err != ErrOrderNotShippable {
+			t.Errorf("Expected ErrOrderNotShippable for %q, got %v", status, err)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("Unfulfilled expectations: %v", err)
+		}
+		db.Close()
+	}
+}
+
+func TestUpdateShipment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	occurredAt := time.Now()
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT order_id, status FROM shipments WHERE shipment_id = \\$1 FOR UPDATE").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow(5, models.ShipmentInTransit))
+	expectLockOrder(mock, 5, models.OrderShipped)
+	mock.ExpectExec("UPDATE shipments SET").
+		WithArgs("", "", models.ShipmentDelivered, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("INSERT INTO shipment_events").
+		WithArgs(1, models.ShipmentDelivered, "Left at the door", "", occurredAt).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("FROM order_items").
+		WithArgs("{5}").
+		WillReturnRows(sqlmock.NewRows(orderItemColumns).AddRow(5, 10, 2))
+	expectShipments(mock, 1, models.ShipmentDelivered)
+	mock.ExpectExec("UPDATE orders SET status").
+		WithArgs(models.OrderDelivered, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	event := &models.ShipmentEvent{Status: models.ShipmentDelivered, Description: "Left at the door", OccurredAt: occurredAt}
+	shipment, err := NewOrderRepository(db).UpdateShipment(context.Background(), 1, "", "", event)
+	if err != nil {
+		t.Fatalf("UpdateShipment failed: %v", err)
+	}
+	if shipment.ShipmentID != 1 || shipment.Status != models.ShipmentDelivered {
+		t.Errorf("Unexpected shipment: %+v", shipment)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestUpdateDeliveredShipment(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT order_id, status FROM shipments").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow(5, models.ShipmentDelivered))
+	mock.ExpectRollback()
+
+	event := &models.ShipmentEvent{Status: models.ShipmentInTransit, OccurredAt: time.Now()}
+	_, err = NewOrderRepository(db).UpdateShipment(context.Background(), 1, "", "", event)
+	if err != ErrShipmentDelivered {
+		t.Errorf("Expected ErrShipmentDelivered, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestUpdateShipmentOfUnpaidOrder(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("SELECT order_id, status FROM shipments").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"order_id", "status"}).AddRow(5, models.ShipmentPending))
+	expectLockOrder(mock, 5, models.OrderPaymentFailed)
+	mock.ExpectRollback()
+
+	event := &models.ShipmentEvent{Status: models.ShipmentInTransit, OccurredAt: time.Now()}
+	_, err = NewOrderRepository(db).UpdateShipment(context.Background(), 1, "", "", event)
+	if err != ErrOrderNotShippable {
+		t.Errorf("Expected ErrOrderNotShippable, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
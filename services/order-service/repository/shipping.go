@@ -0,0 +1,212 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var (
+	// ErrShippingZoneNotFound is returned when no zone has the given ID or
+	// contains the given country.
+	ErrShippingZoneNotFound = errors.New("shipping zone not found")
+	// ErrShippingZoneNameTaken is returned when another zone already has the
+	// name.
+	ErrShippingZoneNameTaken = errors.New("shipping zone name already in use")
+	// ErrShippingCountryTaken is returned when a country is already in
+	// another zone.
+	ErrShippingCountryTaken = errors.New("country already in a shipping zone")
+	// ErrShippingMethodNotFound is returned when no shipping method has the
+	// given ID.
+	ErrShippingMethodNotFound = errors.New("shipping method not found")
+)
+
+func (r *orderRepository) CreateShippingZone(ctx context.Context, zone *models.ShippingZone) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO shipping_zones (name) VALUES ($1)
+		RETURNING zone_id, created_at
+	`, zone.Name).Scan(&zone.ZoneID, &zone.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrShippingZoneNameTaken
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, country := range zone.Countries {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO shipping_zone_countries (country, zone_id) VALUES ($1, $2)
+		`, country, zone.ZoneID)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrShippingCountryTaken
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *orderRepository) ListShippingZones(ctx context.Context) ([]*models.ShippingZone, error) {
+	query := `
+		SELECT z.zone_id, z.name, z.created_at,
+			COALESCE(array_agg(c.country ORDER BY c.country) FILTER (WHERE c.country IS NOT NULL), '{}')
+		FROM shipping_zones z
+		LEFT JOIN shipping_zone_countries c ON c.zone_id = z.zone_id
+		GROUP BY z.zone_id
+		ORDER BY z.name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var zones []*models.ShippingZone
+	for rows.Next() {
+		zone := &models.ShippingZone{}
+		err := rows.Scan(&zone.ZoneID, &zone.Name, &zone.CreatedAt, pq.Array(&zone.Countries))
+		if err != nil {
+			return nil, err
+		}
+		zones = append(zones, zone)
+	}
+
+	return zones, rows.Err()
+}
+
+// GetShippingZoneIDByCountry returns the zone of country, which must already
+// be upper-cased.
+func (r *orderRepository) GetShippingZoneIDByCountry(ctx context.Context, country string) (int, error) {
+	var zoneID int
+	err := r.db.QueryRowContext(ctx, `
+		SELECT zone_id FROM shipping_zone_countries WHERE country = $1
+	`, country).Scan(&zoneID)
+	if err == sql.ErrNoRows {
+		return 0, ErrShippingZoneNotFound
+	}
+	return zoneID, err
+}
+
+// CreateShippingMethod creates a method along with its rate table.
+func (r *orderRepository) CreateShippingMethod(ctx context.Context, method *models.ShippingMethod) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO shipping_methods (carrier, name, min_delivery_days, max_delivery_days, active)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING method_id, created_at
+	`, method.Carrier, method.Name, method.MinDeliveryDays, method.MaxDeliveryDays, method.Active).Scan(&method.MethodID, &method.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	for _, rate := range method.Rates {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO shipping_rates (method_id, zone_id, max_weight_grams, price)
+			VALUES ($1, $2, $3, $4)
+		`, method.MethodID, rate.ZoneID, rate.MaxWeightGrams, rate.Price)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23503" {
+			return ErrShippingZoneNotFound
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListShippingMethods returns the shipping methods with their rate tables,
+// only the active ones if activeOnly is set.
+func (r *orderRepository) ListShippingMethods(ctx context.Context, activeOnly bool) ([]*models.ShippingMethod, error) {
+	query := `
+		SELECT method_id, carrier, name, min_delivery_days, max_delivery_days, active, created_at
+		FROM shipping_methods
+		WHERE active OR NOT $1
+		ORDER BY method_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, activeOnly)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var methods []*models.ShippingMethod
+	byID := make(map[int]*models.ShippingMethod)
+	for rows.Next() {
+		method := &models.ShippingMethod{}
+		err := rows.Scan(
+			&method.MethodID,
+			&method.Carrier,
+			&method.Name,
+			&method.MinDeliveryDays,
+			&method.MaxDeliveryDays,
+			&method.Active,
+			&method.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		methods = append(methods, method)
+		byID[method.MethodID] = method
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	rateRows, err := r.db.QueryContext(ctx, `
+		SELECT method_id, zone_id, max_weight_grams, price
+		FROM shipping_rates
+		ORDER BY method_id, zone_id, max_weight_grams
+	`)
+	if err != nil {
+		return nil, err
+	}
+	defer rateRows.Close()
+
+	for rateRows.Next() {
+		var methodID int
+		var rate models.ShippingRate
+		if err := rateRows.Scan(&methodID, &rate.ZoneID, &rate.MaxWeightGrams, &rate.Price); err != nil {
+			return nil, err
+		}
+		if method, ok := byID[methodID]; ok {
+			method.Rates = append(method.Rates, rate)
+		}
+	}
+
+	return methods, rateRows.Err()
+}
+
+func (r *orderRepository) SetShippingMethodActive(ctx context.Context, methodID int, active bool) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE shipping_methods SET active = $1 WHERE method_id = $2
+	`, active, methodID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrShippingMethodNotFound
+	}
+
+	return nil
+}
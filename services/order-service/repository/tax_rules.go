@@ -0,0 +1,162 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var (
+	// ErrTaxRuleNotFound is returned when no tax rule has the given ID.
+	ErrTaxRuleNotFound = errors.New("tax rule not found")
+	// ErrTaxRuleExists is returned when another rule already covers the same
+	// country, region and tax category.
+	ErrTaxRuleExists = errors.New("tax rule already exists")
+)
+
+const taxRuleColumns = `tax_rule_id, country, region, tax_category, name, rate, inclusive, created_at`
+
+func (r *orderRepository) queryTaxRules(ctx context.Context, query string, args ...interface{}) ([]*models.TaxRule, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var rules []*models.TaxRule
+	for rows.Next() {
+		rule := &models.TaxRule{}
+		err := rows.Scan(
+			&rule.TaxRuleID,
+			&rule.Country,
+			&rule.Region,
+			&rule.TaxCategory,
+			&rule.Name,
+			&rule.Rate,
+			&rule.Inclusive,
+			&rule.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, rows.Err()
+}
+
+func (r *orderRepository) CreateTaxRule(ctx context.Context, rule *models.TaxRule) error {
+	query := `
+		INSERT INTO tax_rules (country, region, tax_category, name, rate, inclusive)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING tax_rule_id, created_at
+	`
+
+	err := r.db.QueryRowContext(ctx, query,
+		rule.Country,
+		rule.Region,
+		rule.TaxCategory,
+		rule.Name,
+		rule.Rate,
+		rule.Inclusive,
+	).Scan(&rule.TaxRuleID, &rule.CreatedAt)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		return ErrTaxRuleExists
+	}
+	return err
+}
+
+func (r *orderRepository) ListTaxRules(ctx context.Context) ([]*models.TaxRule, error) {
+	query := `
+		SELECT ` + taxRuleColumns + `
+		FROM tax_rules
+		ORDER BY country, region, tax_category
+	`
+
+	return r.queryTaxRules(ctx, query)
+}
+
+// ListTaxRulesForCountry returns the rules of country, which must already be
+// upper-cased, for every region and tax category.
+func (r *orderRepository) ListTaxRulesForCountry(ctx context.Context, country string) ([]*models.TaxRule, error) {
+	query := `
+		SELECT ` + taxRuleColumns + `
+		FROM tax_rules
+		WHERE country = $1
+		ORDER BY region, tax_category
+	`
+
+	return r.queryTaxRules(ctx, query, country)
+}
+
+// DeleteTaxRule deletes a rule. Orders it was applied to keep their tax
+// lines.
+func (r *orderRepository) DeleteTaxRule(ctx context.Context, taxRuleID int) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM tax_rules WHERE tax_rule_id = $1`, taxRuleID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrTaxRuleNotFound
+	}
+
+	return nil
+}
+
+// saveTaxLines records the tax lines of an order.
+func saveTaxLines(ctx context.Context, tx *sql.Tx, order *models.Order) error {
+	query := `
+		INSERT INTO order_tax_lines (order_id, tax_rule_id, name, rate, inclusive, taxable_amount, amount)
+		VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7)
+	`
+	for _, line := range order.TaxLines {
+		_, err := tx.ExecContext(ctx, query,
+			order.OrderID,
+			line.TaxRuleID,
+			line.Name,
+			line.Rate,
+			line.Inclusive,
+			line.TaxableAmount,
+			line.Amount,
+		)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// getOrderTaxLines returns the tax lines of the orders orderIDs, keyed by
+// order ID.
+func (r *orderRepository) getOrderTaxLines(ctx context.Context, orderIDs []int) (map[int][]models.OrderTaxLine, error) {
+	query := `
+		SELECT order_id, COALESCE(tax_rule_id, 0), name, rate, inclusive, taxable_amount, amount
+		FROM order_tax_lines
+		WHERE order_id = ANY($1)
+		ORDER BY order_id, amount DESC, name
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(orderIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	taxLines := make(map[int][]models.OrderTaxLine)
+	for rows.Next() {
+		var orderID int
+		var line models.OrderTaxLine
+		err := rows.Scan(&orderID, &line.TaxRuleID, &line.Name, &line.Rate, &line.Inclusive, &line.TaxableAmount, &line.Amount)
+		if err != nil {
+			return nil, err
+		}
+		taxLines[orderID] = append(taxLines[orderID], line)
+	}
+
+	return taxLines, rows.Err()
+}
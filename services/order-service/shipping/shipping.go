@@ -0,0 +1,61 @@
+// Package shipping quotes shipping methods from their weight-based rate tables.
+package shipping
+
+import (
+	"sort"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+// Rate returns the price of shipping a parcel of weight grams to zoneID:
+// the rate of the smallest weight bracket it fits in. ok is false when none
+// of rates covers it.
+func Rate(rates []models.ShippingRate, zoneID int, weight int64) (price float64, ok bool) {
+	var best *models.ShippingRate
+	for i := range rates {
+		rate := &rates[i]
+		if rate.ZoneID != zoneID {
+			continue
+		}
+		if rate.MaxWeightGrams != 0 && int64(rate.MaxWeightGrams) < weight {
+			continue
+		}
+		if best == nil || fitsTighter(rate, best) {
+			best = rate
+		}
+	}
+
+	if best == nil {
+		return 0, false
+	}
+	return best.Price, true
+}
+
+// fitsTighter reports whether rate's bracket is smaller than other's. A
+// bracket without an upper limit is the largest.
+func fitsTighter(rate, other *models.ShippingRate) bool {
+	if other.MaxWeightGrams == 0 {
+		return rate.MaxWeightGrams != 0
+	}
+	return rate.MaxWeightGrams != 0 && rate.MaxWeightGrams < other.MaxWeightGrams
+}
+
+// Quotes returns what each active method in methods charges to ship weight
+// grams to zoneID, cheapest first. Methods without a rate for the parcel are
+// left out.
+func Quotes(methods []*models.ShippingMethod, zoneID int, weight int64) []models.ShippingQuote {
+	var quotes []models.ShippingQuote
+	for _, method := range methods {
+		if !method.Active {
+			continue
+		}
+		if price, ok := Rate(method.Rates, zoneID, weight); ok {
+			quotes = append(quotes, models.ShippingQuote{Method: method, Amount: price})
+		}
+	}
+
+	sort.SliceStable(quotes, func(i, j int) bool {
+		return quotes[i].Amount < quotes[j].Amount
+	})
+	return quotes
+}
@@ -0,0 +1,56 @@
+package shipping
+
+import (
+	"testing"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var rates = []models.ShippingRate{
+	{ZoneID: 1, MaxWeightGrams: 2000, Price: 8},
+	{ZoneID: 1, MaxWeightGrams: 500, Price: 4},
+	{ZoneID: 1, MaxWeightGrams: 0, Price: 25},
+	{ZoneID: 2, MaxWeightGrams: 1000, Price: 15},
+}
+
+func TestRate(t *testing.T) {
+	tests := []struct {
+		name   string
+		zoneID int
+		weight int64
+		price  float64
+		ok     bool
+	}{
+		{"smallest bracket", 1, 300, 4, true},
+		{"bracket limit is inclusive", 1, 500, 4, true},
+		{"middle bracket", 1, 1200, 8, true},
+		{"unlimited bracket", 1, 30000, 25, true},
+		{"other zone", 2, 800, 15, true},
+		{"too heavy for zone", 2, 1500, 0, false},
+		{"zone without rates", 3, 100, 0, false},
+	}
+
+	for _, tt := range tests {
+		price, ok := Rate(rates, tt.zoneID, tt.weight)
+		if price != tt.price || ok != tt.ok {
+			t.Errorf("%s: expected %v %v, got %v %v", tt.name, tt.price, tt.ok, price, ok)
+		}
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	methods := []*models.ShippingMethod{
+		{MethodID: 1, Name: "Express", Active: true, Rates: []models.ShippingRate{{ZoneID: 1, Price: 20}}},
+		{MethodID: 2, Name: "Standard", Active: true, Rates: rates},
+		{MethodID: 3, Name: "Retired", Active: false, Rates: []models.ShippingRate{{ZoneID: 1, Price: 1}}},
+		{MethodID: 4, Name: "Light only", Active: true, Rates: []models.ShippingRate{{ZoneID: 1, MaxWeightGrams: 100, Price: 2}}},
+	}
+
+	quotes := Quotes(methods, 1, 1200)
+	if len(quotes) != 2 {
+		t.Fatalf("Expected 2 quotes, got %+v", quotes)
+	}
+	if quotes[0].Method.MethodID != 2 || quotes[0].Amount != 8 || quotes[1].Method.MethodID != 1 {
+		t.Errorf("Expected the cheapest quote first, got %+v", quotes)
+	}
+}
@@ -0,0 +1,146 @@
+// Package tax matches tax rules to order lines and computes the tax they owe.
+package tax
+
+import (
+	"math"
+	"strings"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+// Line is one product of an order and the amount paid for it.
+type Line struct {
+	TaxCategory string
+	Amount      float64
+}
+
+// Allocate spreads an order-wide discount over lines in proportion to their
+// amounts, so that each line is taxed on what is paid for it. The last line
+// takes the rounding difference.
+func Allocate(lines []Line, discount float64) []Line {
+	var subtotal float64
+	for _, line := range lines {
+		subtotal += line.Amount
+	}
+
+	allocated := make([]Line, len(lines))
+	copy(allocated, lines)
+	if discount <= 0 || subtotal <= 0 {
+		return allocated
+	}
+	discount = math.Min(discount, subtotal)
+
+	remaining := discount
+	for i := range allocated {
+		share := roundPrice(discount * lines[i].Amount / subtotal)
+		if i == len(allocated)-1 {
+			share = roundPrice(remaining)
+		}
+		share = math.Min(share, allocated[i].Amount)
+		allocated[i].Amount = roundPrice(allocated[i].Amount - share)
+		remaining -= share
+	}
+	return allocated
+}
+
+// Match returns the rule that taxes category in country and region, or nil
+// when nothing does. A rule for the region beats one for the whole country,
+// and within each a rule for the category beats one for every category.
+func Match(rules []*models.TaxRule, country, region, category string) *models.TaxRule {
+	var best *models.TaxRule
+	bestScore := -1
+	for _, rule := range rules {
+		if !strings.EqualFold(rule.Country, country) {
+			continue
+		}
+		if rule.Region != "" && !strings.EqualFold(rule.Region, strings.TrimSpace(region)) {
+			continue
+		}
+		if rule.TaxCategory != "" && rule.TaxCategory != category {
+			continue
+		}
+
+		score := 0
+		if rule.Region != "" {
+			score += 2
+		}
+		if rule.TaxCategory != "" {
+			score++
+		}
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+	return best
+}
+
+// Calculate returns the tax lines of an order shipping to country and
+// region: one per rule that applies, taxing the lines it matches. Lines
+// without a tax category are taxed as models.DefaultTaxCategory.
+func Calculate(rules []*models.TaxRule, country, region string, lines []Line) []models.OrderTaxLine {
+	var taxLines []models.OrderTaxLine
+	index := make(map[int]int)
+	for _, line := range lines {
+		category := line.TaxCategory
+		if category == "" {
+			category = models.DefaultTaxCategory
+		}
+
+		rule := Match(rules, country, region, category)
+		if rule == nil || line.Amount <= 0 {
+			continue
+		}
+
+		i, ok := index[rule.TaxRuleID]
+		if !ok {
+			i = len(taxLines)
+			index[rule.TaxRuleID] = i
+			taxLines = append(taxLines, models.OrderTaxLine{
+				TaxRuleID: rule.TaxRuleID,
+				Name:      rule.Name,
+				Rate:      rule.Rate,
+				Inclusive: rule.Inclusive,
+			})
+		}
+		taxLines[i].TaxableAmount = roundPrice(taxLines[i].TaxableAmount + line.Amount)
+	}
+
+	// Tax is rounded once per rule rather than once per line.
+	for i := range taxLines {
+		taxLines[i].Amount = Amount(taxLines[i].TaxableAmount, taxLines[i].Rate, taxLines[i].Inclusive)
+	}
+	return taxLines
+}
+
+// Amount returns the tax at rate on amount. An inclusive amount already
+// contains the tax, so it is extracted rather than added.
+func Amount(amount, rate float64, inclusive bool) float64 {
+	if inclusive {
+		return roundPrice(amount * rate / (100 + rate))
+	}
+	return roundPrice(amount * rate / 100)
+}
+
+// Total returns the tax of all taxLines.
+func Total(taxLines []models.OrderTaxLine) float64 {
+	var total float64
+	for _, line := range taxLines {
+		total += line.Amount
+	}
+	return roundPrice(total)
+}
+
+// Exclusive returns the tax of taxLines that is added to the order total.
+func Exclusive(taxLines []models.OrderTaxLine) float64 {
+	var total float64
+	for _, line := range taxLines {
+		if !line.Inclusive {
+			total += line.Amount
+		}
+	}
+	return roundPrice(total)
+}
+
+func roundPrice(amount float64) float64 {
+	return math.Round(amount*100) / 100
+}
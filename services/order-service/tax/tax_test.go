@@ -0,0 +1,92 @@
+package tax
+
+import (
+	"testing"
+
+	"github.com/metal-oopa/EcomMicroservices/services/order-service/models"
+)
+
+var rules = []*models.TaxRule{
+	{TaxRuleID: 1, Country: "US", Region: "CA", Name: "California sales tax", Rate: 7.25},
+	{TaxRuleID: 2, Country: "US", Region: "CA", TaxCategory: "food", Name: "California food", Rate: 0},
+	{TaxRuleID: 3, Country: "GB", Name: "VAT", Rate: 20, Inclusive: true},
+	{TaxRuleID: 4, Country: "GB", TaxCategory: "reduced", Name: "Reduced VAT", Rate: 5, Inclusive: true},
+	{TaxRuleID: 5, Country: "US", Name: "Federal", Rate: 1},
+}
+
+func TestMatch(t *testing.T) {
+	tests := []struct {
+		name                      string
+		country, region, category string
+		want                      int
+	}{
+		{"region", "US", "CA", "standard", 1},
+		{"region and category", "US", "ca", "food", 2},
+		{"country fallback", "US", "NY", "standard", 5},
+		{"country and category", "GB", "", "reduced", 4},
+		{"country", "gb", "Scotland", "standard", 3},
+		{"untaxed country", "FR", "", "standard", 0},
+	}
+
+	for _, tt := range tests {
+		got := 0
+		if rule := Match(rules, tt.country, tt.region, tt.category); rule != nil {
+			got = rule.TaxRuleID
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected rule %d, got %d", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestCalculate(t *testing.T) {
+	lines := []Line{
+		{TaxCategory: "standard", Amount: 100},
+		{TaxCategory: "", Amount: 20},
+		{TaxCategory: "reduced", Amount: 10.5},
+	}
+
+	taxLines := Calculate(rules, "GB", "", lines)
+	if len(taxLines) != 2 {
+		t.Fatalf("Expected 2 tax lines, got %+v", taxLines)
+	}
+	if vat := taxLines[0]; vat.TaxRuleID != 3 || vat.TaxableAmount != 120 || vat.Amount != 20 {
+		t.Errorf("Unexpected VAT line: %+v", vat)
+	}
+	if reduced := taxLines[1]; reduced.TaxRuleID != 4 || reduced.Amount != 0.5 {
+		t.Errorf("Unexpected reduced VAT line: %+v", reduced)
+	}
+	if Exclusive(taxLines) != 0 || Total(taxLines) != 20.5 {
+		t.Errorf("Expected 20.50 inclusive tax, got %+v", taxLines)
+	}
+
+	taxLines = Calculate(rules, "US", "CA", lines)
+	if Exclusive(taxLines) != 9.46 {
+		t.Errorf("Expected 9.46 exclusive tax, got %+v", taxLines)
+	}
+
+	if taxLines := Calculate(rules, "", "", lines); len(taxLines) != 0 {
+		t.Errorf("Expected no tax without an address, got %+v", taxLines)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	lines := []Line{{Amount: 30}, {Amount: 60}, {Amount: 10}}
+
+	allocated := Allocate(lines, 10)
+	if allocated[0].Amount != 27 || allocated[1].Amount != 54 || allocated[2].Amount != 9 {
+		t.Errorf("Unexpected allocation: %+v", allocated)
+	}
+	if lines[0].Amount != 30 {
+		t.Errorf("Allocate modified its input: %+v", lines)
+	}
+
+	allocated = Allocate([]Line{{Amount: 1}, {Amount: 1}, {Amount: 1}}, 1)
+	var total float64
+	for _, line := range allocated {
+		total += line.Amount
+	}
+	if roundPrice(total) != 2 {
+		t.Errorf("Expected the whole discount to be allocated, got %+v", allocated)
+	}
+}
@@ -3,6 +3,7 @@ package utils
 import (
 	"github.com/stripe/stripe-go/v72"
 	"github.com/stripe/stripe-go/v72/paymentintent"
+	"github.com/stripe/stripe-go/v72/refund"
 )
 
 func InitializeStripe(apiKey string) {
@@ -19,3 +20,11 @@ func CreatePaymentIntent(amount int64, currency, paymentMethodID string) (*strip
 
 	return paymentintent.New(params)
 }
+
+// RefundPaymentIntent refunds a confirmed payment intent in full.
+func RefundPaymentIntent(paymentIntentID string) error {
+	_, err := refund.New(&stripe.RefundParams{
+		PaymentIntent: stripe.String(paymentIntentID),
+	})
+	return err
+}
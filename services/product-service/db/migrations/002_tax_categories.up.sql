@@ -0,0 +1,3 @@
+-- Products are taxed according to their category, such as "standard",
+-- "reduced" or "exempt"; Order Service holds the rates per category.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS tax_category TEXT NOT NULL DEFAULT 'standard';
@@ -0,0 +1,3 @@
+-- Shipping weight of one unit; Order Service prices shipping by the total
+-- weight of an order.
+ALTER TABLE products ADD COLUMN IF NOT EXISTS weight_grams INT NOT NULL DEFAULT 0;
@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"context"
+	"regexp"
 	"strconv"
 
 	"github.com/metal-oopa/EcomMicroservices/services/product-service/models"
@@ -21,15 +22,24 @@ func NewProductServiceServer(repo repository.ProductRepository) productpb.Produc
 }
 
 func (s *ProductServiceServer) CreateProduct(ctx context.Context, req *productpb.CreateProductRequest) (*productpb.CreateProductResponse, error) {
-	if req.Name == "" || req.Price <= 0 || req.Quantity < 0 {
+	if req.Name == "" || req.Price <= 0 || req.Quantity < 0 || req.WeightGrams < 0 {
 		return nil, status.Errorf(codes.InvalidArgument, "invalid input")
 	}
 
+	if !validTaxCategory(req.TaxCategory) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tax category")
+	}
+
 	product := &models.Product{
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Quantity:    req.Quantity,
+		TaxCategory: req.TaxCategory,
+		WeightGrams: req.WeightGrams,
+	}
+	if product.TaxCategory == "" {
+		product.TaxCategory = models.DefaultTaxCategory
 	}
 
 	err := s.repo.CreateProduct(ctx, product)
@@ -58,6 +68,42 @@ func (s *ProductServiceServer) GetProduct(ctx context.Context, req *productpb.Ge
 	}, nil
 }
 
+// maxBatchSize bounds how many products GetProducts looks up at once.
+const maxBatchSize = 100
+
+func (s *ProductServiceServer) GetProducts(ctx context.Context, req *productpb.GetProductsRequest) (*productpb.GetProductsResponse, error) {
+	if len(req.ProductIds) > maxBatchSize {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d products can be requested at once", maxBatchSize)
+	}
+
+	var productIDs []int
+	for _, id := range req.ProductIds {
+		productID, err := strconv.Atoi(id)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid product ID %q", id)
+		}
+		productIDs = append(productIDs, productID)
+	}
+
+	if len(productIDs) == 0 {
+		return &productpb.GetProductsResponse{}, nil
+	}
+
+	products, err := s.repo.GetProductsByIDs(ctx, productIDs)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get products: %v", err)
+	}
+
+	var productProtos []*productpb.Product
+	for _, product := range products {
+		productProtos = append(productProtos, mapProductToProto(product))
+	}
+
+	return &productpb.GetProductsResponse{
+		Products: productProtos,
+	}, nil
+}
+
 func (s *ProductServiceServer) ListProducts(ctx context.Context, req *productpb.ListProductsRequest) (*productpb.ListProductsResponse, error) {
 	products, err := s.repo.ListProducts(ctx)
 	if err != nil {
@@ -80,12 +126,24 @@ func (s *ProductServiceServer) UpdateProduct(ctx context.Context, req *productpb
 		return nil, status.Errorf(codes.InvalidArgument, "invalid product ID")
 	}
 
+	if !validTaxCategory(req.TaxCategory) {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid tax category")
+	}
+
 	product := &models.Product{
 		ProductID:   productID,
 		Name:        req.Name,
 		Description: req.Description,
 		Price:       req.Price,
 		Quantity:    req.Quantity,
+		TaxCategory: req.TaxCategory,
+		WeightGrams: -1,
+	}
+	if req.WeightGrams != nil {
+		if *req.WeightGrams < 0 {
+			return nil, status.Errorf(codes.InvalidArgument, "weight cannot be negative")
+		}
+		product.WeightGrams = *req.WeightGrams
 	}
 
 	err = s.repo.UpdateProduct(ctx, product)
@@ -127,5 +185,15 @@ func mapProductToProto(product *models.Product) *productpb.Product {
 		Description: product.Description,
 		Price:       product.Price,
 		Quantity:    product.Quantity,
+		TaxCategory: product.TaxCategory,
+		WeightGrams: product.WeightGrams,
 	}
 }
+
+var taxCategoryPattern = regexp.MustCompile(`^[a-z0-9_]{1,50}$`)
+
+// validTaxCategory reports whether category is empty, meaning the default,
+// or a lowercase identifier such as "reduced".
+func validTaxCategory(category string) bool {
+	return category == "" || taxCategoryPattern.MatchString(category)
+}
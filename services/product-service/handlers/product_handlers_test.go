@@ -5,8 +5,11 @@ import (
 	"testing"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/metal-oopa/EcomMicroservices/services/product-service/productpb"
 	"github.com/metal-oopa/EcomMicroservices/services/product-service/repository"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 func TestCreateProduct(t *testing.T) {
@@ -19,7 +22,7 @@ func TestCreateProduct(t *testing.T) {
 
 	// Prepare mock expectations
 	mock.ExpectQuery("INSERT INTO products").
-		WithArgs("Test Product", "Description", 99.99, 10).
+		WithArgs("Test Product", "Description", 99.99, 10, "standard", 0).
 		WillReturnRows(sqlmock.NewRows([]string{"product_id"}).AddRow(1))
 
 	repo := repository.NewProductRepository(db)
@@ -44,3 +47,34 @@ func TestCreateProduct(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestGetProducts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("WHERE product_id = ANY").
+		WithArgs(pq.Array([]int{1, 2})).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "name", "description", "price", "quantity", "tax_category", "weight_grams"}).
+			AddRow(1, "Test Product", "Description", 9.99, 5, "standard", 250))
+
+	handler := NewProductServiceServer(repository.NewProductRepository(db))
+
+	resp, err := handler.GetProducts(context.Background(), &productpb.GetProductsRequest{ProductIds: []string{"1", "2"}})
+	if err != nil {
+		t.Fatalf("GetProducts failed: %v", err)
+	}
+	if len(resp.Products) != 1 || resp.Products[0].ProductId != "1" {
+		t.Errorf("Expected only the existing product, got %v", resp.Products)
+	}
+
+	_, err = handler.GetProducts(context.Background(), &productpb.GetProductsRequest{ProductIds: []string{"abc"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed ID, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
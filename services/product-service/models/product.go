@@ -1,9 +1,14 @@
 package models
 
+// DefaultTaxCategory is the tax category of products created without one.
+const DefaultTaxCategory = "standard"
+
 type Product struct {
 	ProductID   int
 	Name        string
 	Description string
 	Price       float64
 	Quantity    int32
+	TaxCategory string
+	WeightGrams int32
 }
@@ -5,12 +5,14 @@ import (
 	"database/sql"
 	"errors"
 
+	"github.com/lib/pq"
 	"github.com/metal-oopa/EcomMicroservices/services/product-service/models"
 )
 
 type ProductRepository interface {
 	CreateProduct(ctx context.Context, product *models.Product) error
 	GetProductByID(ctx context.Context, productID int) (*models.Product, error)
+	GetProductsByIDs(ctx context.Context, productIDs []int) ([]*models.Product, error)
 	ListProducts(ctx context.Context) ([]*models.Product, error)
 	UpdateProduct(ctx context.Context, product *models.Product) error
 	DeleteProduct(ctx context.Context, productID int) error
@@ -26,18 +28,18 @@ func NewProductRepository(db *sql.DB) ProductRepository {
 
 func (r *productRepository) CreateProduct(ctx context.Context, product *models.Product) error {
 	query := `
-		INSERT INTO products (name, description, price, quantity)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO products (name, description, price, quantity, tax_category, weight_grams)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING product_id
 	`
 
-	err := r.db.QueryRowContext(ctx, query, product.Name, product.Description, product.Price, product.Quantity).Scan(&product.ProductID)
+	err := r.db.QueryRowContext(ctx, query, product.Name, product.Description, product.Price, product.Quantity, product.TaxCategory, product.WeightGrams).Scan(&product.ProductID)
 	return err
 }
 
 func (r *productRepository) GetProductByID(ctx context.Context, productID int) (*models.Product, error) {
 	query := `
-		SELECT product_id, name, description, price, quantity
+		SELECT product_id, name, description, price, quantity, tax_category, weight_grams
 		FROM products
 		WHERE product_id = $1
 	`
@@ -49,6 +51,8 @@ func (r *productRepository) GetProductByID(ctx context.Context, productID int) (
 		&product.Description,
 		&product.Price,
 		&product.Quantity,
+		&product.TaxCategory,
+		&product.WeightGrams,
 	)
 
 	if err == sql.ErrNoRows {
@@ -57,9 +61,42 @@ func (r *productRepository) GetProductByID(ctx context.Context, productID int) (
 	return product, err
 }
 
+func (r *productRepository) GetProductsByIDs(ctx context.Context, productIDs []int) ([]*models.Product, error) {
+	query := `
+		SELECT product_id, name, description, price, quantity, tax_category, weight_grams
+		FROM products
+		WHERE product_id = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(productIDs))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var products []*models.Product
+	for rows.Next() {
+		product := &models.Product{}
+		err := rows.Scan(
+			&product.ProductID,
+			&product.Name,
+			&product.Description,
+			&product.Price,
+			&product.Quantity,
+			&product.TaxCategory,
+			&product.WeightGrams,
+		)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, product)
+	}
+	return products, rows.Err()
+}
+
 func (r *productRepository) ListProducts(ctx context.Context) ([]*models.Product, error) {
 	query := `
-		SELECT product_id, name, description, price, quantity
+		SELECT product_id, name, description, price, quantity, tax_category, weight_grams
 		FROM products
 	`
 
@@ -78,6 +115,8 @@ func (r *productRepository) ListProducts(ctx context.Context) ([]*models.Product
 			&product.Description,
 			&product.Price,
 			&product.Quantity,
+			&product.TaxCategory,
+			&product.WeightGrams,
 		)
 		if err != nil {
 			return nil, err
@@ -87,24 +126,24 @@ func (r *productRepository) ListProducts(ctx context.Context) ([]*models.Product
 	return products, nil
 }
 
+// UpdateProduct replaces the product's fields, except that an empty tax
+// category and a negative weight keep the current ones. product.TaxCategory
+// and product.WeightGrams are set to the stored values.
 func (r *productRepository) UpdateProduct(ctx context.Context, product *models.Product) error {
 	query := `
 		UPDATE products
-		SET name = $1, description = $2, price = $3, quantity = $4
-		WHERE product_id = $5
+		SET name = $1, description = $2, price = $3, quantity = $4,
+			tax_category = COALESCE(NULLIF($5, ''), tax_category),
+			weight_grams = CASE WHEN $6 < 0 THEN weight_grams ELSE $6 END
+		WHERE product_id = $7
+		RETURNING tax_category, weight_grams
 	`
 
-	result, err := r.db.ExecContext(ctx, query, product.Name, product.Description, product.Price, product.Quantity, product.ProductID)
-	if err != nil {
-		return err
-	}
-
-	rowsAffected, _ := result.RowsAffected()
-	if rowsAffected == 0 {
+	err := r.db.QueryRowContext(ctx, query, product.Name, product.Description, product.Price, product.Quantity, product.TaxCategory, product.WeightGrams, product.ProductID).Scan(&product.TaxCategory, &product.WeightGrams)
+	if err == sql.ErrNoRows {
 		return errors.New("product not found")
 	}
-
-	return nil
+	return err
 }
 
 func (r *productRepository) DeleteProduct(ctx context.Context, productID int) error {
@@ -2,15 +2,26 @@ package auth
 
 import (
 	"context"
+	"strconv"
 
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type contextKey string
 
-const userIDKey contextKey = "userID"
+const (
+	UserIDKey    contextKey = "userID"
+	RoleKey      contextKey = "role"
+	SessionIDKey contextKey = "sessionID"
+	// ImpersonatorIDKey holds the admin's user ID on requests made with an
+	// impersonation token.
+	ImpersonatorIDKey contextKey = "impersonatorID"
+)
 
-func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
+func UnaryAuthInterceptor(secretKey string, repo repository.UserRepository) grpc.UnaryServerInterceptor {
 	return func(
 		ctx context.Context,
 		req interface{},
@@ -21,6 +32,14 @@ func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 			return handler(ctx, req)
 		}
 
+		if apiKey, ok := ExtractAPIKeyFromMetadata(ctx); ok {
+			newCtx, err := authenticateAPIKey(ctx, repo, apiKey, info.FullMethod)
+			if err != nil {
+				return nil, err
+			}
+			return handler(newCtx, req)
+		}
+
 		tokenString, err := ExtractTokenFromMetadata(ctx)
 		if err != nil {
 			return nil, err
@@ -31,7 +50,47 @@ func UnaryAuthInterceptor(secretKey string) grpc.UnaryServerInterceptor {
 			return nil, err
 		}
 
-		newCtx := context.WithValue(ctx, userIDKey, claims.Subject)
+		// Tokens carry the user's token version at issue time; a password
+		// change bumps the stored version and so revokes older tokens.
+		user, err := repo.GetUserByID(ctx, claims.UserID)
+		if err != nil {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+		}
+		if user.TokenVersion != claims.TokenVersion {
+			return nil, status.Errorf(codes.Unauthenticated, "token has been revoked")
+		}
+		if user.Disabled {
+			return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+		}
+
+		newCtx := context.WithValue(ctx, UserIDKey, claims.UserID)
+		newCtx = context.WithValue(newCtx, RoleKey, user.Role)
+
+		if claims.ImpersonatorID != 0 {
+			if isAccountSecurityMethod(info.FullMethod) {
+				return nil, status.Errorf(codes.PermissionDenied, "method cannot be called while impersonating a user")
+			}
+			newCtx = context.WithValue(newCtx, ImpersonatorIDKey, claims.ImpersonatorID)
+		}
+
+		// Tokens issued before sessions were introduced carry no session ID
+		// and remain valid until they expire.
+		if claims.ID != "" {
+			sessionID, err := strconv.Atoi(claims.ID)
+			if err != nil {
+				return nil, status.Errorf(codes.Unauthenticated, "invalid token")
+			}
+
+			err = repo.TouchSession(ctx, sessionID, claims.UserID)
+			if err != nil {
+				if err == repository.ErrSessionRevoked {
+					return nil, status.Errorf(codes.Unauthenticated, "session has been revoked")
+				}
+				return nil, status.Errorf(codes.Internal, "failed to check session: %v", err)
+			}
+			newCtx = context.WithValue(newCtx, SessionIDKey, sessionID)
+		}
+
 		return handler(newCtx, req)
 	}
 }
@@ -40,6 +99,15 @@ func isUnauthenticatedMethod(fullMethod string) bool {
 	unauthenticatedMethods := []string{
 		"/user.UserService/LoginUser",
 		"/user.UserService/RegisterUser",
+		"/user.UserService/RequestPasswordReset",
+		"/user.UserService/ConfirmPasswordReset",
+		"/user.UserService/VerifyEmail",
+		"/user.UserService/ResendVerification",
+		"/user.UserService/VerifyTOTP",
+		"/user.UserService/StartOIDCLogin",
+		"/user.UserService/CompleteOIDCLogin",
+		"/user.UserService/ResolveAPIKey",
+		"/user.UserService/CheckSession",
 		"/grpc.health.v1.Health/Check",
 	}
 
@@ -50,3 +118,27 @@ func isUnauthenticatedMethod(fullMethod string) bool {
 	}
 	return false
 }
+
+// isAccountSecurityMethod reports whether fullMethod changes how the account
+// is secured or exports its data. Impersonation tokens may not call these, so
+// support staff can see what the user sees without being able to take the
+// account over.
+func isAccountSecurityMethod(fullMethod string) bool {
+	accountSecurityMethods := []string{
+		"/user.UserService/UpdateUserProfile",
+		"/user.UserService/ChangePassword",
+		"/user.UserService/EnrollTOTP",
+		"/user.UserService/ConfirmTOTP",
+		"/user.UserService/DisableTOTP",
+		"/user.UserService/DeleteMyAccount",
+		"/user.UserService/RevokeSession",
+		"/user.UserService/ExportMyData",
+	}
+
+	for _, method := range accountSecurityMethods {
+		if fullMethod == method {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,193 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var apiKeyColumns = []string{"key_id", "user_id", "name", "prefix", "key_hash", "scopes", "created_at", "expires_at", "last_used_at", "revoked_at"}
+
+var userColumns = []string{"user_id", "username", "email", "password_hash", "email_verified", "token_version", "role", "totp_enabled", "disabled"}
+
+func TestAPIKeyScopes(t *testing.T) {
+	cases := []struct {
+		method string
+		code   codes.Code
+	}{
+		{"/user.UserService/GetUserProfile", codes.OK},
+		{"/user.UserService/ListAddresses", codes.PermissionDenied},
+		{"/user.UserService/ChangePassword", codes.PermissionDenied},
+	}
+
+	for _, tc := range cases {
+		db, mock, err := sqlmock.New()
+		if err != nil {
+			t.Fatalf("Failed to create sqlmock: %v", err)
+		}
+
+		mock.ExpectQuery("UPDATE api_keys SET last_used_at").
+			WithArgs(HashAPIKey("ek_test")).
+			WillReturnRows(sqlmock.NewRows(apiKeyColumns).
+				AddRow(3, 5, "Batch", "ek_test", HashAPIKey("ek_test"), "{profile:read}", time.Now(), nil, nil, nil))
+		if tc.code == codes.OK {
+			mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+				WithArgs(5).
+				WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "batch", "batch@example.com", "hash", true, 0, "customer", false, false))
+		}
+
+		interceptor := UnaryAuthInterceptor("secret", repository.NewUserRepository(db))
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(APIKeyHeader, "ek_test"))
+
+		var userID int
+		_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: tc.method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			userID, _ = ctx.Value(UserIDKey).(int)
+			return nil, nil
+		})
+
+		if status.Code(err) != tc.code {
+			t.Errorf("%s: expected %v, got %v", tc.method, tc.code, err)
+		}
+		if tc.code == codes.OK && userID != 5 {
+			t.Errorf("%s: expected user 5 in context, got %d", tc.method, userID)
+		}
+		if err := mock.ExpectationsWereMet(); err != nil {
+			t.Errorf("%s: unfulfilled expectations: %v", tc.method, err)
+		}
+		db.Close()
+	}
+}
+
+func TestRevokedSessionRejected(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &AuthClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		UserID: 5,
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at").
+		WithArgs(7, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id"}))
+
+	interceptor := UnaryAuthInterceptor("secret", repository.NewUserRepository(db))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUserProfile"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("Handler should not be called for a revoked session")
+		return nil, nil
+	})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestImpersonationTokenRestricted(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &AuthClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "7",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		UserID:         5,
+		ImpersonatorID: 1,
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	interceptor := UnaryAuthInterceptor("secret", repository.NewUserRepository(db))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	for _, method := range []string{"/user.UserService/ChangePassword", "/user.UserService/ExportMyData"} {
+		mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+			WithArgs(5).
+			WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+
+		_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: method}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			t.Fatal("Handler should not be called with an impersonation token")
+			return nil, nil
+		})
+		if status.Code(err) != codes.PermissionDenied {
+			t.Errorf("Expected PermissionDenied for %s, got %v", method, err)
+		}
+	}
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at").
+		WithArgs(7, 5).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(7))
+
+	var impersonatorID int
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUserProfile"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		impersonatorID, _ = ctx.Value(ImpersonatorIDKey).(int)
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("Expected GetUserProfile to be allowed, got %v", err)
+	}
+	if impersonatorID != 1 {
+		t.Errorf("Expected impersonator 1 in context, got %d", impersonatorID)
+	}
+}
+
+func TestDisabledUserRejected(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, &AuthClaims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+		UserID: 5,
+	}).SignedString([]byte("secret"))
+	if err != nil {
+		t.Fatalf("Failed to sign token: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 0, "customer", false, true))
+
+	interceptor := UnaryAuthInterceptor("secret", repository.NewUserRepository(db))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "Bearer "+token))
+
+	_, err = interceptor(ctx, nil, &grpc.UnaryServerInfo{FullMethod: "/user.UserService/GetUserProfile"}, func(ctx context.Context, req interface{}) (interface{}, error) {
+		t.Fatal("Handler should not be called for a disabled account")
+		return nil, nil
+	})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, got %v", err)
+	}
+}
@@ -11,7 +11,12 @@ import (
 
 type AuthClaims struct {
 	jwt.RegisteredClaims
-	UserID int
+	UserID        int
+	EmailVerified bool
+	TokenVersion  int
+	Role          string
+	// ImpersonatorID is set on tokens an admin obtained with ImpersonateUser.
+	ImpersonatorID int `json:",omitempty"`
 }
 
 func ExtractTokenFromMetadata(ctx context.Context) (string, error) {
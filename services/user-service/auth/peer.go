@@ -0,0 +1,157 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// ClientIP returns the address of the calling client. x-forwarded-for is only
+// believed when the gRPC peer is one of trustedProxies (the API gateway), and
+// then the right-most entry not added by a trusted proxy is the client;
+// entries to its left were supplied by the client and may be forged. An
+// empty string is returned when no valid address is known.
+func ClientIP(ctx context.Context, trustedProxies []*net.IPNet) string {
+	peerIP := peerAddress(ctx)
+	if peerIP == nil {
+		return ""
+	}
+	if !isTrustedProxy(peerIP, trustedProxies) {
+		return peerIP.String()
+	}
+	return forwardedClient(ctx, peerIP, trustedProxies).String()
+}
+
+// ForwardedClientIP is like ClientIP but only returns an address that a
+// trusted proxy forwarded for the client. It is empty when the peer is not a
+// trusted proxy, since that peer may itself be a proxy carrying many clients,
+// and when every known hop is a trusted proxy.
+func ForwardedClientIP(ctx context.Context, trustedProxies []*net.IPNet) string {
+	peerIP := peerAddress(ctx)
+	if peerIP == nil || !isTrustedProxy(peerIP, trustedProxies) {
+		return ""
+	}
+	client := forwardedClient(ctx, peerIP, trustedProxies)
+	if isTrustedProxy(client, trustedProxies) {
+		return ""
+	}
+	return client.String()
+}
+
+func peerAddress(ctx context.Context) net.IP {
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return nil
+	}
+
+	host, _, err := net.SplitHostPort(p.Addr.String())
+	if err != nil {
+		host = p.Addr.String()
+	}
+	return net.ParseIP(host)
+}
+
+// forwardedClient walks x-forwarded-for from the right, starting at the
+// trusted peerIP, and returns the first hop not added by a trusted proxy.
+func forwardedClient(ctx context.Context, peerIP net.IP, trustedProxies []*net.IPNet) net.IP {
+	md, _ := metadata.FromIncomingContext(ctx)
+	var hops []string
+	for _, forwarded := range md.Get("x-forwarded-for") {
+		hops = append(hops, strings.Split(forwarded, ",")...)
+	}
+
+	client := peerIP
+	for i := len(hops) - 1; i >= 0; i-- {
+		ip := net.ParseIP(strings.TrimSpace(hops[i]))
+		if ip == nil {
+			break
+		}
+		client = ip
+		if !isTrustedProxy(ip, trustedProxies) {
+			break
+		}
+	}
+	return client
+}
+
+// ParseTrustedProxies parses a comma-separated list of IP addresses and CIDR
+// ranges whose x-forwarded-for headers are believed.
+func ParseTrustedProxies(value string) ([]*net.IPNet, error) {
+	var proxies []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, network, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", entry, err)
+		}
+		proxies = append(proxies, network)
+	}
+	return proxies, nil
+}
+
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	for _, network := range trustedProxies {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// UserAgent returns the user agent the client identified itself with.
+func UserAgent(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("user-agent"); len(values) > 0 {
+			return values[0]
+		}
+	}
+	return ""
+}
+
+// DeviceName returns a human-readable name for the calling device. Clients
+// may name themselves with the x-device-name header; otherwise the platform
+// is guessed from the user agent.
+func DeviceName(ctx context.Context) string {
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get("x-device-name"); len(values) > 0 && values[0] != "" {
+			return values[0]
+		}
+	}
+
+	userAgent := strings.ToLower(UserAgent(ctx))
+	platforms := []struct{ match, name string }{
+		{"iphone", "iPhone"},
+		{"ipad", "iPad"},
+		{"android", "Android"},
+		{"windows", "Windows"},
+		{"mac os", "macOS"},
+		{"macintosh", "macOS"},
+		{"linux", "Linux"},
+		{"grpc-", "API client"},
+	}
+	for _, platform := range platforms {
+		if strings.Contains(userAgent, platform.match) {
+			return platform.name
+		}
+	}
+	return "Unknown device"
+}
@@ -0,0 +1,89 @@
+package auth
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+func TestClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8, 192.0.2.1")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		forwarded []string
+		want      string
+	}{
+		{"no header", "198.51.100.4", nil, "198.51.100.4"},
+		{"untrusted peer", "198.51.100.4", []string{"203.0.113.7"}, "198.51.100.4"},
+		{"gateway", "10.0.0.2", []string{"203.0.113.7"}, "203.0.113.7"},
+		{"spoofed entries", "10.0.0.2", []string{"1.2.3.4, 203.0.113.7"}, "203.0.113.7"},
+		{"chained proxies", "10.0.0.2", []string{"203.0.113.7, 192.0.2.1", "10.0.0.9"}, "203.0.113.7"},
+		{"invalid entry", "10.0.0.2", []string{"203.0.113.7, unknown"}, "10.0.0.2"},
+		{"normalised", "10.0.0.2", []string{" 2001:DB8::1 "}, "2001:db8::1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 40000}})
+			md := metadata.MD{}
+			for _, value := range tt.forwarded {
+				md.Append("x-forwarded-for", value)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			if got := ClientIP(ctx, proxies); got != tt.want {
+				t.Errorf("ClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwardedClientIP(t *testing.T) {
+	proxies, err := ParseTrustedProxies("10.0.0.0/8")
+	if err != nil {
+		t.Fatalf("ParseTrustedProxies failed: %v", err)
+	}
+
+	tests := []struct {
+		name      string
+		peer      string
+		proxies   []*net.IPNet
+		forwarded []string
+		want      string
+	}{
+		{"gateway", "10.0.0.2", proxies, []string{"203.0.113.7"}, "203.0.113.7"},
+		{"no header", "10.0.0.2", proxies, nil, ""},
+		{"only proxies", "10.0.0.2", proxies, []string{"10.0.0.9"}, ""},
+		{"untrusted peer", "198.51.100.4", proxies, []string{"203.0.113.7"}, ""},
+		{"no trusted proxies", "10.0.0.2", nil, []string{"203.0.113.7"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP(tt.peer), Port: 40000}})
+			md := metadata.MD{}
+			for _, value := range tt.forwarded {
+				md.Append("x-forwarded-for", value)
+			}
+			ctx = metadata.NewIncomingContext(ctx, md)
+
+			if got := ForwardedClientIP(ctx, tt.proxies); got != tt.want {
+				t.Errorf("ForwardedClientIP() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTrustedProxiesInvalid(t *testing.T) {
+	if _, err := ParseTrustedProxies("gateway"); err == nil {
+		t.Error("Expected an error for an invalid proxy address")
+	}
+}
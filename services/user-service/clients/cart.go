@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"context"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/cartpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/handlers"
+)
+
+type cartDataService struct {
+	conn *serviceConn
+}
+
+// NewCartDataService returns the Cart Service as a source of user data for
+// data-subject requests.
+func NewCartDataService(consulClient *consulapi.Client) handlers.UserDataService {
+	return &cartDataService{conn: newServiceConn(consulClient, "cart-service")}
+}
+
+func (s *cartDataService) Name() string {
+	return "cart"
+}
+
+func (s *cartDataService) ExportUserData(ctx context.Context) ([]byte, error) {
+	conn, err := s.conn.get()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := cartpb.NewCartServiceClient(conn).ExportUserData(ctx, &cartpb.ExportUserDataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (s *cartDataService) EraseUserData(ctx context.Context) error {
+	conn, err := s.conn.get()
+	if err != nil {
+		return err
+	}
+
+	_, err = cartpb.NewCartServiceClient(conn).EraseUserData(ctx, &cartpb.EraseUserDataRequest{})
+	return err
+}
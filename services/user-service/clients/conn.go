@@ -0,0 +1,50 @@
+package clients
+
+import (
+	"sync"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+)
+
+// serviceConn connects to a service registered with Consul the first time it
+// is used. The cart and order services depend on User Service, so they are
+// not yet registered when User Service starts.
+type serviceConn struct {
+	consulClient *consulapi.Client
+	serviceName  string
+
+	mu   sync.Mutex
+	conn *grpc.ClientConn
+}
+
+func newServiceConn(consulClient *consulapi.Client, serviceName string) *serviceConn {
+	return &serviceConn{
+		consulClient: consulClient,
+		serviceName:  serviceName,
+	}
+}
+
+func (c *serviceConn) get() (*grpc.ClientConn, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.conn != nil {
+		return c.conn, nil
+	}
+
+	address, err := utils.GetServiceAddress(c.consulClient, c.serviceName)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := grpc.NewClient(address, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return nil, err
+	}
+
+	c.conn = conn
+	return conn, nil
+}
@@ -0,0 +1,46 @@
+package clients
+
+import (
+	"context"
+
+	consulapi "github.com/hashicorp/consul/api"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/handlers"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/orderpb"
+)
+
+type orderDataService struct {
+	conn *serviceConn
+}
+
+// NewOrderDataService returns the Order Service as a source of user data for
+// data-subject requests.
+func NewOrderDataService(consulClient *consulapi.Client) handlers.UserDataService {
+	return &orderDataService{conn: newServiceConn(consulClient, "order-service")}
+}
+
+func (s *orderDataService) Name() string {
+	return "orders"
+}
+
+func (s *orderDataService) ExportUserData(ctx context.Context) ([]byte, error) {
+	conn, err := s.conn.get()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := orderpb.NewOrderServiceClient(conn).ExportUserData(ctx, &orderpb.ExportUserDataRequest{})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Data, nil
+}
+
+func (s *orderDataService) EraseUserData(ctx context.Context) error {
+	conn, err := s.conn.get()
+	if err != nil {
+		return err
+	}
+
+	_, err = orderpb.NewOrderServiceClient(conn).EraseUserData(ctx, &orderpb.EraseUserDataRequest{})
+	return err
+}
@@ -0,0 +1,32 @@
+// Command mock-oidc runs a local OpenID Connect provider for trying out OIDC
+// login without a real identity provider. Open the authorization URL returned
+// by StartOIDCLogin with a login_hint=<email> parameter appended to sign in as
+// that email address.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc/oidctest"
+)
+
+func main() {
+	addr := flag.String("addr", ":9000", "address to listen on")
+	issuer := flag.String("issuer", "http://localhost:9000", "issuer URL; set OIDC_ISSUER_URL to the same value")
+	clientID := flag.String("client-id", "user-service", "client ID")
+	clientSecret := flag.String("client-secret", "mock-secret", "client secret")
+	flag.Parse()
+
+	provider, err := oidctest.New(*clientID, *clientSecret)
+	if err != nil {
+		log.Fatalf("Failed to create provider: %v", err)
+	}
+	provider.Issuer = *issuer
+
+	log.Printf("Mock OIDC provider %s is running on %s", *issuer, *addr)
+	if err := http.ListenAndServe(*addr, provider); err != nil {
+		log.Fatalf("Failed to serve: %v", err)
+	}
+}
@@ -3,13 +3,18 @@ package main
 import (
 	"log"
 	"net"
+	"os"
 	"strconv"
 	"time"
 
+	consulapi "github.com/hashicorp/consul/api"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/clients"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/config"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/db"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/handlers"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/notifier"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/password"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
@@ -22,6 +27,12 @@ import (
 func main() {
 	cfg := config.LoadConfig()
 
+	// TOTP secrets are stored encrypted with this key; a well-known default
+	// would leave them readable to anyone with the database.
+	if cfg.TOTPEncryptionKey == "" {
+		log.Fatalf("TOTP_ENCRYPTION_KEY must be set")
+	}
+
 	// database connection
 	database, err := db.Connect(cfg.DBSource)
 	if err != nil {
@@ -31,9 +42,9 @@ func main() {
 
 	userRepo := repository.NewUserRepository(database)
 
-	tokenDuration, err := time.ParseDuration(cfg.TokenDuration)
+	userNotifier, err := notifier.New(cfg.Notifier, cfg.NotifierFilePath)
 	if err != nil {
-		log.Fatalf("Invalid token duration: %v", err)
+		log.Fatalf("Failed to create notifier: %v", err)
 	}
 
 	lis, err := net.Listen("tcp", ":"+cfg.Port)
@@ -41,9 +52,53 @@ func main() {
 		log.Fatalf("Failed to listen on port %s: %v", cfg.Port, err)
 	}
 
-	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey)))
+	// Initialize Consul client
+	consulConfig := consulapi.DefaultConfig()
+	consulConfig.Address = cfg.ConsulAddress
+	consulClient, err := consulapi.NewClient(consulConfig)
+	if err != nil {
+		log.Fatalf("Failed to create Consul client: %v", err)
+	}
+
+	// services holding personal data, for data export and account deletion
+	dataServices := []handlers.UserDataService{
+		clients.NewCartDataService(consulClient),
+		clients.NewOrderDataService(consulClient),
+	}
+
+	grpcServer := grpc.NewServer(grpc.UnaryInterceptor(auth.UnaryAuthInterceptor(cfg.JWTSecretKey, userRepo)))
+
+	userpb.RegisterUserServiceServer(grpcServer, handlers.NewUserServiceServer(userRepo, userNotifier, dataServices, handlers.Config{
+		JWTSecretKey:                 cfg.JWTSecretKey,
+		TokenDuration:                parseDuration("TOKEN_DURATION", cfg.TokenDuration),
+		PasswordResetTokenDuration:   parseDuration("PASSWORD_RESET_TOKEN_DURATION", cfg.PasswordResetTokenDuration),
+		VerificationTokenDuration:    parseDuration("VERIFICATION_TOKEN_DURATION", cfg.VerificationTokenDuration),
+		RequireVerifiedEmailForLogin: parseBool("REQUIRE_VERIFIED_EMAIL_FOR_LOGIN", cfg.RequireVerifiedEmailForLogin),
+		TrustedProxies:               parseTrustedProxies(cfg.TrustedProxies),
+		MaxLoginAttempts:             parseInt("MAX_LOGIN_ATTEMPTS", cfg.MaxLoginAttempts),
+		MaxLoginAttemptsPerIP:        parseInt("MAX_LOGIN_ATTEMPTS_PER_IP", cfg.MaxLoginAttemptsPerIP),
+		LoginFailureWindow:           parseDuration("LOGIN_FAILURE_WINDOW", cfg.LoginFailureWindow),
+		LoginLockoutDuration:         parseDuration("LOGIN_LOCKOUT_DURATION", cfg.LoginLockoutDuration),
+		MaxLoginLockoutDuration:      parseDuration("MAX_LOGIN_LOCKOUT_DURATION", cfg.MaxLoginLockoutDuration),
+		LoginLockoutResetAfter:       parseDuration("LOGIN_LOCKOUT_RESET_AFTER", cfg.LoginLockoutResetAfter),
+		TOTPEncryptionKey:            utils.DeriveEncryptionKey(cfg.TOTPEncryptionKey),
+		TOTPIssuer:                   cfg.TOTPIssuer,
+		TOTPChallengeDuration:        parseDuration("TOTP_CHALLENGE_DURATION", cfg.TOTPChallengeDuration),
+		OIDCIssuerURL:                cfg.OIDCIssuerURL,
+		OIDCClientID:                 cfg.OIDCClientID,
+		OIDCClientSecret:             cfg.OIDCClientSecret,
+		OIDCRedirectURL:              cfg.OIDCRedirectURL,
+		OIDCStateDuration:            parseDuration("OIDC_STATE_DURATION", cfg.OIDCStateDuration),
+
+		ImpersonationTokenDuration: parseDuration("IMPERSONATION_TOKEN_DURATION", cfg.ImpersonationTokenDuration),
 
-	userpb.RegisterUserServiceServer(grpcServer, handlers.NewUserServiceServer(userRepo, cfg.JWTSecretKey, tokenDuration))
+		PasswordPolicy: password.Policy{
+			MinLength:           parseInt("PASSWORD_MIN_LENGTH", cfg.PasswordMinLength),
+			MaxLength:           parseInt("PASSWORD_MAX_LENGTH", cfg.PasswordMaxLength),
+			MinCharacterClasses: parseInt("PASSWORD_MIN_CHARACTER_CLASSES", cfg.PasswordMinCharacterClasses),
+			Breached:            loadBreachedList(cfg),
+		},
+	}))
 
 	// health check service
 	healthServer := health.NewServer()
@@ -73,3 +128,58 @@ func main() {
 	// Block main goroutine
 	select {}
 }
+
+// loadBreachedList returns the breached password list to check new passwords
+// against, or nil when the check is disabled.
+func loadBreachedList(cfg *config.Config) *password.BreachedList {
+	if !parseBool("PASSWORD_CHECK_BREACHED", cfg.PasswordCheckBreached) {
+		return nil
+	}
+	if cfg.BreachedPasswordsFile == "" {
+		return password.DefaultBreachedList()
+	}
+
+	f, err := os.Open(cfg.BreachedPasswordsFile)
+	if err != nil {
+		log.Fatalf("Failed to open breached password list: %v", err)
+	}
+	defer f.Close()
+
+	list, err := password.LoadBreachedList(f)
+	if err != nil {
+		log.Fatalf("Failed to load breached password list: %v", err)
+	}
+	return list
+}
+
+func parseTrustedProxies(value string) []*net.IPNet {
+	proxies, err := auth.ParseTrustedProxies(value)
+	if err != nil {
+		log.Fatalf("Invalid TRUSTED_PROXIES: %v", err)
+	}
+	return proxies
+}
+
+func parseDuration(name, value string) time.Duration {
+	d, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return d
+}
+
+func parseBool(name, value string) bool {
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return b
+}
+
+func parseInt(name, value string) int {
+	i, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Invalid %s: %v", name, err)
+	}
+	return i
+}
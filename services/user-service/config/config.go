@@ -15,6 +15,42 @@ type Config struct {
 	ConsulAddress string
 	ServiceName   string
 	ServiceHost   string
+
+	// TrustedProxies lists the addresses of the API gateway, the only peers
+	// whose x-forwarded-for header is believed.
+	TrustedProxies string
+
+	Notifier                   string
+	NotifierFilePath           string
+	PasswordResetTokenDuration string
+	VerificationTokenDuration  string
+
+	RequireVerifiedEmailForLogin string
+
+	MaxLoginAttempts        string
+	MaxLoginAttemptsPerIP   string
+	LoginFailureWindow      string
+	LoginLockoutDuration    string
+	MaxLoginLockoutDuration string
+	LoginLockoutResetAfter  string
+
+	TOTPEncryptionKey     string
+	TOTPIssuer            string
+	TOTPChallengeDuration string
+
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string
+	OIDCStateDuration string
+
+	ImpersonationTokenDuration string
+
+	PasswordMinLength           string
+	PasswordMaxLength           string
+	PasswordMinCharacterClasses string
+	PasswordCheckBreached       string
+	BreachedPasswordsFile       string
 }
 
 func LoadConfig() *Config {
@@ -30,6 +66,40 @@ func LoadConfig() *Config {
 		ConsulAddress: getEnv("CONSUL_ADDRESS", "consul:8500"),
 		ServiceName:   getEnv("SERVICE_NAME", "user-service"),
 		ServiceHost:   getEnv("SERVICE_HOST", "user-service"),
+
+		TrustedProxies: getEnv("TRUSTED_PROXIES", ""),
+
+		Notifier:                   getEnv("NOTIFIER", "log"),
+		NotifierFilePath:           getEnv("NOTIFIER_FILE_PATH", "notifications.log"),
+		PasswordResetTokenDuration: getEnv("PASSWORD_RESET_TOKEN_DURATION", "1h"),
+		VerificationTokenDuration:  getEnv("VERIFICATION_TOKEN_DURATION", "24h"),
+
+		RequireVerifiedEmailForLogin: getEnv("REQUIRE_VERIFIED_EMAIL_FOR_LOGIN", "false"),
+
+		MaxLoginAttempts:        getEnv("MAX_LOGIN_ATTEMPTS", "5"),
+		MaxLoginAttemptsPerIP:   getEnv("MAX_LOGIN_ATTEMPTS_PER_IP", "20"),
+		LoginFailureWindow:      getEnv("LOGIN_FAILURE_WINDOW", "15m"),
+		LoginLockoutDuration:    getEnv("LOGIN_LOCKOUT_DURATION", "1m"),
+		MaxLoginLockoutDuration: getEnv("MAX_LOGIN_LOCKOUT_DURATION", "1h"),
+		LoginLockoutResetAfter:  getEnv("LOGIN_LOCKOUT_RESET_AFTER", "24h"),
+
+		TOTPEncryptionKey:     getEnv("TOTP_ENCRYPTION_KEY", ""),
+		TOTPIssuer:            getEnv("TOTP_ISSUER", "EcomMicroservices"),
+		TOTPChallengeDuration: getEnv("TOTP_CHALLENGE_DURATION", "5m"),
+
+		OIDCIssuerURL:     getEnv("OIDC_ISSUER_URL", ""),
+		OIDCClientID:      getEnv("OIDC_CLIENT_ID", ""),
+		OIDCClientSecret:  getEnv("OIDC_CLIENT_SECRET", ""),
+		OIDCRedirectURL:   getEnv("OIDC_REDIRECT_URL", ""),
+		OIDCStateDuration: getEnv("OIDC_STATE_DURATION", "10m"),
+
+		ImpersonationTokenDuration: getEnv("IMPERSONATION_TOKEN_DURATION", "15m"),
+
+		PasswordMinLength:           getEnv("PASSWORD_MIN_LENGTH", "8"),
+		PasswordMaxLength:           getEnv("PASSWORD_MAX_LENGTH", "72"),
+		PasswordMinCharacterClasses: getEnv("PASSWORD_MIN_CHARACTER_CLASSES", "2"),
+		PasswordCheckBreached:       getEnv("PASSWORD_CHECK_BREACHED", "true"),
+		BreachedPasswordsFile:       getEnv("BREACHED_PASSWORDS_FILE", ""),
 	}
 
 	return config
@@ -0,0 +1,3 @@
+-- token_version is embedded in issued JWTs; bumping it revokes every token
+-- issued before the change.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS token_version INT NOT NULL DEFAULT 0;
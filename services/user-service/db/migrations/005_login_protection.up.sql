@@ -0,0 +1,11 @@
+-- Staff accounts are promoted manually, e.g.
+-- UPDATE users SET role = 'admin' WHERE email = '...';
+ALTER TABLE users ADD COLUMN IF NOT EXISTS role VARCHAR(20) NOT NULL DEFAULT 'customer';
+
+-- failure_key is either "email:<address>" or "ip:<address>".
+CREATE TABLE IF NOT EXISTS login_failures (
+  failure_key VARCHAR(150) PRIMARY KEY,
+  failed_attempts INT NOT NULL,
+  last_failed_at TIMESTAMP NOT NULL,
+  locked_until TIMESTAMP
+);
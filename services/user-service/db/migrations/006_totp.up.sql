@@ -0,0 +1,31 @@
+ALTER TABLE users ADD COLUMN IF NOT EXISTS totp_enabled BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- encrypted_secret is AES-GCM encrypted with TOTP_ENCRYPTION_KEY. The secret
+-- is pending until confirmed_at is set by ConfirmTOTP.
+CREATE TABLE IF NOT EXISTS totp_credentials (
+  user_id INT PRIMARY KEY,
+  encrypted_secret TEXT NOT NULL,
+  confirmed_at TIMESTAMP,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS totp_recovery_codes (
+  code_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+-- A login challenge is issued by LoginUser once the password has been
+-- checked, and redeemed by VerifyTOTP for an access token.
+CREATE TABLE IF NOT EXISTS login_challenges (
+  challenge_hash VARCHAR(64) PRIMARY KEY,
+  user_id INT NOT NULL,
+  failed_attempts INT NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  used_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS addresses (
+  address_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  full_name VARCHAR(100) NOT NULL,
+  line1 VARCHAR(200) NOT NULL,
+  line2 VARCHAR(200) NOT NULL DEFAULT '',
+  city VARCHAR(100) NOT NULL,
+  region VARCHAR(100) NOT NULL DEFAULT '',
+  postcode VARCHAR(20) NOT NULL DEFAULT '',
+  country CHAR(2) NOT NULL,
+  phone VARCHAR(30) NOT NULL DEFAULT '',
+  is_default_shipping BOOLEAN NOT NULL DEFAULT FALSE,
+  is_default_billing BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS addresses_user_id_idx ON addresses (user_id);
+
+-- At most one default shipping and one default billing address per user.
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_default_shipping_idx ON addresses (user_id) WHERE is_default_shipping;
+CREATE UNIQUE INDEX IF NOT EXISTS addresses_default_billing_idx ON addresses (user_id) WHERE is_default_billing;
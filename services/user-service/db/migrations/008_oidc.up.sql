@@ -0,0 +1,23 @@
+-- An external identity is identified by its provider's issuer and the
+-- subject the provider assigned to the user.
+CREATE TABLE IF NOT EXISTS user_identities (
+  identity_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  issuer VARCHAR(255) NOT NULL,
+  subject VARCHAR(255) NOT NULL,
+  email VARCHAR(100) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  UNIQUE (issuer, subject),
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user_id ON user_identities (user_id);
+
+-- Pending OIDC logins, created by StartOIDCLogin and consumed by
+-- CompleteOIDCLogin.
+CREATE TABLE IF NOT EXISTS oidc_login_states (
+  state_hash VARCHAR(64) PRIMARY KEY,
+  nonce VARCHAR(64) NOT NULL,
+  code_verifier VARCHAR(128) NOT NULL,
+  expires_at TIMESTAMP NOT NULL
+);
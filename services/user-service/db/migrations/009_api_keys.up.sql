@@ -0,0 +1,18 @@
+-- API keys authenticate machine clients as the user they were issued for,
+-- limited to the listed scopes. Only a SHA-256 hash of the key is stored;
+-- prefix is kept so keys can be told apart in listings.
+CREATE TABLE IF NOT EXISTS api_keys (
+  key_id SERIAL PRIMARY KEY,
+  user_id INT NOT NULL,
+  name VARCHAR(100) NOT NULL,
+  prefix VARCHAR(16) NOT NULL,
+  key_hash VARCHAR(64) NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  expires_at TIMESTAMP,
+  last_used_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  FOREIGN KEY (user_id) REFERENCES users(user_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys (user_id);
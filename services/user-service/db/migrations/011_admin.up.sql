@@ -0,0 +1,18 @@
+-- Disabled accounts cannot log in and their tokens and API keys stop working.
+ALTER TABLE users ADD COLUMN IF NOT EXISTS disabled_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_users_email_prefix ON users (LOWER(email) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_users_username_prefix ON users (LOWER(username) text_pattern_ops);
+
+-- Every action an admin takes on another account is recorded here. The user
+-- IDs are not foreign keys so the record outlives deleted accounts.
+CREATE TABLE IF NOT EXISTS admin_actions (
+  action_id SERIAL PRIMARY KEY,
+  admin_user_id INT NOT NULL,
+  action VARCHAR(50) NOT NULL,
+  target_user_id INT NOT NULL,
+  reason TEXT NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_admin_actions_target_user_id ON admin_actions (target_user_id);
@@ -0,0 +1,28 @@
+-- Security-relevant authentication events. user_id is empty when the
+-- account could not be identified, and is not a foreign key so the history
+-- outlives deleted accounts.
+CREATE TABLE IF NOT EXISTS auth_events (
+  event_id BIGSERIAL PRIMARY KEY,
+  event_type VARCHAR(50) NOT NULL,
+  user_id INT,
+  email VARCHAR(255) NOT NULL DEFAULT '',
+  ip_address VARCHAR(45) NOT NULL DEFAULT '',
+  user_agent VARCHAR(255) NOT NULL DEFAULT '',
+  outcome VARCHAR(20) NOT NULL,
+  reason VARCHAR(255) NOT NULL DEFAULT '',
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_auth_events_user_id_created_at ON auth_events (user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_auth_events_created_at ON auth_events (created_at);
+
+-- The log is append-only: rows can be inserted but never changed or removed.
+CREATE OR REPLACE FUNCTION reject_auth_event_changes() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE OR REPLACE TRIGGER auth_events_append_only
+  BEFORE UPDATE OR DELETE ON auth_events
+  FOR EACH ROW EXECUTE FUNCTION reject_auth_event_changes();
@@ -0,0 +1,4 @@
+-- Number of lockouts in the current run of failures. Unlike failed_attempts
+-- it survives the failure window, so lockouts keep escalating until a
+-- successful login or a long quiet period.
+ALTER TABLE login_failures ADD COLUMN IF NOT EXISTS lockout_count INT NOT NULL DEFAULT 0;
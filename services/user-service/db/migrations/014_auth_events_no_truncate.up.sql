@@ -0,0 +1,5 @@
+-- Row-level triggers do not fire on TRUNCATE, so reject it separately to
+-- keep the auth log append-only.
+CREATE OR REPLACE TRIGGER auth_events_no_truncate
+  BEFORE TRUNCATE ON auth_events
+  FOR EACH STATEMENT EXECUTE FUNCTION reject_auth_event_changes();
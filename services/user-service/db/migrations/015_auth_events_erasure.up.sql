@@ -0,0 +1,33 @@
+-- The auth log stays append-only, with one exception: the personal data of
+-- a deleted account may be blanked by erase_user_auth_events, which flags
+-- the transaction so the trigger lets exactly that update through.
+CREATE OR REPLACE FUNCTION reject_auth_event_changes() RETURNS trigger AS $$
+BEGIN
+  IF TG_OP = 'UPDATE'
+    AND current_setting('auth_events.erasing', true) = 'on'
+    AND NEW.email = '' AND NEW.ip_address = '' AND NEW.user_agent = ''
+    AND (NEW.event_id, NEW.event_type, NEW.user_id, NEW.outcome, NEW.reason, NEW.created_at)
+      IS NOT DISTINCT FROM (OLD.event_id, OLD.event_type, OLD.user_id, OLD.outcome, OLD.reason, OLD.created_at)
+  THEN
+    RETURN NEW;
+  END IF;
+  RAISE EXCEPTION 'auth_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+-- Blanks the email, address and user agent of every event recorded for the
+-- user, including failed attempts against their email before they could be
+-- tied to the account. It must run before the user row is deleted.
+CREATE OR REPLACE FUNCTION erase_user_auth_events(target_user_id INT) RETURNS void AS $$
+DECLARE
+  target_email VARCHAR(255);
+BEGIN
+  SELECT email INTO target_email FROM users WHERE user_id = target_user_id;
+
+  PERFORM set_config('auth_events.erasing', 'on', true);
+  UPDATE auth_events SET email = '', ip_address = '', user_agent = ''
+  WHERE (user_id = target_user_id OR LOWER(email) = LOWER(target_email))
+    AND (email <> '' OR ip_address <> '' OR user_agent <> '');
+  PERFORM set_config('auth_events.erasing', 'off', true);
+END;
+$$ LANGUAGE plpgsql SECURITY DEFINER SET search_path = public;
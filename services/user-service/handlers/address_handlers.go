@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *UserServiceServer) CreateAddress(ctx context.Context, req *userpb.CreateAddressRequest) (*userpb.CreateAddressResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Address == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "address is required")
+	}
+
+	address := mapProtoToAddress(req.Address)
+	address.UserID = userID
+	address.AddressID = 0
+
+	normalizeAddress(address)
+	if violations := validateAddress(address); len(violations) > 0 {
+		return nil, fieldViolationsError("invalid address", violations)
+	}
+
+	err := s.repo.CreateAddress(ctx, address)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create address: %v", err)
+	}
+
+	return &userpb.CreateAddressResponse{
+		Address: mapAddressToProto(address),
+	}, nil
+}
+
+func (s *UserServiceServer) GetAddress(ctx context.Context, req *userpb.GetAddressRequest) (*userpb.GetAddressResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	addressID, err := strconv.Atoi(req.AddressId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address ID")
+	}
+
+	address, err := s.repo.GetAddress(ctx, userID, addressID)
+	if err != nil {
+		if err == repository.ErrAddressNotFound {
+			return nil, status.Errorf(codes.NotFound, "address not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to get address: %v", err)
+	}
+
+	return &userpb.GetAddressResponse{
+		Address: mapAddressToProto(address),
+	}, nil
+}
+
+func (s *UserServiceServer) ListAddresses(ctx context.Context, req *userpb.ListAddressesRequest) (*userpb.ListAddressesResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	addresses, err := s.repo.ListAddresses(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list addresses: %v", err)
+	}
+
+	var addressProtos []*userpb.Address
+	for _, address := range addresses {
+		addressProtos = append(addressProtos, mapAddressToProto(address))
+	}
+
+	return &userpb.ListAddressesResponse{
+		Addresses: addressProtos,
+	}, nil
+}
+
+func (s *UserServiceServer) UpdateAddress(ctx context.Context, req *userpb.UpdateAddressRequest) (*userpb.UpdateAddressResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Address == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "address is required")
+	}
+
+	address := mapProtoToAddress(req.Address)
+	if address.AddressID == 0 {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address ID")
+	}
+	address.UserID = userID
+
+	normalizeAddress(address)
+	if violations := validateAddress(address); len(violations) > 0 {
+		return nil, fieldViolationsError("invalid address", violations)
+	}
+
+	err := s.repo.UpdateAddress(ctx, address)
+	if err != nil {
+		if err == repository.ErrAddressNotFound {
+			return nil, status.Errorf(codes.NotFound, "address not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update address: %v", err)
+	}
+
+	return &userpb.UpdateAddressResponse{
+		Address: mapAddressToProto(address),
+	}, nil
+}
+
+func (s *UserServiceServer) DeleteAddress(ctx context.Context, req *userpb.DeleteAddressRequest) (*userpb.DeleteAddressResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	addressID, err := strconv.Atoi(req.AddressId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid address ID")
+	}
+
+	err = s.repo.DeleteAddress(ctx, userID, addressID)
+	if err != nil {
+		if err == repository.ErrAddressNotFound {
+			return nil, status.Errorf(codes.NotFound, "address not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to delete address: %v", err)
+	}
+
+	return &userpb.DeleteAddressResponse{
+		Message: "Address deleted successfully",
+	}, nil
+}
+
+// fieldViolationsError returns an InvalidArgument status carrying violations
+// as BadRequest details, so clients can point at the offending fields.
+func fieldViolationsError(message string, violations []*errdetails.BadRequest_FieldViolation) error {
+	st, err := status.New(codes.InvalidArgument, message).WithDetails(&errdetails.BadRequest{
+		FieldViolations: violations,
+	})
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "%s: %s", message, violations[0].Description)
+	}
+	return st.Err()
+}
+
+func mapProtoToAddress(address *userpb.Address) *models.Address {
+	addressID, _ := strconv.Atoi(address.AddressId)
+	return &models.Address{
+		AddressID:         addressID,
+		FullName:          address.FullName,
+		Line1:             address.Line1,
+		Line2:             address.Line2,
+		City:              address.City,
+		Region:            address.Region,
+		Postcode:          address.Postcode,
+		Country:           address.Country,
+		Phone:             address.Phone,
+		IsDefaultShipping: address.IsDefaultShipping,
+		IsDefaultBilling:  address.IsDefaultBilling,
+	}
+}
+
+func mapAddressToProto(address *models.Address) *userpb.Address {
+	return &userpb.Address{
+		AddressId:         strconv.Itoa(address.AddressID),
+		FullName:          address.FullName,
+		Line1:             address.Line1,
+		Line2:             address.Line2,
+		City:              address.City,
+		Region:            address.Region,
+		Postcode:          address.Postcode,
+		Country:           address.Country,
+		Phone:             address.Phone,
+		IsDefaultShipping: address.IsDefaultShipping,
+		IsDefaultBilling:  address.IsDefaultBilling,
+	}
+}
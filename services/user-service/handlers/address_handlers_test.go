@@ -0,0 +1,101 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateAddress(t *testing.T) {
+	cases := []struct {
+		name     string
+		address  models.Address
+		violated []string
+	}{
+		{"valid US", models.Address{FullName: "A", Line1: "1 Main St", City: "Springfield", Country: "US", Postcode: "12345-6789"}, nil},
+		{"valid GB", models.Address{FullName: "A", Line1: "10 Downing St", City: "London", Country: "GB", Postcode: "SW1A 2AA"}, nil},
+		{"optional postcode", models.Address{FullName: "A", Line1: "1 Road", City: "Dubai", Country: "AE"}, nil},
+		{"bad US postcode", models.Address{FullName: "A", Line1: "1 Main St", City: "Springfield", Country: "US", Postcode: "1234"}, []string{"address.postcode"}},
+		{"unknown country", models.Address{FullName: "A", Line1: "1 Main St", City: "Springfield", Country: "XX"}, []string{"address.country"}},
+		{"missing fields", models.Address{Country: "DE", Postcode: "10115"}, []string{"address.full_name", "address.line1", "address.city"}},
+	}
+
+	for _, tc := range cases {
+		violations := validateAddress(&tc.address)
+		if len(violations) != len(tc.violated) {
+			t.Errorf("%s: expected violations %v, got %v", tc.name, tc.violated, violations)
+			continue
+		}
+		for i, field := range tc.violated {
+			if violations[i].Field != field {
+				t.Errorf("%s: expected violation on %s, got %s", tc.name, field, violations[i].Field)
+			}
+		}
+	}
+}
+
+func TestCreateAddress(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE addresses SET is_default_shipping = FALSE").
+		WithArgs(1, 0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO addresses").
+		WithArgs(1, "Test User", "1 Main St", "", "Springfield", "IL", "62701", "US", "", true, false).
+		WillReturnRows(sqlmock.NewRows([]string{"address_id"}).AddRow(7))
+	mock.ExpectCommit()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	resp, err := handler.CreateAddress(ctx, &userpb.CreateAddressRequest{Address: &userpb.Address{
+		FullName:          "Test User",
+		Line1:             "1 Main St",
+		City:              "Springfield",
+		Region:            "IL",
+		Postcode:          " 62701 ",
+		Country:           "us",
+		IsDefaultShipping: true,
+	}})
+	if err != nil {
+		t.Fatalf("CreateAddress failed: %v", err)
+	}
+	if resp.Address.AddressId != "7" || resp.Address.Country != "US" {
+		t.Errorf("Unexpected address: %+v", resp.Address)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+
+	_, err = handler.CreateAddress(ctx, &userpb.CreateAddressRequest{Address: &userpb.Address{
+		FullName: "Test User",
+		Line1:    "1 Main St",
+		City:     "Springfield",
+		Country:  "US",
+		Postcode: "ABC",
+	}})
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	if len(st.Details()) != 1 {
+		t.Fatalf("Expected field violation details, got %v", st.Details())
+	}
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	if badRequest.FieldViolations[0].Field != "address.postcode" {
+		t.Errorf("Expected postcode violation, got %v", badRequest.FieldViolations)
+	}
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// ISO 3166-1 alpha-2 country codes.
+var countryCodes = makeSet(strings.Fields(`
+	AD AE AF AG AI AL AM AO AQ AR AS AT AU AW AX AZ BA BB BD BE BF BG BH BI BJ BL
+	BM BN BO BQ BR BS BT BV BW BY BZ CA CC CD CF CG CH CI CK CL CM CN CO CR CU CV
+	CW CX CY CZ DE DJ DK DM DO DZ EC EE EG EH ER ES ET FI FJ FK FM FO FR GA GB GD
+	GE GF GG GH GI GL GM GN GP GQ GR GS GT GU GW GY HK HM HN HR HT HU ID IE IL IM
+	IN IO IQ IR IS IT JE JM JO JP KE KG KH KI KM KN KP KR KW KY KZ LA LB LC LI LK
+	LR LS LT LU LV LY MA MC MD ME MF MG MH MK ML MM MN MO MP MQ MR MS MT MU MV MW
+	MX MY MZ NA NC NE NF NG NI NL NO NP NR NU NZ OM PA PE PF PG PH PK PL PM PN PR
+	PS PT PW PY QA RE RO RS RU RW SA SB SC SD SE SG SH SI SJ SK SL SM SN SO SR SS
+	ST SV SX SY SZ TC TD TF TG TH TJ TK TL TM TN TO TR TT TV TW TZ UA UG UM US UY
+	UZ VA VC VE VG VI VN VU WF WS YE YT ZA ZM ZW
+`))
+
+// postcodePatterns holds the postcode formats of countries we validate
+// strictly. Addresses in these countries must have a postcode.
+var postcodePatterns = map[string]*regexp.Regexp{
+	"AU": regexp.MustCompile(`^\d{4}$`),
+	"BR": regexp.MustCompile(`^\d{5}-?\d{3}$`),
+	"CA": regexp.MustCompile(`^[A-Z]\d[A-Z] ?\d[A-Z]\d$`),
+	"CH": regexp.MustCompile(`^\d{4}$`),
+	"CN": regexp.MustCompile(`^\d{6}$`),
+	"DE": regexp.MustCompile(`^\d{5}$`),
+	"ES": regexp.MustCompile(`^\d{5}$`),
+	"FR": regexp.MustCompile(`^\d{5}$`),
+	"GB": regexp.MustCompile(`^[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}$`),
+	"IE": regexp.MustCompile(`^[A-Z]\d[\dW] ?[A-Z\d]{4}$`),
+	"IN": regexp.MustCompile(`^\d{6}$`),
+	"IT": regexp.MustCompile(`^\d{5}$`),
+	"JP": regexp.MustCompile(`^\d{3}-?\d{4}$`),
+	"NL": regexp.MustCompile(`^\d{4} ?[A-Z]{2}$`),
+	"PL": regexp.MustCompile(`^\d{2}-\d{3}$`),
+	"SE": regexp.MustCompile(`^\d{3} ?\d{2}$`),
+	"US": regexp.MustCompile(`^\d{5}(-\d{4})?$`),
+}
+
+// genericPostcodePattern applies to countries without a specific pattern, where
+// the postcode is optional.
+var genericPostcodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9 -]{1,9}$`)
+
+// normalizeAddress upper-cases the country and postcode and trims whitespace
+// so that validation and storage see a canonical form.
+func normalizeAddress(address *models.Address) {
+	address.FullName = strings.TrimSpace(address.FullName)
+	address.Line1 = strings.TrimSpace(address.Line1)
+	address.Line2 = strings.TrimSpace(address.Line2)
+	address.City = strings.TrimSpace(address.City)
+	address.Region = strings.TrimSpace(address.Region)
+	address.Postcode = strings.ToUpper(strings.TrimSpace(address.Postcode))
+	address.Country = strings.ToUpper(strings.TrimSpace(address.Country))
+	address.Phone = strings.TrimSpace(address.Phone)
+}
+
+func validateAddress(address *models.Address) []*errdetails.BadRequest_FieldViolation {
+	var violations []*errdetails.BadRequest_FieldViolation
+	violate := func(field, description string) {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       "address." + field,
+			Description: description,
+		})
+	}
+
+	if address.FullName == "" {
+		violate("full_name", "full name is required")
+	}
+	if address.Line1 == "" {
+		violate("line1", "address line 1 is required")
+	}
+	if address.City == "" {
+		violate("city", "city is required")
+	}
+
+	if !countryCodes[address.Country] {
+		violate("country", "country must be an ISO 3166-1 alpha-2 code")
+		return violations
+	}
+
+	if pattern, ok := postcodePatterns[address.Country]; ok {
+		if !pattern.MatchString(address.Postcode) {
+			violate("postcode", "postcode is not valid for "+address.Country)
+		}
+	} else if address.Postcode != "" && !genericPostcodePattern.MatchString(address.Postcode) {
+		violate("postcode", "postcode is not valid")
+	}
+
+	return violations
+}
+
+func makeSet(values []string) map[string]bool {
+	set := make(map[string]bool, len(values))
+	for _, v := range values {
+		set[v] = true
+	}
+	return set
+}
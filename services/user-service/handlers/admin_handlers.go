@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultSearchPageSize = 20
+	maxSearchPageSize     = 100
+)
+
+func (s *UserServiceServer) SearchUsers(ctx context.Context, req *userpb.SearchUsersRequest) (*userpb.SearchUsersResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	pageSize := int(req.PageSize)
+	if pageSize <= 0 {
+		pageSize = defaultSearchPageSize
+	}
+	if pageSize > maxSearchPageSize {
+		pageSize = maxSearchPageSize
+	}
+
+	// The page token is the ID of the last user on the previous page.
+	afterUserID := 0
+	if req.PageToken != "" {
+		var err error
+		afterUserID, err = strconv.Atoi(req.PageToken)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	// One extra row is fetched to tell whether there is another page.
+	users, err := s.repo.SearchUsers(ctx, strings.TrimSpace(req.Query), afterUserID, pageSize+1)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to search users: %v", err)
+	}
+
+	var nextPageToken string
+	if len(users) > pageSize {
+		users = users[:pageSize]
+		nextPageToken = strconv.Itoa(users[pageSize-1].UserID)
+	}
+
+	var userProtos []*userpb.User
+	for _, user := range users {
+		userProtos = append(userProtos, mapUserToProto(user))
+	}
+
+	return &userpb.SearchUsersResponse{
+		Users:         userProtos,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+func (s *UserServiceServer) GetUser(ctx context.Context, req *userpb.GetUserRequest) (*userpb.GetUserResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	return &userpb.GetUserResponse{
+		User:        mapUserToProto(user),
+		TotpEnabled: user.TOTPEnabled,
+	}, nil
+}
+
+func (s *UserServiceServer) DisableUser(ctx context.Context, req *userpb.DisableUserRequest) (*userpb.DisableUserResponse, error) {
+	user, err := s.setUserDisabled(ctx, req.UserId, true, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userpb.DisableUserResponse{
+		User: mapUserToProto(user),
+	}, nil
+}
+
+func (s *UserServiceServer) EnableUser(ctx context.Context, req *userpb.EnableUserRequest) (*userpb.EnableUserResponse, error) {
+	user, err := s.setUserDisabled(ctx, req.UserId, false, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+
+	return &userpb.EnableUserResponse{
+		User: mapUserToProto(user),
+	}, nil
+}
+
+func (s *UserServiceServer) setUserDisabled(ctx context.Context, userIDParam string, disabled bool, reason string) (*models.User, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	adminID, ok := ctx.Value(auth.UserIDKey).(int)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	userID, err := strconv.Atoi(userIDParam)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	reason = strings.TrimSpace(reason)
+	if disabled && reason == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is required")
+	}
+
+	// An admin locking themselves out could leave nobody able to undo it.
+	if disabled && userID == adminID {
+		return nil, status.Errorf(codes.FailedPrecondition, "admins cannot disable their own account")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	action := models.AdminActionEnableUser
+	if disabled {
+		action = models.AdminActionDisableUser
+	}
+
+	err = s.repo.SetUserDisabled(ctx, userID, disabled, &models.AdminAction{
+		AdminUserID:  adminID,
+		Action:       action,
+		TargetUserID: userID,
+		Reason:       reason,
+	})
+	if err != nil {
+		if err.Error() == "user not found" {
+			return nil, status.Errorf(codes.NotFound, "user not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+	}
+
+	user.Disabled = disabled
+	return user, nil
+}
+
+// ImpersonateUser issues a short-lived token that lets an admin see the
+// service as a customer does. The token is bound to its own session, so it
+// shows up in and can be revoked from the user's session list.
+func (s *UserServiceServer) ImpersonateUser(ctx context.Context, req *userpb.ImpersonateUserRequest) (*userpb.ImpersonateUserResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	adminID, ok := ctx.Value(auth.UserIDKey).(int)
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	reason := strings.TrimSpace(req.Reason)
+	if reason == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "reason is required")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	// Impersonating another admin would let an admin act with privileges
+	// that are not their own.
+	if user.Role == models.RoleAdmin {
+		return nil, status.Errorf(codes.PermissionDenied, "admin accounts cannot be impersonated")
+	}
+	if user.Disabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "account is disabled")
+	}
+
+	// The action is recorded before the token exists, so no token is ever
+	// issued without an audit entry.
+	err = s.repo.RecordAdminAction(ctx, &models.AdminAction{
+		AdminUserID:  adminID,
+		Action:       models.AdminActionImpersonate,
+		TargetUserID: userID,
+		Reason:       reason,
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record admin action: %v", err)
+	}
+
+	session := s.newSession(ctx, userID, fmt.Sprintf("Impersonated by admin %d", adminID), s.cfg.ImpersonationTokenDuration)
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create session: %v", err)
+	}
+
+	s.recordAuthEvent(ctx, models.AuthEventTokenIssued, user.UserID, user.Email, models.AuthOutcomeSuccess, fmt.Sprintf("session %d, impersonated by admin %d", session.SessionID, adminID))
+
+	token, err := utils.GenerateImpersonationJWT(user, adminID, session.SessionID, s.cfg.JWTSecretKey, s.cfg.ImpersonationTokenDuration)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token")
+	}
+
+	return &userpb.ImpersonateUserResponse{
+		Token:     token,
+		ExpiresAt: session.ExpiresAt.Format(time.RFC3339),
+	}, nil
+}
+
+func (s *UserServiceServer) ListAdminActions(ctx context.Context, req *userpb.ListAdminActionsRequest) (*userpb.ListAdminActionsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	actions, err := s.repo.ListAdminActions(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list admin actions: %v", err)
+	}
+
+	var actionProtos []*userpb.AdminAction
+	for _, action := range actions {
+		actionProtos = append(actionProtos, mapAdminActionToProto(action))
+	}
+
+	return &userpb.ListAdminActionsResponse{
+		Actions: actionProtos,
+	}, nil
+}
+
+// recordAdminAction records an admin action that has already taken effect.
+// A failure is only logged, since the action cannot be undone at this point.
+func (s *UserServiceServer) recordAdminAction(ctx context.Context, action string, targetUserID int, reason string) {
+	adminID, _ := ctx.Value(auth.UserIDKey).(int)
+
+	err := s.repo.RecordAdminAction(ctx, &models.AdminAction{
+		AdminUserID:  adminID,
+		Action:       action,
+		TargetUserID: targetUserID,
+		Reason:       reason,
+	})
+	if err != nil {
+		log.Printf("Failed to record %s by admin %d on user %d: %v", action, adminID, targetUserID, err)
+	}
+}
+
+func mapAdminActionToProto(action *models.AdminAction) *userpb.AdminAction {
+	return &userpb.AdminAction{
+		ActionId:     strconv.Itoa(action.ActionID),
+		AdminUserId:  strconv.Itoa(action.AdminUserID),
+		Action:       action.Action,
+		TargetUserId: strconv.Itoa(action.TargetUserID),
+		Reason:       action.Reason,
+		CreatedAt:    action.CreatedAt.Format(time.RFC3339),
+	}
+}
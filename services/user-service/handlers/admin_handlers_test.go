@@ -0,0 +1,195 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// expectAdminAction sets up the audit entry recorded for an admin action.
+func expectAdminAction(mock sqlmock.Sqlmock, adminID int, action string, targetUserID int) {
+	mock.ExpectQuery("INSERT INTO admin_actions").
+		WithArgs(adminID, action, targetUserID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"action_id", "created_at"}).AddRow(1, time.Now()))
+}
+
+func TestSearchUsersPagination(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(`jo\_%`, 0, 3).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(2, "jo_smith", "jo_smith@example.com", "hash", true, 0, "customer", false, false).
+			AddRow(4, "jo_brown", "jb@example.com", "hash", true, 0, "customer", false, true).
+			AddRow(9, "jo_green", "jg@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(`jo\_%`, 4, 3).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(9, "jo_green", "jg@example.com", "hash", true, 0, "customer", false, false))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	resp, err := handler.SearchUsers(adminContext(), &userpb.SearchUsersRequest{Query: "Jo_", PageSize: 2})
+	if err != nil {
+		t.Fatalf("SearchUsers failed: %v", err)
+	}
+	if len(resp.Users) != 2 || resp.NextPageToken != "4" || !resp.Users[1].Disabled {
+		t.Fatalf("Unexpected first page: %+v", resp)
+	}
+
+	resp, err = handler.SearchUsers(adminContext(), &userpb.SearchUsersRequest{Query: "Jo_", PageSize: 2, PageToken: resp.NextPageToken})
+	if err != nil {
+		t.Fatalf("SearchUsers failed: %v", err)
+	}
+	if len(resp.Users) != 1 || resp.NextPageToken != "" {
+		t.Errorf("Unexpected last page: %+v", resp)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDisableUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE users SET disabled_at").
+		WithArgs(true, 5).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE sessions SET revoked_at").
+		WithArgs(5).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectQuery("INSERT INTO admin_actions").
+		WithArgs(1, models.AdminActionDisableUser, 5, "Chargeback fraud").
+		WillReturnRows(sqlmock.NewRows([]string{"action_id", "created_at"}).AddRow(1, time.Now()))
+	mock.ExpectCommit()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	resp, err := handler.DisableUser(adminContext(), &userpb.DisableUserRequest{UserId: "5", Reason: " Chargeback fraud "})
+	if err != nil {
+		t.Fatalf("DisableUser failed: %v", err)
+	}
+	if !resp.User.Disabled {
+		t.Errorf("Expected the user to be reported as disabled")
+	}
+
+	_, err = handler.DisableUser(adminContext(), &userpb.DisableUserRequest{UserId: "1", Reason: "Testing"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition when disabling own account, got %v", err)
+	}
+
+	_, err = handler.DisableUser(adminContext(), &userpb.DisableUserRequest{UserId: "5"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a reason, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLoginDisabledUser(t *testing.T) {
+	db, _, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, _, err = handler.(*UserServiceServer).completeLogin(context.Background(), &models.User{UserID: 5, Disabled: true})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for a disabled account, got %v", err)
+	}
+}
+
+func TestImpersonateUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	cfg := testConfig
+	cfg.ImpersonationTokenDuration = 15 * time.Minute
+
+	var device string
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "testuser", "test@example.com", "hash", true, 2, "customer", false, false))
+	mock.ExpectQuery("INSERT INTO admin_actions").
+		WithArgs(1, models.AdminActionImpersonate, 5, "Ticket 4411").
+		WillReturnRows(sqlmock.NewRows([]string{"action_id", "created_at"}).AddRow(1, time.Now()))
+	mock.ExpectQuery("INSERT INTO sessions").
+		WithArgs(5, sqlmock.AnyArg(), sqlmock.AnyArg(), captureArg{&device}, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id", "created_at", "last_seen_at"}).AddRow(8, time.Now(), time.Now()))
+	expectAuthEvent(mock, models.AuthEventTokenIssued, models.AuthOutcomeSuccess)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, cfg)
+
+	resp, err := handler.ImpersonateUser(adminContext(), &userpb.ImpersonateUserRequest{UserId: "5", Reason: "Ticket 4411"})
+	if err != nil {
+		t.Fatalf("ImpersonateUser failed: %v", err)
+	}
+
+	claims, err := auth.ValidateJWT(resp.Token, cfg.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("Returned token is invalid: %v", err)
+	}
+	if claims.UserID != 5 || claims.ImpersonatorID != 1 || claims.ID != "8" || claims.TokenVersion != 2 {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if device != "Impersonated by admin 1" {
+		t.Errorf("Unexpected session device %q", device)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestImpersonateAdminRejected(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(2).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(2, "other", "other@example.com", "hash", true, 0, "admin", false, false))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.ImpersonateUser(adminContext(), &userpb.ImpersonateUserRequest{UserId: "2", Reason: "Ticket 4411"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, got %v", err)
+	}
+
+	customerCtx := context.WithValue(context.Background(), auth.RoleKey, models.RoleCustomer)
+	_, err = handler.ImpersonateUser(customerCtx, &userpb.ImpersonateUserRequest{UserId: "5", Reason: "Ticket 4411"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for non-admin caller, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
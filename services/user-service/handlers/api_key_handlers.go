@@ -0,0 +1,199 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	apiKeyPrefix = "ek_"
+	// apiKeyDisplayLength is how many leading characters of a key are stored
+	// in the clear to identify it.
+	apiKeyDisplayLength = 11
+)
+
+var validAPIKeyScopes = makeSet(models.APIKeyScopes)
+
+func (s *UserServiceServer) CreateAPIKey(ctx context.Context, req *userpb.CreateAPIKeyRequest) (*userpb.CreateAPIKeyResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	key := &models.APIKey{
+		UserID: userID,
+		Name:   strings.TrimSpace(req.Name),
+		Scopes: req.Scopes,
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	if key.Name == "" {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "name", Description: "name is required"})
+	}
+	if len(key.Scopes) == 0 {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "scopes", Description: "at least one scope is required"})
+	}
+	for _, scope := range key.Scopes {
+		if !validAPIKeyScopes[scope] {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "scopes", Description: "unknown scope " + scope})
+		}
+	}
+	if req.ExpiresAt != "" {
+		expiresAt, err := time.Parse(time.RFC3339, req.ExpiresAt)
+		if err != nil || !expiresAt.After(time.Now()) {
+			violations = append(violations, &errdetails.BadRequest_FieldViolation{Field: "expires_at", Description: "must be an RFC 3339 time in the future"})
+		}
+		key.ExpiresAt = &expiresAt
+	}
+	if len(violations) > 0 {
+		return nil, fieldViolationsError("invalid API key", violations)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	// An API key must not grant more than its user could do with a token.
+	if auth.HasScope(key.Scopes, models.ScopeAdmin) && user.Role != models.RoleAdmin {
+		return nil, status.Errorf(codes.FailedPrecondition, "admin scope can only be granted to admin users")
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate API key")
+	}
+	apiKey := apiKeyPrefix + token
+
+	key.Prefix = apiKey[:apiKeyDisplayLength]
+	key.KeyHash = auth.HashAPIKey(apiKey)
+
+	err = s.repo.CreateAPIKey(ctx, key)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create API key: %v", err)
+	}
+
+	s.recordAdminAction(ctx, models.AdminActionCreateAPIKey, userID, "key "+strconv.Itoa(key.KeyID))
+
+	return &userpb.CreateAPIKeyResponse{
+		ApiKey: apiKey,
+		Key:    mapAPIKeyToProto(key),
+	}, nil
+}
+
+func (s *UserServiceServer) ListAPIKeys(ctx context.Context, req *userpb.ListAPIKeysRequest) (*userpb.ListAPIKeysResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	keys, err := s.repo.ListAPIKeys(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list API keys: %v", err)
+	}
+
+	var keyProtos []*userpb.APIKey
+	for _, key := range keys {
+		keyProtos = append(keyProtos, mapAPIKeyToProto(key))
+	}
+
+	return &userpb.ListAPIKeysResponse{
+		Keys: keyProtos,
+	}, nil
+}
+
+func (s *UserServiceServer) RevokeAPIKey(ctx context.Context, req *userpb.RevokeAPIKeyRequest) (*userpb.RevokeAPIKeyResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	keyID, err := strconv.Atoi(req.KeyId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid key ID")
+	}
+
+	userID, err := s.repo.RevokeAPIKey(ctx, keyID)
+	if err != nil {
+		if err == repository.ErrAPIKeyNotFound {
+			return nil, status.Errorf(codes.NotFound, "API key not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke API key: %v", err)
+	}
+
+	s.recordAdminAction(ctx, models.AdminActionRevokeAPIKey, userID, "key "+req.KeyId)
+
+	return &userpb.RevokeAPIKeyResponse{
+		Message: "API key revoked successfully",
+	}, nil
+}
+
+// ResolveAPIKey returns the principal an API key authenticates as. The
+// calling service is responsible for checking the scopes.
+func (s *UserServiceServer) ResolveAPIKey(ctx context.Context, req *userpb.ResolveAPIKeyRequest) (*userpb.ResolveAPIKeyResponse, error) {
+	if req.ApiKey == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "API key is required")
+	}
+
+	key, err := s.repo.GetActiveAPIKey(ctx, auth.HashAPIKey(req.ApiKey))
+	if err != nil {
+		if err == repository.ErrInvalidAPIKey {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to check API key: %v", err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, key.UserID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid API key")
+	}
+	if user.Disabled {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	return &userpb.ResolveAPIKeyResponse{
+		UserId:        strconv.Itoa(user.UserID),
+		Role:          user.Role,
+		EmailVerified: user.EmailVerified,
+		Scopes:        key.Scopes,
+	}, nil
+}
+
+func mapAPIKeyToProto(key *models.APIKey) *userpb.APIKey {
+	return &userpb.APIKey{
+		KeyId:      strconv.Itoa(key.KeyID),
+		UserId:     strconv.Itoa(key.UserID),
+		Name:       key.Name,
+		Prefix:     key.Prefix,
+		Scopes:     key.Scopes,
+		CreatedAt:  key.CreatedAt.Format(time.RFC3339),
+		ExpiresAt:  formatOptionalTime(key.ExpiresAt),
+		LastUsedAt: formatOptionalTime(key.LastUsedAt),
+		RevokedAt:  formatOptionalTime(key.RevokedAt),
+	}
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func adminContext() context.Context {
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+	return context.WithValue(ctx, auth.RoleKey, models.RoleAdmin)
+}
+
+func TestCreateAPIKey(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	var keyHash string
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "warehouse", "warehouse@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("INSERT INTO api_keys").
+		WithArgs(5, "Warehouse sync", sqlmock.AnyArg(), captureArg{&keyHash}, pq.Array([]string{"orders:read"}), nil).
+		WillReturnRows(sqlmock.NewRows([]string{"key_id", "created_at"}).AddRow(3, time.Now()))
+	expectAdminAction(mock, 1, models.AdminActionCreateAPIKey, 5)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	resp, err := handler.CreateAPIKey(adminContext(), &userpb.CreateAPIKeyRequest{
+		UserId: "5",
+		Name:   "Warehouse sync",
+		Scopes: []string{"orders:read"},
+	})
+	if err != nil {
+		t.Fatalf("CreateAPIKey failed: %v", err)
+	}
+
+	if !strings.HasPrefix(resp.ApiKey, resp.Key.Prefix) || !strings.HasPrefix(resp.ApiKey, "ek_") {
+		t.Errorf("Unexpected key %q with prefix %q", resp.ApiKey, resp.Key.Prefix)
+	}
+	if keyHash != auth.HashAPIKey(resp.ApiKey) {
+		t.Errorf("Stored hash does not match the returned key")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCreateAPIKeyValidation(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.CreateAPIKey(adminContext(), &userpb.CreateAPIKeyRequest{UserId: "5", Name: "Batch", Scopes: []string{"everything"}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for unknown scope, got %v", err)
+	}
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(5).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(5, "warehouse", "warehouse@example.com", "hash", true, 0, "customer", false, false))
+
+	_, err = handler.CreateAPIKey(adminContext(), &userpb.CreateAPIKeyRequest{UserId: "5", Name: "Batch", Scopes: []string{"admin"}})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for admin scope on a customer, got %v", err)
+	}
+
+	customerCtx := context.WithValue(context.Background(), auth.RoleKey, models.RoleCustomer)
+	_, err = handler.CreateAPIKey(customerCtx, &userpb.CreateAPIKeyRequest{UserId: "5", Name: "Batch", Scopes: []string{"orders:read"}})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied for non-admin caller, got %v", err)
+	}
+}
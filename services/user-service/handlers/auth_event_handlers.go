@@ -0,0 +1,162 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	defaultAuthEventPageSize = 50
+	maxAuthEventPageSize     = 500
+
+	// maxIPAddressLength is the longest textual IPv6 address. ClientIP only
+	// returns parsed addresses, so this is a guard for the column width.
+	maxIPAddressLength = 45
+)
+
+func (s *UserServiceServer) ListAuthEvents(ctx context.Context, req *userpb.ListAuthEventsRequest) (*userpb.ListAuthEventsResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	filter := models.AuthEventFilter{
+		Limit: int(req.PageSize),
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = defaultAuthEventPageSize
+	}
+	if filter.Limit > maxAuthEventPageSize {
+		filter.Limit = maxAuthEventPageSize
+	}
+
+	var err error
+	if req.UserId != "" {
+		filter.UserID, err = strconv.Atoi(req.UserId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+		}
+	}
+	if req.StartTime != "" {
+		filter.Since, err = time.Parse(time.RFC3339, req.StartTime)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "start time must be an RFC 3339 time")
+		}
+	}
+	if req.EndTime != "" {
+		filter.Until, err = time.Parse(time.RFC3339, req.EndTime)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "end time must be an RFC 3339 time")
+		}
+	}
+
+	// The page token is the ID of the last event on the previous page.
+	if req.PageToken != "" {
+		filter.BeforeEventID, err = strconv.ParseInt(req.PageToken, 10, 64)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	pageSize := filter.Limit
+	filter.Limit++
+
+	events, err := s.repo.ListAuthEvents(ctx, filter)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list auth events: %v", err)
+	}
+
+	var nextPageToken string
+	if len(events) > pageSize {
+		events = events[:pageSize]
+		nextPageToken = strconv.FormatInt(events[pageSize-1].EventID, 10)
+	}
+
+	var eventProtos []*userpb.AuthEvent
+	for _, event := range events {
+		eventProtos = append(eventProtos, mapAuthEventToProto(event))
+	}
+
+	return &userpb.ListAuthEventsResponse{
+		Events:        eventProtos,
+		NextPageToken: nextPageToken,
+	}, nil
+}
+
+// recordAuthEvent appends a successful event to the auth log. userID is 0
+// when the account is unknown. Failures are only logged so that an
+// unavailable audit log cannot lock users out.
+func (s *UserServiceServer) recordAuthEvent(ctx context.Context, eventType string, userID int, email, outcome, reason string) {
+	if err := s.writeAuthEvent(ctx, eventType, userID, email, outcome, reason); err != nil {
+		log.Printf("Failed to record %s auth event for user %d: %v", eventType, userID, err)
+	}
+}
+
+// recordAuthFailure appends a failed attempt to the auth log and returns
+// err, the error the attempt is answered with. Attempts that cannot be
+// logged are answered with Unavailable instead, so that failures such as
+// password guessing are never hidden from the log.
+func (s *UserServiceServer) recordAuthFailure(ctx context.Context, eventType string, userID int, email, reason string, err error) error {
+	if writeErr := s.writeAuthEvent(ctx, eventType, userID, email, models.AuthOutcomeFailure, reason); writeErr != nil {
+		log.Printf("Failed to record %s auth event for user %d: %v", eventType, userID, writeErr)
+		return status.Errorf(codes.Unavailable, "failed to record auth event")
+	}
+	return err
+}
+
+func (s *UserServiceServer) writeAuthEvent(ctx context.Context, eventType string, userID int, email, outcome, reason string) error {
+	event := &models.AuthEvent{
+		EventType: eventType,
+		Email:     truncate(email, 255),
+		IPAddress: truncate(auth.ClientIP(ctx, s.cfg.TrustedProxies), maxIPAddressLength),
+		UserAgent: truncate(auth.UserAgent(ctx), maxSessionFieldLength),
+		Outcome:   outcome,
+		Reason:    truncate(reason, 255),
+	}
+	if userID != 0 {
+		event.UserID = &userID
+	}
+
+	return s.repo.RecordAuthEvent(ctx, event)
+}
+
+// recordLoginOutcome records the result of completeLogin for a user whose
+// primary credentials were accepted, and returns err, or the error from
+// recording it.
+func (s *UserServiceServer) recordLoginOutcome(ctx context.Context, eventType string, user *models.User, challenge string, err error) error {
+	switch {
+	case err != nil:
+		return s.recordAuthFailure(ctx, eventType, user.UserID, user.Email, status.Convert(err).Message(), err)
+	case challenge != "":
+		s.recordAuthEvent(ctx, eventType, user.UserID, user.Email, models.AuthOutcomeSuccess, "two-factor challenge issued")
+	default:
+		s.recordAuthEvent(ctx, eventType, user.UserID, user.Email, models.AuthOutcomeSuccess, "")
+	}
+	return nil
+}
+
+func mapAuthEventToProto(event *models.AuthEvent) *userpb.AuthEvent {
+	var userID string
+	if event.UserID != nil {
+		userID = strconv.Itoa(*event.UserID)
+	}
+
+	return &userpb.AuthEvent{
+		EventId:   strconv.FormatInt(event.EventID, 10),
+		EventType: event.EventType,
+		UserId:    userID,
+		Email:     event.Email,
+		IpAddress: event.IPAddress,
+		UserAgent: event.UserAgent,
+		Outcome:   event.Outcome,
+		Reason:    event.Reason,
+		CreatedAt: event.CreatedAt.Format(time.RFC3339),
+	}
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+var authEventColumns = []string{"event_id", "event_type", "user_id", "email", "ip_address", "user_agent", "outcome", "reason", "created_at"}
+
+func TestListAuthEvents(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	since := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+
+	mock.ExpectQuery(`FROM auth_events\s+WHERE user_id = \$1 AND created_at >= \$2 AND created_at < \$3 AND event_id < \$4\s+ORDER BY event_id DESC LIMIT \$5`).
+		WithArgs(5, since, until, int64(100), 3).
+		WillReturnRows(sqlmock.NewRows(authEventColumns).
+			AddRow(90, "login", 5, "test@example.com", "203.0.113.7", "curl", "failure", "invalid password", since).
+			AddRow(80, "login", 5, "test@example.com", "203.0.113.7", "curl", "success", "", since).
+			AddRow(70, "token_issued", 5, "test@example.com", "203.0.113.7", "curl", "success", "session 3", since))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	resp, err := handler.ListAuthEvents(adminContext(), &userpb.ListAuthEventsRequest{
+		UserId:    "5",
+		StartTime: since.Format(time.RFC3339),
+		EndTime:   until.Format(time.RFC3339),
+		PageSize:  2,
+		PageToken: "100",
+	})
+	if err != nil {
+		t.Fatalf("ListAuthEvents failed: %v", err)
+	}
+	if len(resp.Events) != 2 || resp.NextPageToken != "80" {
+		t.Fatalf("Unexpected page: %+v", resp)
+	}
+	if resp.Events[0].UserId != "5" || resp.Events[0].Reason != "invalid password" {
+		t.Errorf("Unexpected event: %+v", resp.Events[0])
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListAuthEventsValidation(t *testing.T) {
+	handler := NewUserServiceServer(nil, &recordingNotifier{}, nil, testConfig)
+
+	_, err := handler.ListAuthEvents(adminContext(), &userpb.ListAuthEventsRequest{StartTime: "yesterday"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed start time, got %v", err)
+	}
+
+	_, err = handler.ListAuthEvents(adminContext(), &userpb.ListAuthEventsRequest{PageToken: "next"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a malformed page token, got %v", err)
+	}
+}
+
+func TestFailedLoginIsRefusedWhenAuditLogIsUnavailable(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	mock.ExpectQuery("INSERT INTO auth_events").
+		WithArgs(models.AuthEventLogin, nil, "test@example.com", "", "", models.AuthOutcomeFailure, "login locked").
+		WillReturnError(errors.New("connection refused"))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.LoginUser(context.Background(), &userpb.LoginUserRequest{Email: "test@example.com", Password: "password123"})
+	if status.Code(err) != codes.Unavailable {
+		t.Errorf("Expected Unavailable when the failure cannot be recorded, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
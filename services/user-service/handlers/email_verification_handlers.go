@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/notifier"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func (s *UserServiceServer) SendVerification(ctx context.Context, req *userpb.SendVerificationRequest) (*userpb.SendVerificationResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if user.EmailVerified {
+		return nil, status.Errorf(codes.FailedPrecondition, "email address is already verified")
+	}
+
+	err = s.sendVerification(ctx, user)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "failed to send verification email: %v", err)
+	}
+
+	return &userpb.SendVerificationResponse{
+		Message: "Verification email sent",
+	}, nil
+}
+
+const verificationResentMessage = "If an unverified account exists for this email, a verification email has been sent"
+
+func (s *UserServiceServer) ResendVerification(ctx context.Context, req *userpb.ResendVerificationRequest) (*userpb.ResendVerificationResponse, error) {
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	// The response is the same whether or not the account exists, is
+	// already verified or could be emailed, so the endpoint cannot be used
+	// to discover registered emails.
+	resp := &userpb.ResendVerificationResponse{
+		Message: verificationResentMessage,
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return resp, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to look up user: %v", err)
+	}
+
+	if user.EmailVerified || user.Disabled {
+		return resp, nil
+	}
+
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("Failed to resend verification to user %d: %v", user.UserID, err)
+	}
+
+	return resp, nil
+}
+
+func (s *UserServiceServer) VerifyEmail(ctx context.Context, req *userpb.VerifyEmailRequest) (*userpb.VerifyEmailResponse, error) {
+	if req.Token == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token is required")
+	}
+
+	err := s.repo.VerifyEmail(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		if err == repository.ErrInvalidVerificationToken {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid or expired verification token")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to verify email: %v", err)
+	}
+
+	return &userpb.VerifyEmailResponse{
+		Message: "Email verified successfully",
+	}, nil
+}
+
+// sendVerification issues a fresh verification token for the user's current
+// email address and delivers it through the notifier.
+func (s *UserServiceServer) sendVerification(ctx context.Context, user *models.User) error {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return err
+	}
+
+	verificationToken := &models.EmailVerificationToken{
+		UserID:    user.UserID,
+		Email:     user.Email,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.VerificationTokenDuration),
+	}
+
+	err = s.repo.CreateVerificationToken(ctx, verificationToken)
+	if err != nil {
+		return err
+	}
+
+	return s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf("Use the following token to verify your email address. It expires in %s.\n\n%s",
+			s.cfg.VerificationTokenDuration, token),
+	})
+}
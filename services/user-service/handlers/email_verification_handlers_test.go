@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestVerifyEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE email_verification_tokens SET used_at").
+		WithArgs(utils.HashToken("verify-token")).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "email"}).AddRow(1, "old@example.com"))
+	mock.ExpectExec("UPDATE users SET email_verified = TRUE").
+		WithArgs(1, "old@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.VerifyEmail(context.Background(), &userpb.VerifyEmailRequest{Token: "verify-token"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a token issued to a previous email, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLoginUserRequiresVerifiedEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	passwordHash, _ := utils.HashPassword("password123")
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", passwordHash, false, 0, "customer", false, false))
+	expectAuthEvent(mock, models.AuthEventLogin, models.AuthOutcomeFailure)
+
+	cfg := testConfig
+	cfg.RequireVerifiedEmailForLogin = true
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, cfg)
+
+	_, err = handler.LoginUser(context.Background(), &userpb.LoginUserRequest{Email: "test@example.com", Password: "password123"})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition for unverified email, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("missing@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", "hash", false, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_verification_tokens SET used_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO email_verification_tokens").
+		WithArgs(1, "test@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	notifications := &recordingNotifier{}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), notifications, nil, testConfig)
+
+	missing, err := handler.ResendVerification(context.Background(), &userpb.ResendVerificationRequest{Email: "missing@example.com"})
+	if err != nil {
+		t.Fatalf("ResendVerification failed for an unknown email: %v", err)
+	}
+	existing, err := handler.ResendVerification(context.Background(), &userpb.ResendVerificationRequest{Email: "test@example.com"})
+	if err != nil {
+		t.Fatalf("ResendVerification failed: %v", err)
+	}
+
+	if missing.Message != existing.Message {
+		t.Errorf("Expected the same response for unknown and existing emails, got %q and %q", missing.Message, existing.Message)
+	}
+	if len(notifications.messages) != 1 || notifications.messages[0].To != "test@example.com" {
+		t.Errorf("Expected one verification email to test@example.com, got %+v", notifications.messages)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"context"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so that
+// LoginUser takes the same time whether or not an account exists.
+var dummyPasswordHash, _ = utils.HashPassword("dummy-password")
+
+func emailFailureKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipFailureKey(ip string) string {
+	return "ip:" + ip
+}
+
+// loginFailureKeys returns the keys failed logins are tracked under, paired
+// with the number of failures each key tolerates before it is locked. ip is
+// only known when the API gateway forwarded it; the gateway's own address is
+// shared by every client and must never be locked.
+func (s *UserServiceServer) loginFailureKeys(email, ip string) map[string]int {
+	keys := map[string]int{
+		emailFailureKey(email): s.cfg.MaxLoginAttempts,
+	}
+	if ip != "" {
+		keys[ipFailureKey(ip)] = s.cfg.MaxLoginAttemptsPerIP
+	}
+	return keys
+}
+
+func (s *UserServiceServer) isLoginLocked(ctx context.Context, email, ip string) (bool, error) {
+	var keys []string
+	for key := range s.loginFailureKeys(email, ip) {
+		keys = append(keys, key)
+	}
+	return s.repo.IsLoginLocked(ctx, keys)
+}
+
+// recordLoginFailure counts a failed attempt against the account and the
+// client address, locking whichever has reached its limit. Each lockout is
+// twice as long as the one before, up to MaxLoginLockoutDuration, until a
+// successful login or LoginLockoutResetAfter without failures.
+func (s *UserServiceServer) recordLoginFailure(ctx context.Context, email, ip string) {
+	for key, maxAttempts := range s.loginFailureKeys(email, ip) {
+		attempts, lockouts, err := s.repo.RecordLoginFailure(ctx, key, s.cfg.LoginFailureWindow, s.cfg.LoginLockoutResetAfter)
+		if err != nil {
+			log.Printf("Failed to record login failure for %s: %v", key, err)
+			continue
+		}
+
+		if attempts < maxAttempts {
+			continue
+		}
+
+		err = s.repo.LockLogin(ctx, key, s.lockoutDuration(lockouts))
+		if err != nil {
+			log.Printf("Failed to lock login for %s: %v", key, err)
+		}
+	}
+}
+
+// clearLoginFailures forgets the failed attempts against user's account once
+// they have fully authenticated.
+func (s *UserServiceServer) clearLoginFailures(ctx context.Context, user *models.User) {
+	if err := s.repo.ClearLoginFailures(ctx, emailFailureKey(user.Email)); err != nil {
+		log.Printf("Failed to clear login failures for user %d: %v", user.UserID, err)
+	}
+}
+
+// lockoutDuration returns how long to lock a key out for after it has
+// already been locked out previousLockouts times.
+func (s *UserServiceServer) lockoutDuration(previousLockouts int) time.Duration {
+	duration := s.cfg.LoginLockoutDuration
+	for i := 0; i < previousLockouts && duration < s.cfg.MaxLoginLockoutDuration; i++ {
+		duration *= 2
+	}
+	if duration > s.cfg.MaxLoginLockoutDuration {
+		duration = s.cfg.MaxLoginLockoutDuration
+	}
+	return duration
+}
+
+func (s *UserServiceServer) UnlockUser(ctx context.Context, req *userpb.UnlockUserRequest) (*userpb.UnlockUserResponse, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	err = s.repo.ClearLoginFailures(ctx, emailFailureKey(user.Email))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to unlock user: %v", err)
+	}
+
+	s.recordAdminAction(ctx, models.AdminActionUnlockUser, userID, "")
+
+	return &userpb.UnlockUserResponse{
+		Message: "User unlocked successfully",
+	}, nil
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginUserUnknownEmailLocksAfterMaxAttempts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("missing@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	// The account was locked out twice before, so this lockout is four
+	// times as long even though the attempt counter started over.
+	mock.ExpectQuery("INSERT INTO login_failures").
+		WithArgs("email:missing@example.com", sqlmock.AnyArg(), testConfig.LoginLockoutResetAfter.Seconds()).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "lockout_count"}).AddRow(testConfig.MaxLoginAttempts, 2))
+	mock.ExpectExec("UPDATE login_failures SET locked_until .* lockout_count = lockout_count \\+ 1").
+		WithArgs("email:missing@example.com", (4 * testConfig.LoginLockoutDuration).Seconds()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO login_failures").
+		WithArgs("ip:203.0.113.7", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "lockout_count"}).AddRow(1, 0))
+	mock.ExpectQuery("INSERT INTO auth_events").
+		WithArgs(models.AuthEventLogin, nil, "missing@example.com", "203.0.113.7", "", models.AuthOutcomeFailure, "unknown email").
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "created_at"}).AddRow(1, time.Now()))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := fromGateway(context.Background(), metadata.Pairs("x-forwarded-for", "203.0.113.7"))
+
+	_, err = handler.LoginUser(ctx, &userpb.LoginUserRequest{Email: "missing@example.com", Password: "guess"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for unknown email, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLoginFailuresThroughGatewayDoNotLockOtherAccounts(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// Without x-forwarded-for the only address known is the gateway's, which
+	// every client shares, so failures are only counted against the email.
+	attempts := testConfig.MaxLoginAttemptsPerIP + 1
+	for i := 0; i < attempts; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		mock.ExpectQuery("SELECT EXISTS").
+			WithArgs(fmt.Sprintf("{\"email:%s\"}", email)).
+			WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+		mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+			WithArgs(email).
+			WillReturnRows(sqlmock.NewRows(userColumns))
+		mock.ExpectQuery("INSERT INTO login_failures").
+			WithArgs("email:"+email, sqlmock.AnyArg(), sqlmock.AnyArg()).
+			WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "lockout_count"}).AddRow(1, 0))
+		expectAuthEvent(mock, models.AuthEventLogin, models.AuthOutcomeFailure)
+	}
+	mock.ExpectQuery("SELECT EXISTS").
+		WithArgs(`{"email:other@example.com"}`).
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("other@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectQuery("INSERT INTO login_failures").
+		WithArgs("email:other@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "lockout_count"}).AddRow(1, 0))
+	expectAuthEvent(mock, models.AuthEventLogin, models.AuthOutcomeFailure)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := fromGateway(context.Background(), metadata.MD{})
+
+	for i := 0; i < attempts; i++ {
+		_, err = handler.LoginUser(ctx, &userpb.LoginUserRequest{Email: fmt.Sprintf("user%d@example.com", i), Password: "guess"})
+		if status.Code(err) != codes.Unauthenticated {
+			t.Fatalf("Expected Unauthenticated for attempt %d, got %v", i, err)
+		}
+	}
+
+	_, err = handler.LoginUser(ctx, &userpb.LoginUserRequest{Email: "other@example.com", Password: "guess"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected another account not to be locked, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLoginUserLocked(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(true))
+	expectAuthEvent(mock, models.AuthEventLogin, models.AuthOutcomeFailure)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.LoginUser(context.Background(), &userpb.LoginUserRequest{Email: "test@example.com", Password: "password123"})
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted for locked account, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestLockoutDuration(t *testing.T) {
+	s := &UserServiceServer{cfg: Config{LoginLockoutDuration: time.Minute, MaxLoginLockoutDuration: 10 * time.Minute}}
+
+	cases := map[int]time.Duration{
+		0:  time.Minute,
+		1:  2 * time.Minute,
+		3:  8 * time.Minute,
+		4:  10 * time.Minute,
+		50: 10 * time.Minute,
+	}
+	for lockouts, expected := range cases {
+		if got := s.lockoutDuration(lockouts); got != expected {
+			t.Errorf("lockoutDuration(%d) = %s, expected %s", lockouts, got, expected)
+		}
+	}
+}
+
+func TestUnlockUserRequiresAdmin(t *testing.T) {
+	handler := NewUserServiceServer(nil, &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.RoleKey, "customer")
+
+	_, err := handler.UnlockUser(ctx, &userpb.UnlockUserRequest{UserId: "1"})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, got %v", err)
+	}
+}
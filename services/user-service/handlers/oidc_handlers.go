@@ -0,0 +1,186 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const maxUsernameLength = 50
+
+func (s *UserServiceServer) StartOIDCLogin(ctx context.Context, req *userpb.StartOIDCLoginRequest) (*userpb.StartOIDCLoginResponse, error) {
+	if s.oidc == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "OIDC login is not configured")
+	}
+
+	state, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate state")
+	}
+	nonce, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate nonce")
+	}
+	codeVerifier, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate code verifier")
+	}
+
+	authURL, err := s.oidc.AuthCodeURL(ctx, state, nonce, codeVerifier)
+	if err != nil {
+		return nil, status.Errorf(codes.Unavailable, "identity provider unavailable: %v", err)
+	}
+
+	err = s.repo.CreateOIDCLoginState(ctx, &models.OIDCLoginState{
+		StateHash:    utils.HashToken(state),
+		Nonce:        nonce,
+		CodeVerifier: codeVerifier,
+		ExpiresAt:    time.Now().Add(s.cfg.OIDCStateDuration),
+	})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save login state: %v", err)
+	}
+
+	return &userpb.StartOIDCLoginResponse{
+		AuthorizationUrl: authURL,
+		State:            state,
+	}, nil
+}
+
+func (s *UserServiceServer) CompleteOIDCLogin(ctx context.Context, req *userpb.CompleteOIDCLoginRequest) (*userpb.CompleteOIDCLoginResponse, error) {
+	if s.oidc == nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "OIDC login is not configured")
+	}
+
+	if req.State == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "state and code are required")
+	}
+
+	state, err := s.repo.ConsumeOIDCLoginState(ctx, utils.HashToken(req.State))
+	if err != nil {
+		if err == repository.ErrInvalidOIDCState {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired login state")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to load login state: %v", err)
+	}
+
+	claims, err := s.oidc.Exchange(ctx, req.Code, state.CodeVerifier, state.Nonce)
+	if err != nil {
+		if errors.Is(err, oidc.ErrInvalidToken) {
+			log.Printf("OIDC login rejected: %v", err)
+			return nil, s.recordAuthFailure(ctx, models.AuthEventOIDCLogin, 0, "", "invalid ID token", status.Errorf(codes.Unauthenticated, "identity provider login failed"))
+		}
+		return nil, status.Errorf(codes.Unavailable, "identity provider unavailable: %v", err)
+	}
+
+	user, err := s.userForIdentity(ctx, claims)
+	if err != nil {
+		return nil, s.recordAuthFailure(ctx, models.AuthEventOIDCLogin, 0, claims.Email, status.Convert(err).Message(), err)
+	}
+
+	token, challenge, err := s.completeLogin(ctx, user)
+	if err := s.recordLoginOutcome(ctx, models.AuthEventOIDCLogin, user, challenge, err); err != nil {
+		return nil, err
+	}
+
+	return &userpb.CompleteOIDCLoginResponse{
+		Token:          token,
+		TotpRequired:   challenge != "",
+		ChallengeToken: challenge,
+	}, nil
+}
+
+// userForIdentity returns the local user linked to an external identity,
+// linking or creating one on first login. An existing account is only linked
+// automatically when both the provider and this service have verified that
+// the email address belongs to the user; otherwise anyone able to register
+// the address at the provider could take over the account.
+func (s *UserServiceServer) userForIdentity(ctx context.Context, claims *oidc.Claims) (*models.User, error) {
+	issuer := s.oidc.Issuer()
+
+	user, err := s.repo.GetUserByIdentity(ctx, issuer, claims.Subject)
+	if err == nil {
+		return user, nil
+	}
+	if err.Error() != "user not found" {
+		return nil, status.Errorf(codes.Internal, "failed to look up identity: %v", err)
+	}
+
+	if claims.Email == "" {
+		return nil, status.Errorf(codes.FailedPrecondition, "identity provider did not share an email address")
+	}
+
+	identity := &models.UserIdentity{
+		Issuer:  issuer,
+		Subject: claims.Subject,
+		Email:   claims.Email,
+	}
+
+	user, err = s.repo.GetUserByEmail(ctx, claims.Email)
+	if err == nil {
+		if !claims.EmailVerified || !user.EmailVerified {
+			return nil, status.Errorf(codes.FailedPrecondition, "an account with this email address already exists and could not be linked")
+		}
+
+		identity.UserID = user.UserID
+		if err := s.repo.LinkIdentity(ctx, identity); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to link identity: %v", err)
+		}
+		return user, nil
+	}
+	if err.Error() != "user not found" {
+		return nil, status.Errorf(codes.Internal, "failed to look up user: %v", err)
+	}
+
+	// The account has no usable password; the user can set one through the
+	// password reset flow.
+	password, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate password")
+	}
+	passwordHash, err := utils.HashPassword(password)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password")
+	}
+
+	user = &models.User{
+		Username:      usernameForIdentity(claims),
+		Email:         claims.Email,
+		PasswordHash:  passwordHash,
+		EmailVerified: claims.EmailVerified,
+	}
+
+	err = s.repo.CreateUserWithIdentity(ctx, user, identity)
+	if err != nil {
+		if err == repository.ErrEmailTaken {
+			return nil, status.Errorf(codes.AlreadyExists, "email already in use")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
+	}
+
+	if !user.EmailVerified {
+		if err := s.sendVerification(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.UserID, err)
+		}
+	}
+
+	return user, nil
+}
+
+func usernameForIdentity(claims *oidc.Claims) string {
+	username := claims.PreferredUsername
+	if username == "" {
+		username, _, _ = strings.Cut(claims.Email, "@")
+	}
+	return truncate(username, maxUsernameLength)
+}
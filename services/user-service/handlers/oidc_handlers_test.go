@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"database/sql/driver"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc/oidctest"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// captureArg matches any string argument and records it.
+type captureArg struct {
+	value *string
+}
+
+func (a captureArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*a.value = s
+	}
+	return ok
+}
+
+func newOIDCTestHandler(t *testing.T) (userpb.UserServiceServer, sqlmock.Sqlmock, *oidctest.Provider) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	provider, err := oidctest.Start("user-service", "client-secret")
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %v", err)
+	}
+	t.Cleanup(provider.Close)
+
+	cfg := testConfig
+	cfg.OIDCIssuerURL = provider.Issuer
+	cfg.OIDCClientID = "user-service"
+	cfg.OIDCClientSecret = "client-secret"
+	cfg.OIDCRedirectURL = "https://shop.example.com/oidc/callback"
+	cfg.OIDCStateDuration = 10 * time.Minute
+
+	return NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, cfg), mock, provider
+}
+
+// signInWithOIDC runs StartOIDCLogin, signs identity in at the mock provider
+// and sets up the stored login state to be consumed by CompleteOIDCLogin.
+func signInWithOIDC(t *testing.T, handler userpb.UserServiceServer, mock sqlmock.Sqlmock, provider *oidctest.Provider, identity oidctest.Identity) *userpb.CompleteOIDCLoginRequest {
+	t.Helper()
+
+	var nonce, codeVerifier string
+	mock.ExpectExec("INSERT INTO oidc_login_states").
+		WithArgs(sqlmock.AnyArg(), captureArg{&nonce}, captureArg{&codeVerifier}, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	resp, err := handler.StartOIDCLogin(context.Background(), &userpb.StartOIDCLoginRequest{})
+	if err != nil {
+		t.Fatalf("StartOIDCLogin failed: %v", err)
+	}
+
+	code, err := provider.Authorize(resp.AuthorizationUrl, identity)
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+
+	mock.ExpectQuery("DELETE FROM oidc_login_states").
+		WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}).
+			AddRow(nonce, codeVerifier, time.Now().Add(time.Minute)))
+
+	return &userpb.CompleteOIDCLoginRequest{State: resp.State, Code: code}
+}
+
+func TestCompleteOIDCLoginCreatesUser(t *testing.T) {
+	handler, mock, provider := newOIDCTestHandler(t)
+
+	req := signInWithOIDC(t, handler, mock, provider, oidctest.Identity{
+		Subject:       "abc123",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	})
+
+	mock.ExpectQuery("FROM user_identities").
+		WithArgs(provider.Issuer, "abc123").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectBegin()
+	mock.ExpectQuery("INSERT INTO users").
+		WithArgs("alice", "alice@example.com", sqlmock.AnyArg(), true).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).AddRow(9, "customer"))
+	mock.ExpectExec("INSERT INTO user_identities").
+		WithArgs(9, provider.Issuer, "abc123", "alice@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectSession(mock, 9, 1)
+	expectAuthEvent(mock, models.AuthEventOIDCLogin, models.AuthOutcomeSuccess)
+
+	resp, err := handler.CompleteOIDCLogin(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CompleteOIDCLogin failed: %v", err)
+	}
+
+	claims, err := auth.ValidateJWT(resp.Token, testConfig.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("Returned token is invalid: %v", err)
+	}
+	if claims.UserID != 9 || !claims.EmailVerified {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCompleteOIDCLoginDoesNotLinkUnverifiedAccount(t *testing.T) {
+	handler, mock, provider := newOIDCTestHandler(t)
+
+	req := signInWithOIDC(t, handler, mock, provider, oidctest.Identity{
+		Subject:       "abc123",
+		Email:         "alice@example.com",
+		EmailVerified: true,
+	})
+
+	mock.ExpectQuery("FROM user_identities").
+		WithArgs(provider.Issuer, "abc123").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("alice@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "alice", "alice@example.com", "hash", false, 0, "customer", false, false))
+	expectAuthEvent(mock, models.AuthEventOIDCLogin, models.AuthOutcomeFailure)
+
+	_, err := handler.CompleteOIDCLogin(context.Background(), req)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCompleteOIDCLoginRejectsReusedState(t *testing.T) {
+	handler, mock, _ := newOIDCTestHandler(t)
+
+	mock.ExpectQuery("DELETE FROM oidc_login_states").
+		WillReturnRows(sqlmock.NewRows([]string{"nonce", "code_verifier", "expires_at"}))
+
+	_, err := handler.CompleteOIDCLogin(context.Background(), &userpb.CompleteOIDCLoginRequest{State: "used", Code: "code"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Fatalf("Expected Unauthenticated, got %v", err)
+	}
+}
+
+func TestUsernameForIdentityKeepsCharactersWhole(t *testing.T) {
+	name := strings.Repeat("ü", maxUsernameLength+10)
+
+	username := usernameForIdentity(&oidc.Claims{PreferredUsername: name})
+	if !utf8.ValidString(username) {
+		t.Errorf("Expected a valid UTF-8 username, got %q", username)
+	}
+	if utf8.RuneCountInString(username) != maxUsernameLength {
+		t.Errorf("Expected %d characters, got %d", maxUsernameLength, utf8.RuneCountInString(username))
+	}
+}
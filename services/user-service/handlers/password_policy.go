@@ -0,0 +1,24 @@
+package handlers
+
+import (
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// checkPasswordPolicy validates a new password, reporting every problem as a
+// violation of field. email and username are the account's, which the
+// password may not contain.
+func (s *UserServiceServer) checkPasswordPolicy(field, newPassword, email, username string) error {
+	descriptions := s.cfg.PasswordPolicy.Check(newPassword, email, username)
+	if len(descriptions) == 0 {
+		return nil
+	}
+
+	var violations []*errdetails.BadRequest_FieldViolation
+	for _, description := range descriptions {
+		violations = append(violations, &errdetails.BadRequest_FieldViolation{
+			Field:       field,
+			Description: "password " + description,
+		})
+	}
+	return fieldViolationsError("password does not meet the requirements", violations)
+}
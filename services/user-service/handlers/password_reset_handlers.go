@@ -0,0 +1,107 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/notifier"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const passwordResetRequestedMessage = "If an account exists for this email, password reset instructions have been sent"
+
+func (s *UserServiceServer) RequestPasswordReset(ctx context.Context, req *userpb.RequestPasswordResetRequest) (*userpb.RequestPasswordResetResponse, error) {
+	if req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "email is required")
+	}
+
+	// The response is the same whether or not the account exists so the
+	// endpoint cannot be used to discover registered emails.
+	resp := &userpb.RequestPasswordResetResponse{
+		Message: passwordResetRequestedMessage,
+	}
+
+	user, err := s.repo.GetUserByEmail(ctx, req.Email)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return resp, nil
+		}
+		return nil, status.Errorf(codes.Internal, "failed to look up user: %v", err)
+	}
+
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate reset token")
+	}
+
+	resetToken := &models.PasswordResetToken{
+		UserID:    user.UserID,
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(s.cfg.PasswordResetTokenDuration),
+	}
+
+	err = s.repo.CreatePasswordResetToken(ctx, resetToken)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to create reset token: %v", err)
+	}
+
+	err = s.notifier.Send(ctx, notifier.Message{
+		To:      user.Email,
+		Subject: "Reset your password",
+		Body: fmt.Sprintf("Use the following token to reset your password. It expires in %s and can only be used once.\n\n%s",
+			s.cfg.PasswordResetTokenDuration, token),
+	})
+	// A failed send is only logged: an error here would tell the caller the
+	// account exists.
+	if err != nil {
+		log.Printf("Failed to send password reset to user %d: %v", user.UserID, err)
+	}
+
+	return resp, nil
+}
+
+func (s *UserServiceServer) ConfirmPasswordReset(ctx context.Context, req *userpb.ConfirmPasswordResetRequest) (*userpb.ConfirmPasswordResetResponse, error) {
+	if req.Token == "" || req.NewPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "token and new password are required")
+	}
+
+	// The token is only looked up here, to check the password against the
+	// account; ResetPassword consumes it.
+	user, err := s.repo.GetUserByResetToken(ctx, utils.HashToken(req.Token))
+	if err != nil {
+		if err == repository.ErrInvalidResetToken {
+			return nil, s.recordAuthFailure(ctx, models.AuthEventPasswordReset, 0, "", "invalid or expired reset token", status.Errorf(codes.InvalidArgument, "invalid or expired reset token"))
+		}
+		return nil, status.Errorf(codes.Internal, "failed to look up reset token: %v", err)
+	}
+
+	if err := s.checkPasswordPolicy("new_password", req.NewPassword, user.Email, user.Username); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password")
+	}
+
+	userID, err := s.repo.ResetPassword(ctx, utils.HashToken(req.Token), hashedPassword)
+	if err != nil {
+		if err == repository.ErrInvalidResetToken {
+			return nil, s.recordAuthFailure(ctx, models.AuthEventPasswordReset, 0, "", "invalid or expired reset token", status.Errorf(codes.InvalidArgument, "invalid or expired reset token"))
+		}
+		return nil, status.Errorf(codes.Internal, "failed to reset password: %v", err)
+	}
+
+	s.recordAuthEvent(ctx, models.AuthEventPasswordReset, userID, user.Email, models.AuthOutcomeSuccess, "")
+
+	return &userpb.ConfirmPasswordResetResponse{
+		Message: "Password reset successfully",
+	}, nil
+}
@@ -0,0 +1,188 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/notifier"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/password"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type recordingNotifier struct {
+	messages []notifier.Message
+}
+
+func (n *recordingNotifier) Send(ctx context.Context, msg notifier.Message) error {
+	n.messages = append(n.messages, msg)
+	return nil
+}
+
+// failingNotifier fails every send, like an unreachable mail server.
+type failingNotifier struct{}
+
+func (failingNotifier) Send(ctx context.Context, msg notifier.Message) error {
+	return errors.New("mail server unavailable")
+}
+
+func TestRequestPasswordResetHidesSendFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE password_reset_tokens SET used_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO password_reset_tokens").
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), failingNotifier{}, nil, testConfig)
+
+	resp, err := handler.RequestPasswordReset(context.Background(), &userpb.RequestPasswordResetRequest{Email: "test@example.com"})
+	if err != nil {
+		t.Fatalf("Expected the generic response when sending fails, got %v", err)
+	}
+	if resp.Message != passwordResetRequestedMessage {
+		t.Errorf("Unexpected message %q", resp.Message)
+	}
+}
+
+func TestPasswordReset(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE password_reset_tokens SET used_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO password_reset_tokens").
+		WithArgs(1, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow(1))
+	mock.ExpectCommit()
+
+	repo := repository.NewUserRepository(db)
+	sent := &recordingNotifier{}
+	handler := NewUserServiceServer(repo, sent, nil, testConfig)
+
+	_, err = handler.RequestPasswordReset(context.Background(), &userpb.RequestPasswordResetRequest{Email: "test@example.com"})
+	if err != nil {
+		t.Fatalf("RequestPasswordReset failed: %v", err)
+	}
+
+	if len(sent.messages) != 1 || sent.messages[0].To != "test@example.com" {
+		t.Fatalf("Expected one message to test@example.com, got %+v", sent.messages)
+	}
+	lines := strings.Split(sent.messages[0].Body, "\n")
+	token := lines[len(lines)-1]
+
+	mock.ExpectQuery("SELECT user_id FROM password_reset_tokens").
+		WithArgs(utils.HashToken(token)).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE password_reset_tokens SET used_at").
+		WithArgs(utils.HashToken(token)).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectExec("UPDATE users SET password_hash").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE sessions SET revoked_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	expectAuthEvent(mock, models.AuthEventPasswordReset, models.AuthOutcomeSuccess)
+
+	_, err = handler.ConfirmPasswordReset(context.Background(), &userpb.ConfirmPasswordResetRequest{Token: token, NewPassword: "new-password"})
+	if err != nil {
+		t.Fatalf("ConfirmPasswordReset failed: %v", err)
+	}
+
+	mock.ExpectQuery("SELECT user_id FROM password_reset_tokens").
+		WithArgs(utils.HashToken(token)).
+		WillReturnRows(sqlmock.NewRows(userColumns))
+	expectAuthEvent(mock, models.AuthEventPasswordReset, models.AuthOutcomeFailure)
+
+	_, err = handler.ConfirmPasswordReset(context.Background(), &userpb.ConfirmPasswordResetRequest{Token: token, NewPassword: "new-password"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument when reusing token, got %v", err)
+	}
+
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRequestPasswordResetUnknownEmail(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("missing@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns))
+
+	sent := &recordingNotifier{}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), sent, nil, testConfig)
+
+	resp, err := handler.RequestPasswordReset(context.Background(), &userpb.RequestPasswordResetRequest{Email: "missing@example.com"})
+	if err != nil {
+		t.Fatalf("RequestPasswordReset failed: %v", err)
+	}
+	if resp.Message != passwordResetRequestedMessage {
+		t.Errorf("Expected generic response, got %q", resp.Message)
+	}
+	if len(sent.messages) != 0 {
+		t.Errorf("Expected no notification, got %d", len(sent.messages))
+	}
+}
+
+func TestConfirmPasswordResetEnforcesPolicy(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id FROM password_reset_tokens").
+		WithArgs(utils.HashToken("reset-token")).
+		WillReturnRows(sqlmock.NewRows(userColumns).
+			AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+
+	cfg := testConfig
+	cfg.PasswordPolicy = password.Policy{MinLength: 8, Breached: password.DefaultBreachedList()}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, cfg)
+
+	_, err = handler.ConfirmPasswordReset(context.Background(), &userpb.ConfirmPasswordResetRequest{Token: "reset-token", NewPassword: "testuser99"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for a password containing the username, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
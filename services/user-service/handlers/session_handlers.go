@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// maxSessionFieldLength bounds client-supplied values stored on a session.
+const maxSessionFieldLength = 255
+
+// issueToken starts a session for the calling client and returns an access
+// token bound to it.
+func (s *UserServiceServer) issueToken(ctx context.Context, user *models.User) (string, error) {
+	session := s.newSession(ctx, user.UserID, auth.DeviceName(ctx), s.cfg.TokenDuration)
+
+	if err := s.repo.CreateSession(ctx, session); err != nil {
+		return "", err
+	}
+
+	s.recordAuthEvent(ctx, models.AuthEventTokenIssued, user.UserID, user.Email, models.AuthOutcomeSuccess, "session "+strconv.Itoa(session.SessionID))
+
+	return utils.GenerateJWT(user, session.SessionID, s.cfg.JWTSecretKey, s.cfg.TokenDuration)
+}
+
+// newSession describes a session for the calling client lasting duration.
+func (s *UserServiceServer) newSession(ctx context.Context, userID int, device string, duration time.Duration) *models.Session {
+	return &models.Session{
+		UserID:    userID,
+		IPAddress: auth.ClientIP(ctx, s.cfg.TrustedProxies),
+		UserAgent: truncate(auth.UserAgent(ctx), maxSessionFieldLength),
+		Device:    truncate(device, 100),
+		ExpiresAt: time.Now().Add(duration),
+	}
+}
+
+func (s *UserServiceServer) ListSessions(ctx context.Context, req *userpb.ListSessionsRequest) (*userpb.ListSessionsResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	sessions, err := s.repo.ListSessions(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list sessions: %v", err)
+	}
+
+	currentSessionID, _ := ctx.Value(auth.SessionIDKey).(int)
+
+	var sessionProtos []*userpb.Session
+	for _, session := range sessions {
+		sessionProto := mapSessionToProto(session)
+		sessionProto.Current = session.SessionID == currentSessionID
+		sessionProtos = append(sessionProtos, sessionProto)
+	}
+
+	return &userpb.ListSessionsResponse{
+		Sessions: sessionProtos,
+	}, nil
+}
+
+func (s *UserServiceServer) RevokeSession(ctx context.Context, req *userpb.RevokeSessionRequest) (*userpb.RevokeSessionResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	sessionID, err := strconv.Atoi(req.SessionId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid session ID")
+	}
+
+	err = s.repo.RevokeSession(ctx, userID, sessionID)
+	if err != nil {
+		if err == repository.ErrSessionNotFound {
+			return nil, status.Errorf(codes.NotFound, "session not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to revoke session: %v", err)
+	}
+
+	return &userpb.RevokeSessionResponse{
+		Message: "Session revoked successfully",
+	}, nil
+}
+
+// CheckSession reports whether a token is still valid: its session, if it
+// has one, is active, recording it as seen, and its token version is
+// current. Other services call it to honour revoked tokens.
+func (s *UserServiceServer) CheckSession(ctx context.Context, req *userpb.CheckSessionRequest) (*userpb.CheckSessionResponse, error) {
+	userID, err := strconv.Atoi(req.UserId)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+	}
+
+	if req.SessionId != "" {
+		sessionID, err := strconv.Atoi(req.SessionId)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid session ID")
+		}
+
+		err = s.repo.TouchSession(ctx, sessionID, userID)
+		if err != nil {
+			if err == repository.ErrSessionRevoked {
+				return nil, status.Errorf(codes.Unauthenticated, "session has been revoked")
+			}
+			return nil, status.Errorf(codes.Internal, "failed to check session: %v", err)
+		}
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Unauthenticated, "user not found")
+	}
+	if user.TokenVersion != int(req.TokenVersion) {
+		return nil, status.Errorf(codes.Unauthenticated, "token has been revoked")
+	}
+	if user.Disabled {
+		return nil, status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	return &userpb.CheckSessionResponse{
+		EmailVerified: user.EmailVerified,
+	}, nil
+}
+
+func mapSessionToProto(session *models.Session) *userpb.Session {
+	return &userpb.Session{
+		SessionId:  strconv.Itoa(session.SessionID),
+		IpAddress:  session.IPAddress,
+		UserAgent:  session.UserAgent,
+		Device:     session.Device,
+		CreatedAt:  session.CreatedAt.Format(time.RFC3339),
+		LastSeenAt: session.LastSeenAt.Format(time.RFC3339),
+		ExpiresAt:  session.ExpiresAt.Format(time.RFC3339),
+	}
+}
+
+// truncate cuts value to at most maxLength characters, which is how VARCHAR
+// columns count, without splitting a multi-byte character.
+func truncate(value string, maxLength int) string {
+	characters := 0
+	for i := range value {
+		if characters == maxLength {
+			return value[:i]
+		}
+		characters++
+	}
+	return value
+}
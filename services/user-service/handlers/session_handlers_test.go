@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+var sessionColumns = []string{"session_id", "user_id", "ip_address", "user_agent", "device", "created_at", "last_seen_at", "expires_at"}
+
+func TestIssueTokenRecordsSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	userAgent := "Mozilla/5.0 (iPhone; CPU iPhone OS 17_0 like Mac OS X)"
+	mock.ExpectQuery("INSERT INTO sessions").
+		WithArgs(1, "203.0.113.7", userAgent, "iPhone", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id", "created_at", "last_seen_at"}).AddRow(12, time.Now(), time.Now()))
+	mock.ExpectQuery("INSERT INTO auth_events").
+		WithArgs(models.AuthEventTokenIssued, 1, "", "203.0.113.7", userAgent, models.AuthOutcomeSuccess, "session 12").
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "created_at"}).AddRow(1, time.Now()))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig).(*UserServiceServer)
+	ctx := fromGateway(context.Background(), metadata.Pairs(
+		"x-forwarded-for", "203.0.113.7",
+		"user-agent", userAgent,
+	))
+
+	token, err := handler.issueToken(ctx, &models.User{UserID: 1, Role: models.RoleCustomer})
+	if err != nil {
+		t.Fatalf("issueToken failed: %v", err)
+	}
+
+	claims, err := auth.ValidateJWT(token, testConfig.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("Returned token is invalid: %v", err)
+	}
+	if claims.ID != "12" {
+		t.Errorf("Expected token for session 12, got %q", claims.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestListSessionsMarksCurrent(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	now := time.Now()
+	mock.ExpectQuery("FROM sessions").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(sessionColumns).
+			AddRow(12, 1, "203.0.113.7", "grpc-go/1.67.1", "API client", now, now, now.Add(time.Hour)).
+			AddRow(9, 1, "198.51.100.2", "Mozilla/5.0 (Windows NT 10.0)", "Windows", now, now, now.Add(time.Hour)))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+	ctx = context.WithValue(ctx, auth.SessionIDKey, 9)
+
+	resp, err := handler.ListSessions(ctx, &userpb.ListSessionsRequest{})
+	if err != nil {
+		t.Fatalf("ListSessions failed: %v", err)
+	}
+	if len(resp.Sessions) != 2 || resp.Sessions[0].Current || !resp.Sessions[1].Current {
+		t.Errorf("Expected only session 9 to be current, got %+v", resp.Sessions)
+	}
+}
+
+func TestRevokeSessionOfAnotherUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectExec("UPDATE sessions SET revoked_at").
+		WithArgs(15, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.RevokeSession(ctx, &userpb.RevokeSessionRequest{SessionId: "15"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound, got %v", err)
+	}
+}
+
+func TestCheckSessionRejectsOldTokenVersion(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at").
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(4))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "hash", true, 3, "customer", false, false))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	// The password has been changed since the token was issued at version 2.
+	_, err = handler.CheckSession(context.Background(), &userpb.CheckSessionRequest{SessionId: "4", UserId: "1", TokenVersion: 2})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCheckSessionRejectsDisabledUser(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("UPDATE sessions SET last_seen_at").
+		WithArgs(4, 1).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id"}).AddRow(4))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "hash", true, 2, "customer", false, true))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.CheckSession(context.Background(), &userpb.CheckSessionRequest{SessionId: "4", UserId: "1", TokenVersion: 2})
+	if status.Code(err) != codes.PermissionDenied {
+		t.Errorf("Expected PermissionDenied, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestCheckSessionWithoutSession(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	// Tokens from before sessions only have their token version checked.
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "hash", true, 3, "customer", false, false))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	resp, err := handler.CheckSession(context.Background(), &userpb.CheckSessionRequest{UserId: "1", TokenVersion: 3})
+	if err != nil {
+		t.Fatalf("CheckSession failed: %v", err)
+	}
+	if !resp.EmailVerified {
+		t.Errorf("Expected the email to be verified")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestTruncateKeepsCharactersWhole(t *testing.T) {
+	tests := []struct {
+		value     string
+		maxLength int
+		want      string
+	}{
+		{"Firefox", 10, "Firefox"},
+		{"Firefox", 4, "Fire"},
+		{"Zürich", 2, "Zü"},
+		{"日本語のブラウザ", 3, "日本語"},
+	}
+	for _, tt := range tests {
+		if got := truncate(tt.value, tt.maxLength); got != tt.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.value, tt.maxLength, got, tt.want)
+		}
+	}
+}
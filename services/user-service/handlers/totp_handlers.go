@@ -0,0 +1,287 @@
+package handlers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/totp"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	recoveryCodeCount    = 10
+	maxChallengeAttempts = 5
+)
+
+func (s *UserServiceServer) EnrollTOTP(ctx context.Context, req *userpb.EnrollTOTPRequest) (*userpb.EnrollTOTPResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if user.TOTPEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate secret")
+	}
+
+	encryptedSecret, err := utils.Encrypt(s.cfg.TOTPEncryptionKey, secret)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encrypt secret")
+	}
+
+	err = s.repo.SaveTOTPSecret(ctx, userID, encryptedSecret)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to save secret: %v", err)
+	}
+
+	return &userpb.EnrollTOTPResponse{
+		Secret:          secret,
+		ProvisioningUri: totp.ProvisioningURI(s.cfg.TOTPIssuer, user.Email, secret),
+	}, nil
+}
+
+func (s *UserServiceServer) ConfirmTOTP(ctx context.Context, req *userpb.ConfirmTOTPRequest) (*userpb.ConfirmTOTPResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "code is required")
+	}
+
+	credential, err := s.repo.GetTOTPCredential(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.FailedPrecondition, "no pending two-factor enrolment")
+	}
+
+	if credential.Confirmed {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is already enabled")
+	}
+
+	secret, err := utils.Decrypt(s.cfg.TOTPEncryptionKey, credential.EncryptedSecret)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to decrypt secret")
+	}
+
+	step, valid := totp.Validate(secret, req.Code, time.Now())
+	if !valid {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid code")
+	}
+
+	used, err := s.repo.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to record code use: %v", err)
+	}
+	if !used {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid code")
+	}
+
+	recoveryCodes, err := generateRecoveryCodes(recoveryCodeCount)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate recovery codes")
+	}
+
+	var hashes []string
+	for _, code := range recoveryCodes {
+		hashes = append(hashes, utils.HashToken(normalizeRecoveryCode(code)))
+	}
+
+	err = s.repo.EnableTOTP(ctx, userID, hashes)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to enable two-factor authentication: %v", err)
+	}
+
+	return &userpb.ConfirmTOTPResponse{
+		RecoveryCodes: recoveryCodes,
+	}, nil
+}
+
+func (s *UserServiceServer) DisableTOTP(ctx context.Context, req *userpb.DisableTOTPRequest) (*userpb.DisableTOTPResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Password == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "password and code are required")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if !user.TOTPEnabled {
+		return nil, status.Errorf(codes.FailedPrecondition, "two-factor authentication is not enabled")
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+	}
+
+	valid, err := s.verifySecondFactor(ctx, userID, req.Code)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify code: %v", err)
+	}
+	if !valid {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid code")
+	}
+
+	err = s.repo.DisableTOTP(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to disable two-factor authentication: %v", err)
+	}
+
+	return &userpb.DisableTOTPResponse{
+		Message: "Two-factor authentication disabled",
+	}, nil
+}
+
+func (s *UserServiceServer) VerifyTOTP(ctx context.Context, req *userpb.VerifyTOTPRequest) (*userpb.VerifyTOTPResponse, error) {
+	if req.ChallengeToken == "" || req.Code == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "challenge token and code are required")
+	}
+
+	challengeHash := utils.HashToken(req.ChallengeToken)
+
+	userID, err := s.repo.GetLoginChallenge(ctx, challengeHash, maxChallengeAttempts)
+	if err != nil {
+		if err == repository.ErrInvalidLoginChallenge {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to load challenge: %v", err)
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	// Wrong codes count against the account like wrong passwords, so that
+	// logging in again for a fresh challenge does not allow more guesses.
+	ip := auth.ForwardedClientIP(ctx, s.cfg.TrustedProxies)
+
+	locked, err := s.isLoginLocked(ctx, user.Email, ip)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts: %v", err)
+	}
+	if locked {
+		return nil, s.recordAuthFailure(ctx, models.AuthEventTOTPVerify, user.UserID, user.Email, "login locked", status.Errorf(codes.ResourceExhausted, "too many failed login attempts, try again later"))
+	}
+
+	valid, err := s.verifySecondFactor(ctx, userID, req.Code)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to verify code: %v", err)
+	}
+	if !valid {
+		if err := s.repo.RecordChallengeFailure(ctx, challengeHash); err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to record attempt: %v", err)
+		}
+		s.recordLoginFailure(ctx, user.Email, ip)
+		return nil, s.recordAuthFailure(ctx, models.AuthEventTOTPVerify, user.UserID, user.Email, "invalid code", status.Errorf(codes.Unauthenticated, "invalid code"))
+	}
+
+	err = s.repo.ConsumeLoginChallenge(ctx, challengeHash)
+	if err != nil {
+		if err == repository.ErrInvalidLoginChallenge {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid or expired challenge")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to consume challenge: %v", err)
+	}
+
+	// The account may have been disabled after the challenge was issued.
+	if user.Disabled {
+		return nil, s.recordAuthFailure(ctx, models.AuthEventTOTPVerify, user.UserID, user.Email, "account is disabled", status.Errorf(codes.PermissionDenied, "account is disabled"))
+	}
+
+	s.clearLoginFailures(ctx, user)
+
+	token, err := s.issueToken(ctx, user)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token")
+	}
+
+	s.recordAuthEvent(ctx, models.AuthEventTOTPVerify, user.UserID, user.Email, models.AuthOutcomeSuccess, "")
+
+	return &userpb.VerifyTOTPResponse{
+		Token: token,
+	}, nil
+}
+
+// createLoginChallenge issues the challenge LoginUser returns to accounts with
+// two-factor authentication enabled.
+func (s *UserServiceServer) createLoginChallenge(ctx context.Context, user *models.User) (string, error) {
+	token, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	err = s.repo.CreateLoginChallenge(ctx, &models.LoginChallenge{
+		UserID:        user.UserID,
+		ChallengeHash: utils.HashToken(token),
+		ExpiresAt:     time.Now().Add(s.cfg.TOTPChallengeDuration),
+	})
+	if err != nil {
+		return "", err
+	}
+	return token, nil
+}
+
+// verifySecondFactor accepts either a current TOTP code, which may only be
+// used once, or an unused recovery code.
+func (s *UserServiceServer) verifySecondFactor(ctx context.Context, userID int, code string) (bool, error) {
+	credential, err := s.repo.GetTOTPCredential(ctx, userID)
+	if err != nil || !credential.Confirmed {
+		return false, nil
+	}
+
+	secret, err := utils.Decrypt(s.cfg.TOTPEncryptionKey, credential.EncryptedSecret)
+	if err != nil {
+		return false, err
+	}
+
+	if step, valid := totp.Validate(secret, code, time.Now()); valid {
+		return s.repo.UseTOTPStep(ctx, userID, step)
+	}
+
+	return s.repo.UseRecoveryCode(ctx, userID, utils.HashToken(normalizeRecoveryCode(code)))
+}
+
+func generateRecoveryCodes(n int) ([]string, error) {
+	var recoveryCodes []string
+	for i := 0; i < n; i++ {
+		b := make([]byte, 8)
+		if _, err := rand.Read(b); err != nil {
+			return nil, err
+		}
+		code := hex.EncodeToString(b)
+		recoveryCodes = append(recoveryCodes, code[0:4]+"-"+code[4:8]+"-"+code[8:12]+"-"+code[12:16])
+	}
+	return recoveryCodes, nil
+}
+
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+}
@@ -0,0 +1,175 @@
+package handlers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/totp"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestLoginWithTOTP(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	secret, _ := totp.GenerateSecret()
+	encryptedSecret, _ := utils.Encrypt(testConfig.TOTPEncryptionKey, secret)
+	passwordHash, _ := utils.HashPassword("password123")
+
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("test@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 0, "admin", true, false))
+	mock.ExpectExec("INSERT INTO login_challenges").
+		WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuthEvent(mock, models.AuthEventLogin, models.AuthOutcomeSuccess)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	loginResp, err := handler.LoginUser(context.Background(), &userpb.LoginUserRequest{Email: "test@example.com", Password: "password123"})
+	if err != nil {
+		t.Fatalf("LoginUser failed: %v", err)
+	}
+	if !loginResp.TotpRequired || loginResp.Token != "" || loginResp.ChallengeToken == "" {
+		t.Fatalf("Expected a TOTP challenge instead of a token, got %+v", loginResp)
+	}
+
+	now := time.Now()
+	code, _ := totp.Code(secret, totp.Step(now))
+	challengeHash := utils.HashToken(loginResp.ChallengeToken)
+
+	mock.ExpectQuery("SELECT user_id FROM login_challenges").
+		WithArgs(challengeHash, maxChallengeAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 0, "admin", true, false))
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, encrypted_secret").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "encrypted_secret", "confirmed", "last_used_step"}).AddRow(1, encryptedSecret, true, 0))
+	mock.ExpectExec("UPDATE totp_credentials SET last_used_step").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE login_challenges SET used_at").
+		WithArgs(challengeHash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("DELETE FROM login_failures").
+		WithArgs("email:test@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectSession(mock, 1, 1)
+	expectAuthEvent(mock, models.AuthEventTOTPVerify, models.AuthOutcomeSuccess)
+
+	verifyResp, err := handler.VerifyTOTP(context.Background(), &userpb.VerifyTOTPRequest{ChallengeToken: loginResp.ChallengeToken, Code: code})
+	if err != nil {
+		t.Fatalf("VerifyTOTP failed: %v", err)
+	}
+	if verifyResp.Token == "" {
+		t.Errorf("Expected a token after completing the challenge")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestVerifyTOTPInvalidCodeCountsAsLoginFailure(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	secret, _ := totp.GenerateSecret()
+	encryptedSecret, _ := utils.Encrypt(testConfig.TOTPEncryptionKey, secret)
+	challengeHash := utils.HashToken("challenge")
+
+	mock.ExpectQuery("SELECT user_id FROM login_challenges").
+		WithArgs(challengeHash, maxChallengeAttempts).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "", true, 0, "customer", true, false))
+	mock.ExpectQuery("SELECT EXISTS").
+		WillReturnRows(sqlmock.NewRows([]string{"exists"}).AddRow(false))
+	mock.ExpectQuery("SELECT user_id, encrypted_secret").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "encrypted_secret", "confirmed", "last_used_step"}).AddRow(1, encryptedSecret, true, 0))
+	mock.ExpectExec("UPDATE totp_recovery_codes SET used_at").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("UPDATE login_challenges SET failed_attempts").
+		WithArgs(challengeHash).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("INSERT INTO login_failures").
+		WithArgs("email:test@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"failed_attempts", "lockout_count"}).AddRow(1, 0))
+	expectAuthEvent(mock, models.AuthEventTOTPVerify, models.AuthOutcomeFailure)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+
+	_, err = handler.VerifyTOTP(context.Background(), &userpb.VerifyTOTPRequest{ChallengeToken: "challenge", Code: "not-a-code"})
+	if status.Code(err) != codes.Unauthenticated {
+		t.Errorf("Expected Unauthenticated for an invalid code, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestConfirmTOTPRejectsReusedCode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	secret, _ := totp.GenerateSecret()
+	encryptedSecret, _ := utils.Encrypt(testConfig.TOTPEncryptionKey, secret)
+	now := time.Now()
+	code, _ := totp.Code(secret, totp.Step(now))
+
+	mock.ExpectQuery("SELECT user_id, encrypted_secret").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "encrypted_secret", "confirmed", "last_used_step"}).AddRow(1, encryptedSecret, false, 0))
+	mock.ExpectExec("UPDATE totp_credentials SET last_used_step").
+		WithArgs(1, sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.ConfirmTOTP(ctx, &userpb.ConfirmTOTPRequest{Code: code})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a reused code, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestRecoveryCodeNormalization(t *testing.T) {
+	codes, err := generateRecoveryCodes(2)
+	if err != nil {
+		t.Fatalf("generateRecoveryCodes failed: %v", err)
+	}
+	if len(codes) != 2 || codes[0] == codes[1] {
+		t.Fatalf("Expected two distinct codes, got %v", codes)
+	}
+	if normalizeRecoveryCode("ABCD-ef01 2345-6789") != "abcdef0123456789" {
+		t.Errorf("Unexpected normalization result")
+	}
+}
@@ -0,0 +1,207 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// UserDataService is another service that stores personal data about users.
+// Data-subject requests are fanned out to each one, acting on the user named
+// by the forwarded authorization header.
+type UserDataService interface {
+	// Name is the key the service's data is stored under in an export.
+	Name() string
+	ExportUserData(ctx context.Context) ([]byte, error)
+	EraseUserData(ctx context.Context) error
+}
+
+// maxExportedAuthEvents caps the sign-in history included in an export.
+const maxExportedAuthEvents = 1000
+
+// userDataExport is the archive returned by ExportMyData.
+type userDataExport struct {
+	ExportedAt       string                     `json:"exported_at"`
+	User             json.RawMessage            `json:"user"`
+	TwoFactorEnabled bool                       `json:"two_factor_enabled"`
+	Addresses        []json.RawMessage          `json:"addresses"`
+	LinkedIdentities []exportedIdentity         `json:"linked_identities"`
+	AuthEvents       []exportedAuthEvent        `json:"auth_events"`
+	Services         map[string]json.RawMessage `json:"services"`
+}
+
+type exportedIdentity struct {
+	Issuer   string `json:"issuer"`
+	Subject  string `json:"subject"`
+	Email    string `json:"email"`
+	LinkedAt string `json:"linked_at"`
+}
+
+type exportedAuthEvent struct {
+	EventType string `json:"event_type"`
+	Email     string `json:"email"`
+	IPAddress string `json:"ip_address"`
+	UserAgent string `json:"user_agent"`
+	Outcome   string `json:"outcome"`
+	Reason    string `json:"reason"`
+	CreatedAt string `json:"created_at"`
+}
+
+func (s *UserServiceServer) ExportMyData(ctx context.Context, req *userpb.ExportMyDataRequest) (*userpb.ExportMyDataResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	addresses, err := s.repo.ListAddresses(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list addresses: %v", err)
+	}
+
+	identities, err := s.repo.ListIdentities(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list linked identities: %v", err)
+	}
+
+	events, err := s.repo.ListAuthEvents(ctx, models.AuthEventFilter{UserID: userID, Limit: maxExportedAuthEvents})
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to list auth events: %v", err)
+	}
+
+	export := &userDataExport{
+		ExportedAt:       time.Now().UTC().Format(time.RFC3339),
+		TwoFactorEnabled: user.TOTPEnabled,
+		Addresses:        []json.RawMessage{},
+		LinkedIdentities: []exportedIdentity{},
+		AuthEvents:       []exportedAuthEvent{},
+		Services:         make(map[string]json.RawMessage),
+	}
+
+	for _, identity := range identities {
+		export.LinkedIdentities = append(export.LinkedIdentities, exportedIdentity{
+			Issuer:   identity.Issuer,
+			Subject:  identity.Subject,
+			Email:    identity.Email,
+			LinkedAt: identity.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	for _, event := range events {
+		export.AuthEvents = append(export.AuthEvents, exportedAuthEvent{
+			EventType: event.EventType,
+			Email:     event.Email,
+			IPAddress: event.IPAddress,
+			UserAgent: event.UserAgent,
+			Outcome:   event.Outcome,
+			Reason:    event.Reason,
+			CreatedAt: event.CreatedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	export.User, err = protojson.Marshal(mapUserToProto(user))
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode user: %v", err)
+	}
+
+	for _, address := range addresses {
+		data, err := protojson.Marshal(mapAddressToProto(address))
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to encode address: %v", err)
+		}
+		export.Addresses = append(export.Addresses, data)
+	}
+
+	outgoingCtx := auth.ForwardAuthorization(ctx)
+	for _, service := range s.dataServices {
+		data, err := service.ExportUserData(outgoingCtx)
+		if err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to export %s data: %v", service.Name(), err)
+		}
+		if !json.Valid(data) {
+			return nil, status.Errorf(codes.Internal, "invalid %s data", service.Name())
+		}
+		export.Services[service.Name()] = data
+	}
+
+	archive, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode export: %v", err)
+	}
+
+	return &userpb.ExportMyDataResponse{
+		Archive: archive,
+	}, nil
+}
+
+// DeleteMyAccount erases the caller's data in every other service before
+// deleting the account itself, so that a failed request can be retried with
+// the same credentials.
+func (s *UserServiceServer) DeleteMyAccount(ctx context.Context, req *userpb.DeleteMyAccountRequest) (*userpb.DeleteMyAccountResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Password == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "password is required")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+	}
+
+	if user.TOTPEnabled {
+		if req.Code == "" {
+			return nil, status.Errorf(codes.InvalidArgument, "code is required when two-factor authentication is enabled")
+		}
+
+		valid, err := s.verifySecondFactor(ctx, userID, req.Code)
+		if err != nil {
+			return nil, status.Errorf(codes.Internal, "failed to verify code: %v", err)
+		}
+		if !valid {
+			return nil, status.Errorf(codes.Unauthenticated, "invalid code")
+		}
+	}
+
+	outgoingCtx := auth.ForwardAuthorization(ctx)
+	for _, service := range s.dataServices {
+		if err := service.EraseUserData(outgoingCtx); err != nil {
+			return nil, status.Errorf(codes.Unavailable, "failed to erase %s data: %v", service.Name(), err)
+		}
+	}
+
+	err = s.repo.DeleteUser(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to delete account: %v", err)
+	}
+
+	if err := s.repo.ClearLoginFailures(ctx, emailFailureKey(user.Email)); err != nil {
+		log.Printf("Failed to clear login failures for deleted user %d: %v", userID, err)
+	}
+
+	return &userpb.DeleteMyAccountResponse{
+		Message: "Account deleted successfully",
+	}, nil
+}
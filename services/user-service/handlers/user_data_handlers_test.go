@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type fakeDataService struct {
+	name     string
+	data     string
+	eraseErr error
+	erased   bool
+}
+
+func (s *fakeDataService) Name() string {
+	return s.name
+}
+
+func (s *fakeDataService) ExportUserData(ctx context.Context) ([]byte, error) {
+	return []byte(s.data), nil
+}
+
+func (s *fakeDataService) EraseUserData(ctx context.Context) error {
+	if s.eraseErr != nil {
+		return s.eraseErr
+	}
+	s.erased = true
+	return nil
+}
+
+func TestExportMyData(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("FROM addresses").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"address_id", "user_id", "full_name", "line1", "line2", "city", "region", "postcode", "country", "phone", "is_default_shipping", "is_default_billing"}).
+			AddRow(7, 1, "Test User", "1 Main St", "", "Springfield", "IL", "62701", "US", "", true, false))
+	mock.ExpectQuery("FROM user_identities").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows([]string{"issuer", "subject", "email", "created_at"}))
+	mock.ExpectQuery("FROM auth_events").
+		WithArgs(1, maxExportedAuthEvents).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "event_type", "user_id", "email", "ip_address", "user_agent", "outcome", "reason", "created_at"}).
+			AddRow(4, models.AuthEventLogin, 1, "test@example.com", "203.0.113.7", "grpc-go", models.AuthOutcomeSuccess, "", time.Now()))
+
+	cart := &fakeDataService{name: "cart", data: `{"items":[{"productId":"3","quantity":2}]}`}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, []UserDataService{cart}, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	resp, err := handler.ExportMyData(ctx, &userpb.ExportMyDataRequest{})
+	if err != nil {
+		t.Fatalf("ExportMyData failed: %v", err)
+	}
+
+	var archive struct {
+		User struct {
+			Email string `json:"email"`
+		} `json:"user"`
+		Addresses []struct {
+			Line1 string `json:"line1"`
+		} `json:"addresses"`
+		AuthEvents []struct {
+			IPAddress string `json:"ip_address"`
+		} `json:"auth_events"`
+		Services map[string]struct {
+			Items []json.RawMessage `json:"items"`
+		} `json:"services"`
+	}
+	if err := json.Unmarshal(resp.Archive, &archive); err != nil {
+		t.Fatalf("Archive is not valid JSON: %v", err)
+	}
+	if archive.User.Email != "test@example.com" {
+		t.Errorf("Expected user email in archive, got %q", archive.User.Email)
+	}
+	if len(archive.Addresses) != 1 || archive.Addresses[0].Line1 != "1 Main St" {
+		t.Errorf("Unexpected addresses in archive: %+v", archive.Addresses)
+	}
+	if len(archive.AuthEvents) != 1 || archive.AuthEvents[0].IPAddress != "203.0.113.7" {
+		t.Errorf("Unexpected auth events in archive: %+v", archive.AuthEvents)
+	}
+	if len(archive.Services["cart"].Items) != 1 {
+		t.Errorf("Expected cart data in archive, got %+v", archive.Services)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeleteMyAccount(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	passwordHash, _ := utils.HashPassword("password")
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 0, "customer", false, false))
+	// The personal data in the auth log is erased along with the account.
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT erase_user_auth_events\\(\\$1\\)").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec("DELETE FROM users").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectExec("DELETE FROM login_failures").
+		WithArgs("email:test@example.com").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	cart := &fakeDataService{name: "cart"}
+	orders := &fakeDataService{name: "orders"}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, []UserDataService{cart, orders}, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.DeleteMyAccount(ctx, &userpb.DeleteMyAccountRequest{Password: "password"})
+	if err != nil {
+		t.Fatalf("DeleteMyAccount failed: %v", err)
+	}
+	if !cart.erased || !orders.erased {
+		t.Errorf("Expected data to be erased in every service")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeleteMyAccountKeepsAccountWhenEraseFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	passwordHash, _ := utils.HashPassword("password")
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 0, "customer", false, false))
+
+	orders := &fakeDataService{name: "orders", eraseErr: errors.New("connection refused")}
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, []UserDataService{orders}, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.DeleteMyAccount(ctx, &userpb.DeleteMyAccountRequest{Password: "password"})
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("Expected Unavailable, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestDeleteMyAccountKeepsAccountWhenAuthLogEraseFails(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	passwordHash, _ := utils.HashPassword("password")
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 0, "customer", false, false))
+	mock.ExpectBegin()
+	mock.ExpectExec("SELECT erase_user_auth_events").
+		WithArgs(1).
+		WillReturnError(errors.New("auth_events is append-only"))
+	mock.ExpectRollback()
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.DeleteMyAccount(ctx, &userpb.DeleteMyAccountRequest{Password: "password"})
+	if status.Code(err) != codes.Internal {
+		t.Fatalf("Expected Internal, got %v", err)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
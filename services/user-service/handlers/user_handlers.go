@@ -2,10 +2,16 @@ package handlers
 
 import (
 	"context"
+	"log"
+	"net"
 	"strconv"
 	"time"
 
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/notifier"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/password"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
@@ -13,19 +19,70 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+// Config holds the settings UserServiceServer needs beyond its dependencies.
+type Config struct {
+	JWTSecretKey                 string
+	TokenDuration                time.Duration
+	PasswordResetTokenDuration   time.Duration
+	VerificationTokenDuration    time.Duration
+	RequireVerifiedEmailForLogin bool
+
+	// TrustedProxies are the peers whose x-forwarded-for header is believed
+	// when recording client addresses.
+	TrustedProxies []*net.IPNet
+
+	MaxLoginAttempts        int
+	MaxLoginAttemptsPerIP   int
+	LoginFailureWindow      time.Duration
+	LoginLockoutDuration    time.Duration
+	MaxLoginLockoutDuration time.Duration
+	// LoginLockoutResetAfter is how long without failures it takes for
+	// lockouts to start again from LoginLockoutDuration.
+	LoginLockoutResetAfter time.Duration
+
+	TOTPEncryptionKey     []byte
+	TOTPIssuer            string
+	TOTPChallengeDuration time.Duration
+
+	// OIDC login is disabled when OIDCIssuerURL is empty.
+	OIDCIssuerURL     string
+	OIDCClientID      string
+	OIDCClientSecret  string
+	OIDCRedirectURL   string
+	OIDCStateDuration time.Duration
+
+	ImpersonationTokenDuration time.Duration
+
+	PasswordPolicy password.Policy
+}
+
 type UserServiceServer struct {
 	userpb.UnimplementedUserServiceServer
-	repo          repository.UserRepository
-	jwtSecretKey  string
-	tokenDuration time.Duration
+	repo         repository.UserRepository
+	notifier     notifier.Notifier
+	dataServices []UserDataService
+	oidc         *oidc.Provider
+	cfg          Config
 }
 
-func NewUserServiceServer(repo repository.UserRepository, jwtSecretKey string, tokenDuration time.Duration) userpb.UserServiceServer {
-	return &UserServiceServer{
-		repo:          repo,
-		jwtSecretKey:  jwtSecretKey,
-		tokenDuration: tokenDuration,
+func NewUserServiceServer(repo repository.UserRepository, notifier notifier.Notifier, dataServices []UserDataService, cfg Config) userpb.UserServiceServer {
+	server := &UserServiceServer{
+		repo:         repo,
+		notifier:     notifier,
+		dataServices: dataServices,
+		cfg:          cfg,
+	}
+
+	if cfg.OIDCIssuerURL != "" {
+		server.oidc = oidc.NewProvider(oidc.Config{
+			IssuerURL:    cfg.OIDCIssuerURL,
+			ClientID:     cfg.OIDCClientID,
+			ClientSecret: cfg.OIDCClientSecret,
+			RedirectURL:  cfg.OIDCRedirectURL,
+		})
 	}
+
+	return server
 }
 
 func (s *UserServiceServer) RegisterUser(ctx context.Context, req *userpb.RegisterUserRequest) (*userpb.RegisterUserResponse, error) {
@@ -33,9 +90,13 @@ func (s *UserServiceServer) RegisterUser(ctx context.Context, req *userpb.Regist
 		return nil, status.Errorf(codes.InvalidArgument, "all fields are required")
 	}
 
+	if err := s.checkPasswordPolicy("password", req.Password, req.Email, req.Username); err != nil {
+		return nil, err
+	}
+
 	_, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err == nil {
-		return nil, status.Errorf(codes.AlreadyExists, "user already exists")
+		return nil, s.recordAuthFailure(ctx, models.AuthEventRegister, 0, req.Email, "user already exists", status.Errorf(codes.AlreadyExists, "user already exists"))
 	}
 
 	hashedPassword, err := utils.HashPassword(req.Password)
@@ -54,11 +115,16 @@ func (s *UserServiceServer) RegisterUser(ctx context.Context, req *userpb.Regist
 		return nil, status.Errorf(codes.Internal, "failed to create user: %v", err)
 	}
 
+	s.recordAuthEvent(ctx, models.AuthEventRegister, user.UserID, user.Email, models.AuthOutcomeSuccess, "")
+
+	// The account already exists at this point, so a delivery failure is only
+	// logged; the user can ask for a new link with SendVerification.
+	if err := s.sendVerification(ctx, user); err != nil {
+		log.Printf("Failed to send verification email to user %d: %v", user.UserID, err)
+	}
+
 	return &userpb.RegisterUserResponse{
-		User: &userpb.User{
-			Username: user.Username,
-			Email:    user.Email,
-		},
+		User: mapUserToProto(user),
 	}, nil
 }
 
@@ -67,30 +133,83 @@ func (s *UserServiceServer) LoginUser(ctx context.Context, req *userpb.LoginUser
 		return nil, status.Errorf(codes.InvalidArgument, "email and password are required")
 	}
 
+	ip := auth.ForwardedClientIP(ctx, s.cfg.TrustedProxies)
+
+	locked, err := s.isLoginLocked(ctx, req.Email, ip)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check login attempts: %v", err)
+	}
+	if locked {
+		return nil, s.recordAuthFailure(ctx, models.AuthEventLogin, 0, req.Email, "login locked", status.Errorf(codes.ResourceExhausted, "too many failed login attempts, try again later"))
+	}
+
+	// Unknown emails and wrong passwords produce the same error so the
+	// endpoint does not reveal which accounts exist.
 	user, err := s.repo.GetUserByEmail(ctx, req.Email)
 	if err != nil {
-		return nil, status.Errorf(codes.NotFound, "user not found")
+		if err.Error() != "user not found" {
+			return nil, status.Errorf(codes.Internal, "failed to look up user: %v", err)
+		}
+		utils.CheckPasswordHash(req.Password, dummyPasswordHash)
+		s.recordLoginFailure(ctx, req.Email, ip)
+		return nil, s.recordAuthFailure(ctx, models.AuthEventLogin, 0, req.Email, "unknown email", status.Errorf(codes.Unauthenticated, "invalid credentials"))
 	}
 
 	if !utils.CheckPasswordHash(req.Password, user.PasswordHash) {
-		return nil, status.Errorf(codes.Unauthenticated, "invalid credentials")
+		s.recordLoginFailure(ctx, req.Email, ip)
+		return nil, s.recordAuthFailure(ctx, models.AuthEventLogin, user.UserID, user.Email, "invalid password", status.Errorf(codes.Unauthenticated, "invalid credentials"))
 	}
 
-	token, err := utils.GenerateJWT(user.UserID, s.jwtSecretKey, s.tokenDuration)
-	if err != nil {
-		return nil, status.Errorf(codes.Internal, "failed to generate token")
+	token, challenge, err := s.completeLogin(ctx, user)
+	if err := s.recordLoginOutcome(ctx, models.AuthEventLogin, user, challenge, err); err != nil {
+		return nil, err
+	}
+
+	// Accounts with two-factor authentication keep their failures until
+	// VerifyTOTP accepts the second factor.
+	if challenge == "" {
+		s.clearLoginFailures(ctx, user)
 	}
 
 	return &userpb.LoginUserResponse{
-		Token: token,
+		Token:          token,
+		TotpRequired:   challenge != "",
+		ChallengeToken: challenge,
 	}, nil
 }
 
-func (s *UserServiceServer) GetUserProfile(ctx context.Context, req *userpb.GetUserProfileRequest) (*userpb.GetUserProfileResponse, error) {
-	userIDStr := ctx.Value("userID").(string)
-	userID, err := strconv.Atoi(userIDStr)
+// completeLogin applies the login policies to a user whose primary
+// credentials have been checked. It returns either an access token or, when
+// two-factor authentication is enabled, a challenge token for VerifyTOTP.
+func (s *UserServiceServer) completeLogin(ctx context.Context, user *models.User) (string, string, error) {
+	if user.Disabled {
+		return "", "", status.Errorf(codes.PermissionDenied, "account is disabled")
+	}
+
+	if s.cfg.RequireVerifiedEmailForLogin && !user.EmailVerified {
+		return "", "", status.Errorf(codes.FailedPrecondition, "email address has not been verified")
+	}
+
+	if user.TOTPEnabled {
+		challenge, err := s.createLoginChallenge(ctx, user)
+		if err != nil {
+			return "", "", status.Errorf(codes.Internal, "failed to create login challenge: %v", err)
+		}
+		return "", challenge, nil
+	}
+
+	token, err := s.issueToken(ctx, user)
 	if err != nil {
-		return nil, status.Errorf(codes.InvalidArgument, "invalid user ID")
+		return "", "", status.Errorf(codes.Internal, "failed to generate token")
+	}
+	return token, "", nil
+}
+
+func (s *UserServiceServer) GetUserProfile(ctx context.Context, req *userpb.GetUserProfileRequest) (*userpb.GetUserProfileResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
 	}
 
 	user, err := s.repo.GetUserByID(ctx, userID)
@@ -99,9 +218,123 @@ func (s *UserServiceServer) GetUserProfile(ctx context.Context, req *userpb.GetU
 	}
 
 	return &userpb.GetUserProfileResponse{
-		User: &userpb.User{
-			Username: user.Username,
-			Email:    user.Email,
-		},
+		User: mapUserToProto(user),
+	}, nil
+}
+
+func (s *UserServiceServer) UpdateUserProfile(ctx context.Context, req *userpb.UpdateUserProfileRequest) (*userpb.UpdateUserProfileResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.Username == "" && req.Email == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "username or email is required")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if req.Username != "" {
+		user.Username = req.Username
+	}
+
+	emailChanged := req.Email != "" && req.Email != user.Email
+	if emailChanged {
+		existing, err := s.repo.GetUserByEmail(ctx, req.Email)
+		if err == nil && existing.UserID != user.UserID {
+			return nil, status.Errorf(codes.AlreadyExists, "email already in use")
+		}
+
+		// A new address has to be verified again before it is trusted.
+		user.Email = req.Email
+		user.EmailVerified = false
+	}
+
+	err = s.repo.UpdateUser(ctx, user)
+	if err != nil {
+		if err == repository.ErrEmailTaken {
+			return nil, status.Errorf(codes.AlreadyExists, "email already in use")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to update user: %v", err)
+	}
+
+	if emailChanged {
+		if err := s.sendVerification(ctx, user); err != nil {
+			log.Printf("Failed to send verification email to user %d: %v", user.UserID, err)
+		}
+	}
+
+	return &userpb.UpdateUserProfileResponse{
+		User: mapUserToProto(user),
+	}, nil
+}
+
+func (s *UserServiceServer) ChangePassword(ctx context.Context, req *userpb.ChangePasswordRequest) (*userpb.ChangePasswordResponse, error) {
+	userID, ok := ctx.Value(auth.UserIDKey).(int)
+
+	if !ok {
+		return nil, status.Errorf(codes.Unauthenticated, "invalid user ID")
+	}
+
+	if req.CurrentPassword == "" || req.NewPassword == "" {
+		return nil, status.Errorf(codes.InvalidArgument, "current and new password are required")
+	}
+
+	user, err := s.repo.GetUserByID(ctx, userID)
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "user not found")
+	}
+
+	if !utils.CheckPasswordHash(req.CurrentPassword, user.PasswordHash) {
+		return nil, s.recordAuthFailure(ctx, models.AuthEventPasswordChange, user.UserID, user.Email, "invalid current password", status.Errorf(codes.Unauthenticated, "invalid credentials"))
+	}
+
+	if err := s.checkPasswordPolicy("new_password", req.NewPassword, user.Email, user.Username); err != nil {
+		return nil, err
+	}
+
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to hash password")
+	}
+
+	user.TokenVersion, err = s.repo.UpdatePassword(ctx, user.UserID, hashedPassword)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to update password: %v", err)
+	}
+
+	s.recordAuthEvent(ctx, models.AuthEventPasswordChange, user.UserID, user.Email, models.AuthOutcomeSuccess, "")
+
+	token, err := s.issueToken(ctx, user)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to generate token")
+	}
+
+	return &userpb.ChangePasswordResponse{
+		Message: "Password changed successfully",
+		Token:   token,
 	}, nil
 }
+
+func mapUserToProto(user *models.User) *userpb.User {
+	return &userpb.User{
+		UserId:        strconv.Itoa(user.UserID),
+		Username:      user.Username,
+		Email:         user.Email,
+		EmailVerified: user.EmailVerified,
+		Role:          user.Role,
+		Disabled:      user.Disabled,
+	}
+}
+
+func requireAdmin(ctx context.Context) error {
+	role, _ := ctx.Value(auth.RoleKey).(string)
+	if role != models.RoleAdmin {
+		return status.Errorf(codes.PermissionDenied, "admin access required")
+	}
+	return nil
+}
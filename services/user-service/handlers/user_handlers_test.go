@@ -2,14 +2,66 @@ package handlers
 
 import (
 	"context"
+	"net"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/password"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/repository"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/userpb"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 )
 
+var userColumns = []string{"user_id", "username", "email", "password_hash", "email_verified", "token_version", "role", "totp_enabled", "disabled"}
+
+var testConfig = Config{
+	JWTSecretKey:               "test-secret-key",
+	TokenDuration:              24 * time.Hour,
+	PasswordResetTokenDuration: time.Hour,
+	VerificationTokenDuration:  24 * time.Hour,
+	MaxLoginAttempts:           5,
+	MaxLoginAttemptsPerIP:      20,
+	LoginFailureWindow:         15 * time.Minute,
+	LoginLockoutDuration:       time.Minute,
+	MaxLoginLockoutDuration:    time.Hour,
+	LoginLockoutResetAfter:     24 * time.Hour,
+	TOTPEncryptionKey:          utils.DeriveEncryptionKey("test-totp-key"),
+	TOTPIssuer:                 "Test",
+	TOTPChallengeDuration:      5 * time.Minute,
+	TrustedProxies:             []*net.IPNet{{IP: net.IPv4(10, 0, 0, 0).To4(), Mask: net.CIDRMask(8, 32)}},
+}
+
+// fromGateway returns ctx as received from the API gateway, a trusted proxy
+// in testConfig, carrying md.
+func fromGateway(ctx context.Context, md metadata.MD) context.Context {
+	ctx = peer.NewContext(ctx, &peer.Peer{Addr: &net.TCPAddr{IP: net.IPv4(10, 0, 0, 2), Port: 40000}})
+	return metadata.NewIncomingContext(ctx, md)
+}
+
+// expectSession sets up the session created whenever a token is issued, and
+// the auth event recording it.
+func expectSession(mock sqlmock.Sqlmock, userID, sessionID int) {
+	mock.ExpectQuery("INSERT INTO sessions").
+		WithArgs(userID, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"session_id", "created_at", "last_seen_at"}).AddRow(sessionID, time.Now(), time.Now()))
+	expectAuthEvent(mock, models.AuthEventTokenIssued, models.AuthOutcomeSuccess)
+}
+
+// expectAuthEvent sets up an entry in the auth event log.
+func expectAuthEvent(mock sqlmock.Sqlmock, eventType, outcome string) {
+	mock.ExpectQuery("INSERT INTO auth_events").
+		WithArgs(eventType, sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), sqlmock.AnyArg(), outcome, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"event_id", "created_at"}).AddRow(1, time.Now()))
+}
+
 func TestRegisterUser(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -19,10 +71,19 @@ func TestRegisterUser(t *testing.T) {
 
 	mock.ExpectQuery("INSERT INTO users").
 		WithArgs("testuser", "test@example.com", sqlmock.AnyArg()).
-		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow(1))
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "role"}).AddRow(1, "customer"))
+	expectAuthEvent(mock, models.AuthEventRegister, models.AuthOutcomeSuccess)
+	mock.ExpectBegin()
+	mock.ExpectExec("UPDATE email_verification_tokens SET used_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery("INSERT INTO email_verification_tokens").
+		WithArgs(1, "test@example.com", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"token_id"}).AddRow(1))
+	mock.ExpectCommit()
 
 	repo := repository.NewUserRepository(db)
-	handler := NewUserServiceServer(repo, "test-secret-key", 24*time.Hour)
+	handler := NewUserServiceServer(repo, &recordingNotifier{}, nil, testConfig)
 
 	req := &userpb.RegisterUserRequest{
 		Username: "testuser",
@@ -42,3 +103,99 @@ func TestRegisterUser(t *testing.T) {
 		t.Errorf("Unfulfilled expectations: %v", err)
 	}
 }
+
+func TestChangePassword(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	passwordHash, _ := utils.HashPassword("old-password")
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", passwordHash, true, 2, "customer", false, false))
+	// The password and the user's sessions change together.
+	mock.ExpectBegin()
+	mock.ExpectQuery("UPDATE users SET password_hash").
+		WithArgs(sqlmock.AnyArg(), 1).
+		WillReturnRows(sqlmock.NewRows([]string{"token_version"}).AddRow(3))
+	mock.ExpectExec("UPDATE sessions SET revoked_at").
+		WithArgs(1).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+	expectAuthEvent(mock, models.AuthEventPasswordChange, models.AuthOutcomeSuccess)
+	expectSession(mock, 1, 4)
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	resp, err := handler.ChangePassword(ctx, &userpb.ChangePasswordRequest{CurrentPassword: "old-password", NewPassword: "new-password"})
+	if err != nil {
+		t.Fatalf("ChangePassword failed: %v", err)
+	}
+
+	claims, err := auth.ValidateJWT(resp.Token, testConfig.JWTSecretKey)
+	if err != nil {
+		t.Fatalf("Returned token is invalid: %v", err)
+	}
+	if claims.TokenVersion != 3 || claims.ID != "4" {
+		t.Errorf("Expected token version 3 for session 4, got %d for session %q", claims.TokenVersion, claims.ID)
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Errorf("Unfulfilled expectations: %v", err)
+	}
+}
+
+func TestUpdateUserProfileEmailTaken(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatalf("Failed to create sqlmock: %v", err)
+	}
+	defer db.Close()
+
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs(1).
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(1, "testuser", "test@example.com", "hash", true, 0, "customer", false, false))
+	mock.ExpectQuery("SELECT user_id, username, email, password_hash").
+		WithArgs("taken@example.com").
+		WillReturnRows(sqlmock.NewRows(userColumns).AddRow(2, "other", "taken@example.com", "hash", true, 0, "customer", false, false))
+
+	handler := NewUserServiceServer(repository.NewUserRepository(db), &recordingNotifier{}, nil, testConfig)
+	ctx := context.WithValue(context.Background(), auth.UserIDKey, 1)
+
+	_, err = handler.UpdateUserProfile(ctx, &userpb.UpdateUserProfileRequest{Email: "taken@example.com"})
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Expected AlreadyExists, got %v", err)
+	}
+}
+
+func TestRegisterUserPasswordPolicy(t *testing.T) {
+	cfg := testConfig
+	cfg.PasswordPolicy = password.Policy{
+		MinLength:           10,
+		MinCharacterClasses: 3,
+		Breached:            password.DefaultBreachedList(),
+	}
+	handler := NewUserServiceServer(nil, &recordingNotifier{}, nil, cfg)
+
+	_, err := handler.RegisterUser(context.Background(), &userpb.RegisterUserRequest{
+		Username: "testuser",
+		Email:    "test@example.com",
+		Password: "password1",
+	})
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument || len(st.Details()) != 1 {
+		t.Fatalf("Expected InvalidArgument with details, got %v", err)
+	}
+	badRequest := st.Details()[0].(*errdetails.BadRequest)
+	if len(badRequest.FieldViolations) != 3 {
+		t.Errorf("Expected length, character class and breached violations, got %v", badRequest.FieldViolations)
+	}
+	for _, violation := range badRequest.FieldViolations {
+		if violation.Field != "password" {
+			t.Errorf("Unexpected field %q", violation.Field)
+		}
+	}
+}
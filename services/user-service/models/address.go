@@ -0,0 +1,16 @@
+package models
+
+type Address struct {
+	AddressID         int
+	UserID            int
+	FullName          string
+	Line1             string
+	Line2             string
+	City              string
+	Region            string
+	Postcode          string
+	Country           string
+	Phone             string
+	IsDefaultShipping bool
+	IsDefaultBilling  bool
+}
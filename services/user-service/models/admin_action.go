@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+const (
+	AdminActionDisableUser  = "disable_user"
+	AdminActionEnableUser   = "enable_user"
+	AdminActionImpersonate  = "impersonate_user"
+	AdminActionUnlockUser   = "unlock_user"
+	AdminActionCreateAPIKey = "create_api_key"
+	AdminActionRevokeAPIKey = "revoke_api_key"
+)
+
+type AdminAction struct {
+	ActionID     int
+	AdminUserID  int
+	Action       string
+	TargetUserID int
+	Reason       string
+	CreatedAt    time.Time
+}
@@ -0,0 +1,40 @@
+package models
+
+import "time"
+
+// Scopes that can be granted to an API key. Each service maps the methods
+// API keys may call to one of these.
+const (
+	ScopeProfileRead    = "profile:read"
+	ScopeAddressesRead  = "addresses:read"
+	ScopeAddressesWrite = "addresses:write"
+	ScopeCartRead       = "cart:read"
+	ScopeCartWrite      = "cart:write"
+	ScopeOrdersRead     = "orders:read"
+	ScopeOrdersWrite    = "orders:write"
+	ScopeAdmin          = "admin"
+)
+
+var APIKeyScopes = []string{
+	ScopeProfileRead,
+	ScopeAddressesRead,
+	ScopeAddressesWrite,
+	ScopeCartRead,
+	ScopeCartWrite,
+	ScopeOrdersRead,
+	ScopeOrdersWrite,
+	ScopeAdmin,
+}
+
+type APIKey struct {
+	KeyID      int
+	UserID     int
+	Name       string
+	Prefix     string
+	KeyHash    string
+	Scopes     []string
+	CreatedAt  time.Time
+	ExpiresAt  *time.Time
+	LastUsedAt *time.Time
+	RevokedAt  *time.Time
+}
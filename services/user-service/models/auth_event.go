@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+const (
+	AuthEventRegister       = "register"
+	AuthEventLogin          = "login"
+	AuthEventTOTPVerify     = "totp_verify"
+	AuthEventOIDCLogin      = "oidc_login"
+	AuthEventTokenIssued    = "token_issued"
+	AuthEventPasswordChange = "password_change"
+	AuthEventPasswordReset  = "password_reset"
+
+	AuthOutcomeSuccess = "success"
+	AuthOutcomeFailure = "failure"
+)
+
+type AuthEvent struct {
+	EventID   int64
+	EventType string
+	// UserID is nil when the event could not be tied to an account.
+	UserID    *int
+	Email     string
+	IPAddress string
+	UserAgent string
+	Outcome   string
+	Reason    string
+	CreatedAt time.Time
+}
+
+// AuthEventFilter selects auth events. Zero values match everything.
+type AuthEventFilter struct {
+	UserID        int
+	Since         time.Time
+	Until         time.Time
+	BeforeEventID int64
+	Limit         int
+}
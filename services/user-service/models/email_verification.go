@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// EmailVerificationToken proves ownership of Email. The address is stored with
+// the token so that a token issued before an email change cannot verify the
+// new address.
+type EmailVerificationToken struct {
+	TokenID   int
+	UserID    int
+	Email     string
+	TokenHash string
+	ExpiresAt time.Time
+}
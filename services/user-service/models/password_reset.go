@@ -0,0 +1,10 @@
+package models
+
+import "time"
+
+type PasswordResetToken struct {
+	TokenID   int
+	UserID    int
+	TokenHash string
+	ExpiresAt time.Time
+}
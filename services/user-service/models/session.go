@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+type Session struct {
+	SessionID  int
+	UserID     int
+	IPAddress  string
+	UserAgent  string
+	Device     string
+	CreatedAt  time.Time
+	LastSeenAt time.Time
+	ExpiresAt  time.Time
+}
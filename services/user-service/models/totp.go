@@ -0,0 +1,16 @@
+package models
+
+import "time"
+
+type TOTPCredential struct {
+	UserID          int
+	EncryptedSecret string
+	Confirmed       bool
+	LastUsedStep    int64
+}
+
+type LoginChallenge struct {
+	UserID        int
+	ChallengeHash string
+	ExpiresAt     time.Time
+}
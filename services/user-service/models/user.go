@@ -1,8 +1,18 @@
 package models
 
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+)
+
 type User struct {
-	UserID       int
-	Username     string
-	Email        string
-	PasswordHash string
+	UserID        int
+	Username      string
+	Email         string
+	PasswordHash  string
+	EmailVerified bool
+	TokenVersion  int
+	Role          string
+	TOTPEnabled   bool
+	Disabled      bool
 }
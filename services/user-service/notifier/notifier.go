@@ -0,0 +1,34 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message is a notification addressed to a single user, such as a password
+// reset email.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Notifier delivers messages to users. Production deployments are expected to
+// plug in an email or SMS provider; the log and file implementations are
+// stand-ins for local development.
+type Notifier interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// New returns the notifier selected by kind ("log" or "file"). filePath is only
+// used by the file notifier.
+func New(kind, filePath string) (Notifier, error) {
+	switch kind {
+	case "", "log":
+		return NewLogNotifier(), nil
+	case "file":
+		return NewFileNotifier(filePath), nil
+	default:
+		return nil, fmt.Errorf("unknown notifier %q", kind)
+	}
+}
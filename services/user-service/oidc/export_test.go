@@ -0,0 +1,7 @@
+package oidc
+
+import "context"
+
+func VerifyIDTokenForTest(p *Provider, idToken, nonce string) (*Claims, error) {
+	return p.verifyIDToken(context.Background(), idToken, nonce)
+}
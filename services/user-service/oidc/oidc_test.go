@@ -0,0 +1,103 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc/oidctest"
+)
+
+const redirectURL = "https://shop.example.com/oidc/callback"
+
+func startProvider(t *testing.T) (*oidctest.Provider, *oidc.Provider) {
+	t.Helper()
+
+	mock, err := oidctest.Start("shop", "shop-secret")
+	if err != nil {
+		t.Fatalf("Failed to start mock provider: %v", err)
+	}
+	t.Cleanup(mock.Close)
+
+	provider := oidc.NewProvider(oidc.Config{
+		IssuerURL:    mock.Issuer,
+		ClientID:     "shop",
+		ClientSecret: "shop-secret",
+		RedirectURL:  redirectURL,
+	})
+	return mock, provider
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	mock, provider := startProvider(t)
+	ctx := context.Background()
+
+	authURL, err := provider.AuthCodeURL(ctx, "state", "nonce", "verifier-verifier-verifier-verifier-verifier")
+	if err != nil {
+		t.Fatalf("AuthCodeURL failed: %v", err)
+	}
+
+	u, _ := url.Parse(authURL)
+	if u.Query().Get("redirect_uri") != redirectURL || u.Query().Get("state") != "state" {
+		t.Errorf("Unexpected authorization URL: %s", authURL)
+	}
+
+	code, err := mock.Authorize(authURL, oidctest.Identity{Subject: "42", Email: "alice@example.com", EmailVerified: true})
+	if err != nil {
+		t.Fatalf("Authorize failed: %v", err)
+	}
+
+	claims, err := provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+	if err != nil {
+		t.Fatalf("Exchange failed: %v", err)
+	}
+	if claims.Subject != "42" || claims.Email != "alice@example.com" || !claims.EmailVerified {
+		t.Errorf("Unexpected claims: %+v", claims)
+	}
+
+	// Codes are single use.
+	_, err = provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "nonce")
+	if !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken on code reuse, got %v", err)
+	}
+}
+
+func TestExchangeRejectsWrongVerifierAndNonce(t *testing.T) {
+	mock, provider := startProvider(t)
+	ctx := context.Background()
+	identity := oidctest.Identity{Subject: "42", Email: "alice@example.com"}
+
+	authURL, _ := provider.AuthCodeURL(ctx, "state", "nonce", "verifier-verifier-verifier-verifier-verifier")
+	code, _ := mock.Authorize(authURL, identity)
+	if _, err := provider.Exchange(ctx, code, "another-verifier-another-verifier-another", "nonce"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for wrong code verifier, got %v", err)
+	}
+
+	code, _ = mock.Authorize(authURL, identity)
+	if _, err := provider.Exchange(ctx, code, "verifier-verifier-verifier-verifier-verifier", "other-nonce"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken for wrong nonce, got %v", err)
+	}
+}
+
+func TestExchangeRejectsTokenFromOtherProvider(t *testing.T) {
+	_, provider := startProvider(t)
+
+	other, err := oidctest.New("shop", "shop-secret")
+	if err != nil {
+		t.Fatalf("Failed to create provider: %v", err)
+	}
+	other.Issuer = provider.Issuer()
+
+	// Same issuer and key ID, but signed with a different key.
+	idToken, err := other.SignIDToken(oidctest.Identity{Subject: "42"}, "nonce", time.Hour)
+	if err != nil {
+		t.Fatalf("SignIDToken failed: %v", err)
+	}
+
+	if _, err := oidc.VerifyIDTokenForTest(provider, idToken, "nonce"); !errors.Is(err, oidc.ErrInvalidToken) {
+		t.Errorf("Expected ErrInvalidToken, got %v", err)
+	}
+}
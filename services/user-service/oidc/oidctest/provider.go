@@ -0,0 +1,271 @@
+// Package oidctest provides a minimal OpenID Connect provider for tests and
+// local development. It signs in any identity it is asked to, so it must
+// never be used in production.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/oidc"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/utils"
+)
+
+const keyID = "oidctest"
+
+// Identity is the account a user signs in to at the mock provider.
+type Identity struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+}
+
+type authorization struct {
+	identity      Identity
+	clientID      string
+	redirectURI   string
+	nonce         string
+	codeChallenge string
+}
+
+// Provider is an OpenID Connect provider serving discovery, authorization,
+// token and key set endpoints.
+type Provider struct {
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+
+	key    *rsa.PrivateKey
+	server *httptest.Server
+
+	mu    sync.Mutex
+	codes map[string]authorization
+}
+
+// New returns a provider for a single client. Issuer must be set to the URL
+// the provider is served at before it is used.
+func New(clientID, clientSecret string) (*Provider, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Provider{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		key:          key,
+		codes:        make(map[string]authorization),
+	}, nil
+}
+
+// Start returns a provider served on a local test server. Call Close when
+// done.
+func Start(clientID, clientSecret string) (*Provider, error) {
+	p, err := New(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	p.server = httptest.NewServer(p)
+	p.Issuer = p.server.URL
+	return p, nil
+}
+
+func (p *Provider) Close() {
+	if p.server != nil {
+		p.server.Close()
+	}
+}
+
+// Authorize signs identity in for the given authorization URL, as if the user
+// had completed the login page, and returns the authorization code the
+// provider would redirect back with.
+func (p *Provider) Authorize(authURL string, identity Identity) (string, error) {
+	u, err := url.Parse(authURL)
+	if err != nil {
+		return "", err
+	}
+	return p.authorize(u.Query(), identity)
+}
+
+func (p *Provider) authorize(params url.Values, identity Identity) (string, error) {
+	if params.Get("response_type") != "code" {
+		return "", fmt.Errorf("unsupported response_type %q", params.Get("response_type"))
+	}
+	if params.Get("client_id") != p.ClientID {
+		return "", fmt.Errorf("unknown client %q", params.Get("client_id"))
+	}
+	if params.Get("code_challenge_method") != "S256" {
+		return "", fmt.Errorf("unsupported code_challenge_method %q", params.Get("code_challenge_method"))
+	}
+
+	code, err := utils.GenerateToken()
+	if err != nil {
+		return "", err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.codes[code] = authorization{
+		identity:      identity,
+		clientID:      params.Get("client_id"),
+		redirectURI:   params.Get("redirect_uri"),
+		nonce:         params.Get("nonce"),
+		codeChallenge: params.Get("code_challenge"),
+	}
+	return code, nil
+}
+
+func (p *Provider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch r.URL.Path {
+	case "/.well-known/openid-configuration":
+		writeJSON(w, http.StatusOK, map[string]string{
+			"issuer":                 p.Issuer,
+			"authorization_endpoint": p.Issuer + "/authorize",
+			"token_endpoint":         p.Issuer + "/token",
+			"jwks_uri":               p.Issuer + "/jwks",
+		})
+	case "/authorize":
+		p.handleAuthorize(w, r)
+	case "/token":
+		p.handleToken(w, r)
+	case "/jwks":
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"kid": keyID,
+				"use": "sig",
+				"alg": "RS256",
+				"n":   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+			}},
+		})
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// handleAuthorize signs in the email given as login_hint without asking for
+// credentials and redirects straight back to the client.
+func (p *Provider) handleAuthorize(w http.ResponseWriter, r *http.Request) {
+	params := r.URL.Query()
+
+	email := params.Get("login_hint")
+	if email == "" {
+		http.Error(w, "login_hint is required", http.StatusBadRequest)
+		return
+	}
+
+	code, err := p.authorize(params, Identity{
+		Subject:       "mock|" + email,
+		Email:         email,
+		EmailVerified: true,
+	})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	redirect, err := url.Parse(params.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	query := redirect.Query()
+	query.Set("code", code)
+	query.Set("state", params.Get("state"))
+	redirect.RawQuery = query.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (p *Provider) handleToken(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	clientID, clientSecret, ok := r.BasicAuth()
+	if ok {
+		clientID, _ = url.QueryUnescape(clientID)
+		clientSecret, _ = url.QueryUnescape(clientSecret)
+	} else {
+		clientID, clientSecret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if clientID != p.ClientID || clientSecret != p.ClientSecret {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+
+	if r.PostFormValue("grant_type") != "authorization_code" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "unsupported_grant_type"})
+		return
+	}
+
+	// Codes are single use, whether or not the exchange succeeds.
+	p.mu.Lock()
+	auth, ok := p.codes[r.PostFormValue("code")]
+	delete(p.codes, r.PostFormValue("code"))
+	p.mu.Unlock()
+
+	if !ok || auth.clientID != clientID || auth.redirectURI != r.PostFormValue("redirect_uri") ||
+		oidc.CodeChallenge(r.PostFormValue("code_verifier")) != auth.codeChallenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	idToken, err := p.SignIDToken(auth.identity, auth.nonce, time.Hour)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	accessToken, err := utils.GenerateToken()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   3600,
+		"id_token":     idToken,
+	})
+}
+
+// SignIDToken returns an ID token for identity issued to the provider's
+// client.
+func (p *Provider) SignIDToken(identity Identity, nonce string, duration time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, jwt.MapClaims{
+		"iss":                p.Issuer,
+		"sub":                identity.Subject,
+		"aud":                p.ClientID,
+		"iat":                now.Unix(),
+		"exp":                now.Add(duration).Unix(),
+		"nonce":              nonce,
+		"email":              identity.Email,
+		"email_verified":     identity.EmailVerified,
+		"preferred_username": identity.PreferredUsername,
+	})
+	token.Header["kid"] = keyID
+	return token.SignedString(p.key)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,87 @@
+package password
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+)
+
+// prefixLength is the length of the hash prefix the list is partitioned by,
+// as in the Have I Been Pwned range API.
+const prefixLength = 5
+
+//go:embed breached.txt
+var bundledList string
+
+var (
+	defaultList     *BreachedList
+	defaultListOnce sync.Once
+)
+
+// BreachedList holds SHA-1 hashes of known breached passwords, grouped by
+// hash prefix. Lookups only ever ask for the range matching a prefix, so the
+// list can be swapped for a remote range service without changing callers.
+type BreachedList struct {
+	ranges map[string]map[string]bool
+}
+
+// DefaultBreachedList returns the list of common passwords bundled with the
+// service.
+func DefaultBreachedList() *BreachedList {
+	defaultListOnce.Do(func() {
+		list, err := LoadBreachedList(strings.NewReader(bundledList))
+		if err != nil {
+			panic(fmt.Sprintf("invalid bundled breached password list: %v", err))
+		}
+		defaultList = list
+	})
+	return defaultList
+}
+
+// LoadBreachedList reads upper or lower case hex SHA-1 hashes, one per line.
+// Anything after a colon is ignored, so the downloadable Have I Been Pwned
+// "hash:count" files can be used directly.
+func LoadBreachedList(r io.Reader) (*BreachedList, error) {
+	list := &BreachedList{ranges: make(map[string]map[string]bool)}
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		hash, _, _ := strings.Cut(strings.TrimSpace(scanner.Text()), ":")
+		if hash == "" {
+			continue
+		}
+
+		hash = strings.ToUpper(hash)
+		if _, err := hex.DecodeString(hash); err != nil || len(hash) != 2*sha1.Size {
+			return nil, fmt.Errorf("line %d: not a SHA-1 hash", line)
+		}
+
+		prefix, suffix := hash[:prefixLength], hash[prefixLength:]
+		if list.ranges[prefix] == nil {
+			list.ranges[prefix] = make(map[string]bool)
+		}
+		list.ranges[prefix][suffix] = true
+	}
+
+	return list, scanner.Err()
+}
+
+// Range returns the hash suffixes of the breached passwords whose hash
+// starts with prefix.
+func (l *BreachedList) Range(prefix string) map[string]bool {
+	return l.ranges[strings.ToUpper(prefix)]
+}
+
+// Contains reports whether password is on the list.
+func (l *BreachedList) Contains(password string) bool {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+	return l.Range(hash[:prefixLength])[hash[prefixLength:]]
+}
@@ -0,0 +1,124 @@
+// Package password checks new passwords against a configurable policy and a
+// list of known breached passwords.
+package password
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// MaxBcryptLength is the number of bytes bcrypt uses; anything after it is
+// silently ignored, so longer passwords are rejected rather than truncated.
+const MaxBcryptLength = 72
+
+// minPersonalInfoLength is the shortest email or username part that a
+// password is checked for, so short names do not rule out most passwords.
+const minPersonalInfoLength = 3
+
+type Policy struct {
+	MinLength int
+	// MaxLength is in bytes and never exceeds MaxBcryptLength.
+	MaxLength int
+	// MinCharacterClasses is how many of lower case letters, upper case
+	// letters, digits and symbols a password must mix.
+	MinCharacterClasses int
+	// Breached is consulted when set.
+	Breached *BreachedList
+}
+
+// Check returns a description of every way password violates the policy.
+// personalInfo holds the user's email address and username, which the
+// password may not contain.
+func (p Policy) Check(password string, personalInfo ...string) []string {
+	var violations []string
+
+	if utf8.RuneCountInString(password) < p.MinLength {
+		violations = append(violations, fmt.Sprintf("must be at least %d characters long", p.MinLength))
+	}
+
+	maxLength := p.MaxLength
+	if maxLength <= 0 || maxLength > MaxBcryptLength {
+		maxLength = MaxBcryptLength
+	}
+	if len(password) > maxLength {
+		violations = append(violations, fmt.Sprintf("must be at most %d bytes long", maxLength))
+	}
+
+	if classes := characterClasses(password); classes < p.MinCharacterClasses {
+		violations = append(violations, fmt.Sprintf("must mix at least %d of lower case letters, upper case letters, digits and symbols", p.MinCharacterClasses))
+	}
+
+	lower := strings.ToLower(password)
+	for _, info := range personalInfo {
+		for _, part := range personalInfoParts(info) {
+			if strings.Contains(lower, part) {
+				violations = append(violations, "must not contain your email address or username")
+				break
+			}
+		}
+	}
+
+	if p.Breached != nil && p.Breached.Contains(password) {
+		violations = append(violations, "is a commonly used or breached password")
+	}
+
+	return dedupe(violations)
+}
+
+func characterClasses(password string) int {
+	var lower, upper, digit, symbol bool
+	for _, r := range password {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		default:
+			symbol = true
+		}
+	}
+
+	count := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			count++
+		}
+	}
+	return count
+}
+
+// personalInfoParts splits an email address into its local part and domain
+// name, and returns those long enough to check, lower-cased.
+func personalInfoParts(info string) []string {
+	info = strings.ToLower(info)
+
+	candidates := []string{info}
+	if local, domain, ok := strings.Cut(info, "@"); ok {
+		name, _, _ := strings.Cut(domain, ".")
+		candidates = []string{local, name}
+	}
+
+	var parts []string
+	for _, candidate := range candidates {
+		if len(candidate) >= minPersonalInfoLength {
+			parts = append(parts, candidate)
+		}
+	}
+	return parts
+}
+
+func dedupe(values []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, value := range values {
+		if !seen[value] {
+			seen[value] = true
+			result = append(result, value)
+		}
+	}
+	return result
+}
@@ -0,0 +1,71 @@
+package password
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyCheck(t *testing.T) {
+	policy := Policy{
+		MinLength:           10,
+		MinCharacterClasses: 3,
+		Breached:            DefaultBreachedList(),
+	}
+
+	cases := []struct {
+		password   string
+		violations int
+	}{
+		{"Correct-Horse-7", 0},
+		{"short1A", 1},
+		{"alllowercaseletters", 1},
+		{"Password123", 1},
+		{"Alice-Smith-2024", 1},
+		{strings.Repeat("Ab1-", 19), 1},
+		{"abc", 2},
+	}
+
+	for _, tc := range cases {
+		violations := policy.Check(tc.password, "alice.smith@example.com", "alice")
+		if len(violations) != tc.violations {
+			t.Errorf("Check(%q) = %v, expected %d violations", tc.password, violations, tc.violations)
+		}
+	}
+}
+
+func TestPersonalInfoParts(t *testing.T) {
+	parts := personalInfoParts("Jo@Example.com")
+	if len(parts) != 1 || parts[0] != "example" {
+		t.Errorf("Expected only the domain name to be checked, got %v", parts)
+	}
+}
+
+func TestLoadBreachedList(t *testing.T) {
+	// SHA-1 of "password", in the Have I Been Pwned download format.
+	list, err := LoadBreachedList(strings.NewReader("5baa61e4c9b93f3f0682250b6cf8331b7ee68fd8:9545824\n\n"))
+	if err != nil {
+		t.Fatalf("LoadBreachedList failed: %v", err)
+	}
+	if !list.Contains("password") || list.Contains("Password") {
+		t.Errorf("Unexpected lookup results")
+	}
+	if len(list.Range("5baa6")) != 1 {
+		t.Errorf("Expected one suffix in range 5BAA6")
+	}
+
+	if _, err := LoadBreachedList(strings.NewReader("not-a-hash\n")); err == nil {
+		t.Errorf("Expected an error for a malformed line")
+	}
+}
+
+func TestDefaultBreachedList(t *testing.T) {
+	list := DefaultBreachedList()
+	for _, password := range []string{"123456", "qwerty", "P@ssw0rd"} {
+		if !list.Contains(password) {
+			t.Errorf("Expected %q to be on the bundled list", password)
+		}
+	}
+	if list.Contains("Correct-Horse-7") {
+		t.Errorf("Did not expect a random password on the bundled list")
+	}
+}
@@ -0,0 +1,198 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var ErrAddressNotFound = errors.New("address not found")
+
+const addressColumns = `address_id, user_id, full_name, line1, line2, city, region, postcode, country, phone, is_default_shipping, is_default_billing`
+
+func scanAddress(row rowScanner) (*models.Address, error) {
+	address := &models.Address{}
+	err := row.Scan(
+		&address.AddressID,
+		&address.UserID,
+		&address.FullName,
+		&address.Line1,
+		&address.Line2,
+		&address.City,
+		&address.Region,
+		&address.Postcode,
+		&address.Country,
+		&address.Phone,
+		&address.IsDefaultShipping,
+		&address.IsDefaultBilling,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, ErrAddressNotFound
+	}
+	return address, err
+}
+
+func (r *userRepository) CreateAddress(ctx context.Context, address *models.Address) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := clearDefaultAddresses(ctx, tx, address); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		INSERT INTO addresses (user_id, full_name, line1, line2, city, region, postcode, country, phone, is_default_shipping, is_default_billing)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11)
+		RETURNING address_id
+	`
+	err = tx.QueryRowContext(ctx, query,
+		address.UserID,
+		address.FullName,
+		address.Line1,
+		address.Line2,
+		address.City,
+		address.Region,
+		address.Postcode,
+		address.Country,
+		address.Phone,
+		address.IsDefaultShipping,
+		address.IsDefaultBilling,
+	).Scan(&address.AddressID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) GetAddress(ctx context.Context, userID, addressID int) (*models.Address, error) {
+	query := `
+		SELECT ` + addressColumns + `
+		FROM addresses
+		WHERE address_id = $1 AND user_id = $2
+	`
+
+	return scanAddress(r.db.QueryRowContext(ctx, query, addressID, userID))
+}
+
+func (r *userRepository) ListAddresses(ctx context.Context, userID int) ([]*models.Address, error) {
+	query := `
+		SELECT ` + addressColumns + `
+		FROM addresses
+		WHERE user_id = $1
+		ORDER BY address_id
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var addresses []*models.Address
+	for rows.Next() {
+		address, err := scanAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		addresses = append(addresses, address)
+	}
+
+	return addresses, rows.Err()
+}
+
+func (r *userRepository) UpdateAddress(ctx context.Context, address *models.Address) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := clearDefaultAddresses(ctx, tx, address); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	query := `
+		UPDATE addresses
+		SET full_name = $1, line1 = $2, line2 = $3, city = $4, region = $5, postcode = $6,
+			country = $7, phone = $8, is_default_shipping = $9, is_default_billing = $10
+		WHERE address_id = $11 AND user_id = $12
+	`
+	result, err := tx.ExecContext(ctx, query,
+		address.FullName,
+		address.Line1,
+		address.Line2,
+		address.City,
+		address.Region,
+		address.Postcode,
+		address.Country,
+		address.Phone,
+		address.IsDefaultShipping,
+		address.IsDefaultBilling,
+		address.AddressID,
+		address.UserID,
+	)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return ErrAddressNotFound
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) DeleteAddress(ctx context.Context, userID, addressID int) error {
+	query := `
+		DELETE FROM addresses WHERE address_id = $1 AND user_id = $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, addressID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrAddressNotFound
+	}
+
+	return nil
+}
+
+// clearDefaultAddresses unsets the user's other default shipping and billing
+// addresses when address is about to take over either role.
+func clearDefaultAddresses(ctx context.Context, tx *sql.Tx, address *models.Address) error {
+	if address.IsDefaultShipping {
+		query := `
+			UPDATE addresses SET is_default_shipping = FALSE
+			WHERE user_id = $1 AND address_id <> $2 AND is_default_shipping
+		`
+		if _, err := tx.ExecContext(ctx, query, address.UserID, address.AddressID); err != nil {
+			return err
+		}
+	}
+
+	if address.IsDefaultBilling {
+		query := `
+			UPDATE addresses SET is_default_billing = FALSE
+			WHERE user_id = $1 AND address_id <> $2 AND is_default_billing
+		`
+		if _, err := tx.ExecContext(ctx, query, address.UserID, address.AddressID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+const insertAdminActionQuery = `
+	INSERT INTO admin_actions (admin_user_id, action, target_user_id, reason)
+	VALUES ($1, $2, $3, $4)
+	RETURNING action_id, created_at
+`
+
+// SearchUsers returns users whose email or username starts with query,
+// ignoring case, ordered by user ID and starting after afterUserID.
+func (r *userRepository) SearchUsers(ctx context.Context, query string, afterUserID, limit int) ([]*models.User, error) {
+	sqlQuery := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE (LOWER(email) LIKE $1 OR LOWER(username) LIKE $1) AND user_id > $2
+		ORDER BY user_id
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, sqlQuery, escapeLikePattern(strings.ToLower(query))+"%", afterUserID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []*models.User
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, user)
+	}
+
+	return users, rows.Err()
+}
+
+// SetUserDisabled disables or re-enables a user and records the admin action
+// that did so. Disabling also revokes the user's sessions.
+func (r *userRepository) SetUserDisabled(ctx context.Context, userID int, disabled bool, action *models.AdminAction) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users SET disabled_at = CASE WHEN $1 THEN COALESCE(disabled_at, NOW()) END
+		WHERE user_id = $2
+	`
+
+	result, err := tx.ExecContext(ctx, query, disabled, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	if disabled {
+		query = `
+			UPDATE sessions SET revoked_at = NOW()
+			WHERE user_id = $1 AND revoked_at IS NULL
+		`
+
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			return err
+		}
+	}
+
+	err = tx.QueryRowContext(ctx, insertAdminActionQuery, action.AdminUserID, action.Action, action.TargetUserID, action.Reason).
+		Scan(&action.ActionID, &action.CreatedAt)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) RecordAdminAction(ctx context.Context, action *models.AdminAction) error {
+	return r.db.QueryRowContext(ctx, insertAdminActionQuery, action.AdminUserID, action.Action, action.TargetUserID, action.Reason).
+		Scan(&action.ActionID, &action.CreatedAt)
+}
+
+// ListAdminActions returns the actions taken on a user, most recent first.
+func (r *userRepository) ListAdminActions(ctx context.Context, targetUserID int) ([]*models.AdminAction, error) {
+	query := `
+		SELECT action_id, admin_user_id, action, target_user_id, reason, created_at
+		FROM admin_actions
+		WHERE target_user_id = $1
+		ORDER BY action_id DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, targetUserID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var actions []*models.AdminAction
+	for rows.Next() {
+		action := &models.AdminAction{}
+		err := rows.Scan(
+			&action.ActionID,
+			&action.AdminUserID,
+			&action.Action,
+			&action.TargetUserID,
+			&action.Reason,
+			&action.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		actions = append(actions, action)
+	}
+
+	return actions, rows.Err()
+}
+
+// escapeLikePattern escapes the LIKE wildcards in s so it matches literally.
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
@@ -0,0 +1,88 @@
+package repository
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+func (r *userRepository) RecordAuthEvent(ctx context.Context, event *models.AuthEvent) error {
+	query := `
+		INSERT INTO auth_events (event_type, user_id, email, ip_address, user_agent, outcome, reason)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING event_id, created_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		event.EventType,
+		event.UserID,
+		event.Email,
+		event.IPAddress,
+		event.UserAgent,
+		event.Outcome,
+		event.Reason,
+	).Scan(&event.EventID, &event.CreatedAt)
+}
+
+// ListAuthEvents returns the events matching filter, most recent first.
+func (r *userRepository) ListAuthEvents(ctx context.Context, filter models.AuthEventFilter) ([]*models.AuthEvent, error) {
+	var conditions []string
+	var args []interface{}
+	addCondition := func(condition string, arg interface{}) {
+		args = append(args, arg)
+		conditions = append(conditions, strings.Replace(condition, "?", "$"+strconv.Itoa(len(args)), 1))
+	}
+
+	if filter.UserID != 0 {
+		addCondition("user_id = ?", filter.UserID)
+	}
+	if !filter.Since.IsZero() {
+		addCondition("created_at >= ?", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		addCondition("created_at < ?", filter.Until)
+	}
+	if filter.BeforeEventID != 0 {
+		addCondition("event_id < ?", filter.BeforeEventID)
+	}
+
+	query := `
+		SELECT event_id, event_type, user_id, email, ip_address, user_agent, outcome, reason, created_at
+		FROM auth_events
+	`
+	if len(conditions) > 0 {
+		query += "WHERE " + strings.Join(conditions, " AND ") + "\n"
+	}
+	args = append(args, filter.Limit)
+	query += "ORDER BY event_id DESC LIMIT $" + strconv.Itoa(len(args))
+
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []*models.AuthEvent
+	for rows.Next() {
+		event := &models.AuthEvent{}
+		err := rows.Scan(
+			&event.EventID,
+			&event.EventType,
+			&event.UserID,
+			&event.Email,
+			&event.IPAddress,
+			&event.UserAgent,
+			&event.Outcome,
+			&event.Reason,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
@@ -0,0 +1,86 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var ErrInvalidVerificationToken = errors.New("invalid or expired verification token")
+
+// CreateVerificationToken stores a new email verification token and
+// invalidates any outstanding ones for the same user.
+func (r *userRepository) CreateVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	invalidateQuery := `
+		UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+	_, err = tx.ExecContext(ctx, invalidateQuery, token.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO email_verification_tokens (user_id, email, token_hash, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING token_id
+	`
+	err = tx.QueryRowContext(ctx, insertQuery, token.UserID, token.Email, token.TokenHash, token.ExpiresAt).Scan(&token.TokenID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// VerifyEmail consumes the verification token identified by tokenHash and
+// marks the user's email as verified, provided the address has not changed
+// since the token was issued.
+func (r *userRepository) VerifyEmail(ctx context.Context, tokenHash string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	consumeQuery := `
+		UPDATE email_verification_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id, email
+	`
+	var userID int
+	var email string
+	err = tx.QueryRowContext(ctx, consumeQuery, tokenHash).Scan(&userID, &email)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return ErrInvalidVerificationToken
+		}
+		return err
+	}
+
+	updateQuery := `
+		UPDATE users SET email_verified = TRUE WHERE user_id = $1 AND email = $2
+	`
+	result, err := tx.ExecContext(ctx, updateQuery, userID, email)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		tx.Rollback()
+		return ErrInvalidVerificationToken
+	}
+
+	return tx.Commit()
+}
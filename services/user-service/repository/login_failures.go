@@ -0,0 +1,71 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// IsLoginLocked reports whether any of the given failure keys is currently
+// locked out.
+func (r *userRepository) IsLoginLocked(ctx context.Context, keys []string) (bool, error) {
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM login_failures
+			WHERE failure_key = ANY($1) AND locked_until > NOW()
+		)
+	`
+
+	var locked bool
+	err := r.db.QueryRowContext(ctx, query, pq.Array(keys)).Scan(&locked)
+	return locked, err
+}
+
+// RecordLoginFailure increments the failed attempt counter for key and returns
+// the new count along with the number of times key has been locked out. The
+// attempt counter starts over when the previous failure is older than window,
+// and the lockout count when it is older than quietPeriod.
+func (r *userRepository) RecordLoginFailure(ctx context.Context, key string, window, quietPeriod time.Duration) (int, int, error) {
+	query := `
+		INSERT INTO login_failures (failure_key, failed_attempts, last_failed_at)
+		VALUES ($1, 1, NOW())
+		ON CONFLICT (failure_key) DO UPDATE SET
+			failed_attempts = CASE
+				WHEN login_failures.last_failed_at < NOW() - make_interval(secs => $2) THEN 1
+				ELSE login_failures.failed_attempts + 1
+			END,
+			lockout_count = CASE
+				WHEN login_failures.last_failed_at < NOW() - make_interval(secs => $3) THEN 0
+				ELSE login_failures.lockout_count
+			END,
+			last_failed_at = NOW()
+		RETURNING failed_attempts, lockout_count
+	`
+
+	var attempts, lockouts int
+	err := r.db.QueryRowContext(ctx, query, key, window.Seconds(), quietPeriod.Seconds()).Scan(&attempts, &lockouts)
+	return attempts, lockouts, err
+}
+
+// LockLogin locks key out for duration and counts the lockout.
+func (r *userRepository) LockLogin(ctx context.Context, key string, duration time.Duration) error {
+	query := `
+		UPDATE login_failures SET
+			locked_until = NOW() + make_interval(secs => $2),
+			lockout_count = lockout_count + 1
+		WHERE failure_key = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key, duration.Seconds())
+	return err
+}
+
+func (r *userRepository) ClearLoginFailures(ctx context.Context, key string) error {
+	query := `
+		DELETE FROM login_failures WHERE failure_key = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, key)
+	return err
+}
@@ -0,0 +1,122 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/lib/pq"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var ErrInvalidOIDCState = errors.New("invalid or expired login state")
+
+func (r *userRepository) CreateOIDCLoginState(ctx context.Context, state *models.OIDCLoginState) error {
+	query := `
+		INSERT INTO oidc_login_states (state_hash, nonce, code_verifier, expires_at)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, state.StateHash, state.Nonce, state.CodeVerifier, state.ExpiresAt)
+	return err
+}
+
+// ConsumeOIDCLoginState deletes a pending login state and returns it, so each
+// state can complete at most one login.
+func (r *userRepository) ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error) {
+	query := `
+		DELETE FROM oidc_login_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING nonce, code_verifier, expires_at
+	`
+
+	state := &models.OIDCLoginState{StateHash: stateHash}
+	err := r.db.QueryRowContext(ctx, query, stateHash).Scan(&state.Nonce, &state.CodeVerifier, &state.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, ErrInvalidOIDCState
+	}
+	return state, err
+}
+
+func (r *userRepository) GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE user_id = (SELECT user_id FROM user_identities WHERE issuer = $1 AND subject = $2)
+	`
+
+	return scanUser(r.db.QueryRowContext(ctx, query, issuer, subject))
+}
+
+func (r *userRepository) LinkIdentity(ctx context.Context, identity *models.UserIdentity) error {
+	query := `
+		INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, identity.UserID, identity.Issuer, identity.Subject, identity.Email)
+	return err
+}
+
+// CreateUserWithIdentity creates a user for a first-time OIDC login and links
+// the external identity to it.
+func (r *userRepository) CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	userQuery := `
+		INSERT INTO users (username, email, password_hash, email_verified)
+		VALUES ($1, $2, $3, $4)
+		RETURNING user_id, role
+	`
+	err = tx.QueryRowContext(ctx, userQuery, user.Username, user.Email, user.PasswordHash, user.EmailVerified).Scan(&user.UserID, &user.Role)
+	if err != nil {
+		tx.Rollback()
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	identityQuery := `
+		INSERT INTO user_identities (user_id, issuer, subject, email)
+		VALUES ($1, $2, $3, $4)
+	`
+	_, err = tx.ExecContext(ctx, identityQuery, user.UserID, identity.Issuer, identity.Subject, identity.Email)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	identity.UserID = user.UserID
+	return tx.Commit()
+}
+
+func (r *userRepository) ListIdentities(ctx context.Context, userID int) ([]*models.UserIdentity, error) {
+	query := `
+		SELECT issuer, subject, email, created_at
+		FROM user_identities
+		WHERE user_id = $1
+		ORDER BY created_at
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var identities []*models.UserIdentity
+	for rows.Next() {
+		identity := &models.UserIdentity{UserID: userID}
+		err := rows.Scan(&identity.Issuer, &identity.Subject, &identity.Email, &identity.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		identities = append(identities, identity)
+	}
+
+	return identities, rows.Err()
+}
@@ -0,0 +1,107 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var ErrInvalidResetToken = errors.New("invalid or expired reset token")
+
+// CreatePasswordResetToken stores a new reset token and invalidates any
+// previously issued tokens for the same user, so only the latest link works.
+func (r *userRepository) CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	invalidateQuery := `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE user_id = $1 AND used_at IS NULL
+	`
+	_, err = tx.ExecContext(ctx, invalidateQuery, token.UserID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	insertQuery := `
+		INSERT INTO password_reset_tokens (user_id, token_hash, expires_at)
+		VALUES ($1, $2, $3)
+		RETURNING token_id
+	`
+	err = tx.QueryRowContext(ctx, insertQuery, token.UserID, token.TokenHash, token.ExpiresAt).Scan(&token.TokenID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// GetUserByResetToken returns the user a usable reset token belongs to,
+// without consuming the token.
+func (r *userRepository) GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE user_id = (
+			SELECT user_id FROM password_reset_tokens
+			WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		)
+	`
+
+	user, err := scanUser(r.db.QueryRowContext(ctx, query, tokenHash))
+	if err != nil && err.Error() == "user not found" {
+		return nil, ErrInvalidResetToken
+	}
+	return user, err
+}
+
+// ResetPassword consumes the reset token identified by tokenHash and sets the
+// owning user's password hash, revoking their existing tokens. The reset token
+// is marked used in the same statement that checks it, so concurrent requests
+// cannot redeem it twice. It returns the ID of the user whose password was
+// reset.
+func (r *userRepository) ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	consumeQuery := `
+		UPDATE password_reset_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING user_id
+	`
+	var userID int
+	err = tx.QueryRowContext(ctx, consumeQuery, tokenHash).Scan(&userID)
+	if err != nil {
+		tx.Rollback()
+		if err == sql.ErrNoRows {
+			return 0, ErrInvalidResetToken
+		}
+		return 0, err
+	}
+
+	updateQuery := `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1
+		WHERE user_id = $2
+	`
+	_, err = tx.ExecContext(ctx, updateQuery, passwordHash, userID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, revokeAllSessionsQuery, userID)
+	if err != nil {
+		tx.Rollback()
+		return 0, err
+	}
+
+	return userID, tx.Commit()
+}
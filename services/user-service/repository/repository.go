@@ -4,14 +4,66 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"time"
 
+	"github.com/lib/pq"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
 )
 
+var ErrEmailTaken = errors.New("email already in use")
+
 type UserRepository interface {
 	CreateUser(ctx context.Context, user *models.User) error
 	GetUserByEmail(ctx context.Context, email string) (*models.User, error)
 	GetUserByID(ctx context.Context, userID int) (*models.User, error)
+	CreatePasswordResetToken(ctx context.Context, token *models.PasswordResetToken) error
+	GetUserByResetToken(ctx context.Context, tokenHash string) (*models.User, error)
+	ResetPassword(ctx context.Context, tokenHash, passwordHash string) (int, error)
+	CreateVerificationToken(ctx context.Context, token *models.EmailVerificationToken) error
+	VerifyEmail(ctx context.Context, tokenHash string) error
+	UpdateUser(ctx context.Context, user *models.User) error
+	UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error)
+	IsLoginLocked(ctx context.Context, keys []string) (bool, error)
+	RecordLoginFailure(ctx context.Context, key string, window, quietPeriod time.Duration) (int, int, error)
+	LockLogin(ctx context.Context, key string, duration time.Duration) error
+	ClearLoginFailures(ctx context.Context, key string) error
+	SaveTOTPSecret(ctx context.Context, userID int, encryptedSecret string) error
+	GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error)
+	EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error
+	DisableTOTP(ctx context.Context, userID int) error
+	UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error)
+	UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error)
+	CreateLoginChallenge(ctx context.Context, challenge *models.LoginChallenge) error
+	GetLoginChallenge(ctx context.Context, challengeHash string, maxAttempts int) (int, error)
+	RecordChallengeFailure(ctx context.Context, challengeHash string) error
+	ConsumeLoginChallenge(ctx context.Context, challengeHash string) error
+	CreateAddress(ctx context.Context, address *models.Address) error
+	GetAddress(ctx context.Context, userID, addressID int) (*models.Address, error)
+	ListAddresses(ctx context.Context, userID int) ([]*models.Address, error)
+	UpdateAddress(ctx context.Context, address *models.Address) error
+	DeleteAddress(ctx context.Context, userID, addressID int) error
+	DeleteUser(ctx context.Context, userID int) error
+	CreateOIDCLoginState(ctx context.Context, state *models.OIDCLoginState) error
+	ConsumeOIDCLoginState(ctx context.Context, stateHash string) (*models.OIDCLoginState, error)
+	GetUserByIdentity(ctx context.Context, issuer, subject string) (*models.User, error)
+	LinkIdentity(ctx context.Context, identity *models.UserIdentity) error
+	CreateUserWithIdentity(ctx context.Context, user *models.User, identity *models.UserIdentity) error
+	ListIdentities(ctx context.Context, userID int) ([]*models.UserIdentity, error)
+	CreateAPIKey(ctx context.Context, key *models.APIKey) error
+	ListAPIKeys(ctx context.Context, userID int) ([]*models.APIKey, error)
+	RevokeAPIKey(ctx context.Context, keyID int) (int, error)
+	GetActiveAPIKey(ctx context.Context, keyHash string) (*models.APIKey, error)
+	CreateSession(ctx context.Context, session *models.Session) error
+	TouchSession(ctx context.Context, sessionID, userID int) error
+	ListSessions(ctx context.Context, userID int) ([]*models.Session, error)
+	RevokeSession(ctx context.Context, userID, sessionID int) error
+	RevokeAllSessions(ctx context.Context, userID int) error
+	SearchUsers(ctx context.Context, query string, afterUserID, limit int) ([]*models.User, error)
+	SetUserDisabled(ctx context.Context, userID int, disabled bool, action *models.AdminAction) error
+	RecordAdminAction(ctx context.Context, action *models.AdminAction) error
+	ListAdminActions(ctx context.Context, targetUserID int) ([]*models.AdminAction, error)
+	RecordAuthEvent(ctx context.Context, event *models.AuthEvent) error
+	ListAuthEvents(ctx context.Context, filter models.AuthEventFilter) ([]*models.AuthEvent, error)
 }
 
 type userRepository struct {
@@ -26,26 +78,32 @@ func (r *userRepository) CreateUser(ctx context.Context, user *models.User) erro
 	query := `
 		INSERT INTO users (username, email, password_hash)
 		VALUES ($1, $2, $3)
-		RETURNING user_id
+		RETURNING user_id, role
 	`
 
-	err := r.db.QueryRowContext(ctx, query, user.Username, user.Email, user.PasswordHash).Scan(&user.UserID)
+	err := r.db.QueryRowContext(ctx, query, user.Username, user.Email, user.PasswordHash).Scan(&user.UserID, &user.Role)
 	return err
 }
 
-func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
-	query := `
-		SELECT user_id, username, email, password_hash
-		FROM users
-		WHERE email = $1
-	`
+// userColumns lists the columns scanned by scanUser, in order.
+const userColumns = `user_id, username, email, password_hash, email_verified, token_version, role, totp_enabled, disabled_at IS NOT NULL`
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
 
+func scanUser(row rowScanner) (*models.User, error) {
 	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, email).Scan(
+	err := row.Scan(
 		&user.UserID,
 		&user.Username,
 		&user.Email,
 		&user.PasswordHash,
+		&user.EmailVerified,
+		&user.TokenVersion,
+		&user.Role,
+		&user.TOTPEnabled,
+		&user.Disabled,
 	)
 
 	if err == sql.ErrNoRows {
@@ -54,22 +112,109 @@ func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*mod
 	return user, err
 }
 
+func (r *userRepository) GetUserByEmail(ctx context.Context, email string) (*models.User, error) {
+	query := `
+		SELECT ` + userColumns + `
+		FROM users
+		WHERE email = $1
+	`
+
+	return scanUser(r.db.QueryRowContext(ctx, query, email))
+}
+
 func (r *userRepository) GetUserByID(ctx context.Context, userID int) (*models.User, error) {
 	query := `
-		SELECT user_id, username, email
+		SELECT ` + userColumns + `
 		FROM users
 		WHERE user_id = $1
 	`
 
-	user := &models.User{}
-	err := r.db.QueryRowContext(ctx, query, userID).Scan(
-		&user.UserID,
-		&user.Username,
-		&user.Email,
-	)
+	return scanUser(r.db.QueryRowContext(ctx, query, userID))
+}
+
+func (r *userRepository) UpdateUser(ctx context.Context, user *models.User) error {
+	query := `
+		UPDATE users SET username = $1, email = $2, email_verified = $3
+		WHERE user_id = $4
+	`
 
+	result, err := r.db.ExecContext(ctx, query, user.Username, user.Email, user.EmailVerified, user.UserID)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			return ErrEmailTaken
+		}
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return nil
+}
+
+// UpdatePassword sets a new password hash, bumps the user's token version and
+// revokes their sessions, so that every token issued before the change stops
+// working. It returns the new token version.
+func (r *userRepository) UpdatePassword(ctx context.Context, userID int, passwordHash string) (int, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE users SET password_hash = $1, token_version = token_version + 1
+		WHERE user_id = $2
+		RETURNING token_version
+	`
+
+	var tokenVersion int
+	err = tx.QueryRowContext(ctx, query, passwordHash, userID).Scan(&tokenVersion)
 	if err == sql.ErrNoRows {
-		return nil, errors.New("user not found")
+		return 0, errors.New("user not found")
 	}
-	return user, err
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = tx.ExecContext(ctx, revokeAllSessionsQuery, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return tokenVersion, tx.Commit()
+}
+
+// DeleteUser removes the user. Tokens, two-factor credentials and addresses
+// are removed along with it by ON DELETE CASCADE. Auth events outlive the
+// account, so the personal data in them is erased in the same transaction.
+func (r *userRepository) DeleteUser(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.ExecContext(ctx, `SELECT erase_user_auth_events($1)`, userID)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM users WHERE user_id = $1
+	`
+
+	result, err := tx.ExecContext(ctx, query, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return errors.New("user not found")
+	}
+
+	return tx.Commit()
 }
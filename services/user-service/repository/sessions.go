@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var (
+	ErrSessionNotFound = errors.New("session not found")
+	ErrSessionRevoked  = errors.New("session has been revoked")
+)
+
+func (r *userRepository) CreateSession(ctx context.Context, session *models.Session) error {
+	query := `
+		INSERT INTO sessions (user_id, ip_address, user_agent, device, expires_at)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING session_id, created_at, last_seen_at
+	`
+
+	return r.db.QueryRowContext(ctx, query,
+		session.UserID,
+		session.IPAddress,
+		session.UserAgent,
+		session.Device,
+		session.ExpiresAt,
+	).Scan(&session.SessionID, &session.CreatedAt, &session.LastSeenAt)
+}
+
+// TouchSession records activity on a session. Revoked, expired and unknown
+// sessions are reported as ErrSessionRevoked.
+func (r *userRepository) TouchSession(ctx context.Context, sessionID, userID int) error {
+	query := `
+		UPDATE sessions SET last_seen_at = NOW()
+		WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL AND expires_at > NOW()
+		RETURNING session_id
+	`
+
+	err := r.db.QueryRowContext(ctx, query, sessionID, userID).Scan(&sessionID)
+	if err == sql.ErrNoRows {
+		return ErrSessionRevoked
+	}
+	return err
+}
+
+// ListSessions returns the user's active sessions, most recently used first.
+func (r *userRepository) ListSessions(ctx context.Context, userID int) ([]*models.Session, error) {
+	query := `
+		SELECT session_id, user_id, ip_address, user_agent, device, created_at, last_seen_at, expires_at
+		FROM sessions
+		WHERE user_id = $1 AND revoked_at IS NULL AND expires_at > NOW()
+		ORDER BY last_seen_at DESC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sessions []*models.Session
+	for rows.Next() {
+		session := &models.Session{}
+		err := rows.Scan(
+			&session.SessionID,
+			&session.UserID,
+			&session.IPAddress,
+			&session.UserAgent,
+			&session.Device,
+			&session.CreatedAt,
+			&session.LastSeenAt,
+			&session.ExpiresAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, rows.Err()
+}
+
+func (r *userRepository) RevokeSession(ctx context.Context, userID, sessionID int) error {
+	query := `
+		UPDATE sessions SET revoked_at = NOW()
+		WHERE session_id = $1 AND user_id = $2 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, sessionID, userID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrSessionNotFound
+	}
+	return nil
+}
+
+// revokeAllSessionsQuery revokes every live session of user $1.
+const revokeAllSessionsQuery = `
+	UPDATE sessions SET revoked_at = NOW()
+	WHERE user_id = $1 AND revoked_at IS NULL
+`
+
+func (r *userRepository) RevokeAllSessions(ctx context.Context, userID int) error {
+	_, err := r.db.ExecContext(ctx, revokeAllSessionsQuery, userID)
+	return err
+}
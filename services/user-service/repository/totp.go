@@ -0,0 +1,193 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
+)
+
+var ErrInvalidLoginChallenge = errors.New("invalid or expired login challenge")
+
+// SaveTOTPSecret stores a pending secret for the user, replacing any earlier
+// pending enrolment.
+func (r *userRepository) SaveTOTPSecret(ctx context.Context, userID int, encryptedSecret string) error {
+	query := `
+		INSERT INTO totp_credentials (user_id, encrypted_secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_id) DO UPDATE SET
+			encrypted_secret = EXCLUDED.encrypted_secret,
+			confirmed_at = NULL,
+			last_used_step = 0,
+			created_at = NOW()
+	`
+
+	_, err := r.db.ExecContext(ctx, query, userID, encryptedSecret)
+	return err
+}
+
+func (r *userRepository) GetTOTPCredential(ctx context.Context, userID int) (*models.TOTPCredential, error) {
+	query := `
+		SELECT user_id, encrypted_secret, confirmed_at IS NOT NULL, last_used_step
+		FROM totp_credentials
+		WHERE user_id = $1
+	`
+
+	credential := &models.TOTPCredential{}
+	err := r.db.QueryRowContext(ctx, query, userID).Scan(
+		&credential.UserID,
+		&credential.EncryptedSecret,
+		&credential.Confirmed,
+		&credential.LastUsedStep,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, errors.New("totp not enrolled")
+	}
+	return credential, err
+}
+
+// EnableTOTP confirms the pending secret and replaces the user's recovery
+// codes.
+func (r *userRepository) EnableTOTP(ctx context.Context, userID int, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := []string{
+		`UPDATE totp_credentials SET confirmed_at = NOW() WHERE user_id = $1`,
+		`UPDATE users SET totp_enabled = TRUE WHERE user_id = $1`,
+		`DELETE FROM totp_recovery_codes WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	codeQuery := `
+		INSERT INTO totp_recovery_codes (user_id, code_hash)
+		VALUES ($1, $2)
+	`
+	for _, codeHash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, codeQuery, userID, codeHash); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *userRepository) DisableTOTP(ctx context.Context, userID int) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	queries := []string{
+		`DELETE FROM totp_credentials WHERE user_id = $1`,
+		`DELETE FROM totp_recovery_codes WHERE user_id = $1`,
+		`UPDATE users SET totp_enabled = FALSE WHERE user_id = $1`,
+	}
+	for _, query := range queries {
+		if _, err := tx.ExecContext(ctx, query, userID); err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UseTOTPStep records step as the last one a code was accepted for. It returns
+// false if a code for this or a later step was already used, which stops a
+// captured code from being replayed.
+func (r *userRepository) UseTOTPStep(ctx context.Context, userID int, step int64) (bool, error) {
+	query := `
+		UPDATE totp_credentials SET last_used_step = $2
+		WHERE user_id = $1 AND last_used_step < $2
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, step)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected == 1, nil
+}
+
+// UseRecoveryCode marks the matching unused recovery code as used. It returns
+// false if no such code exists.
+func (r *userRepository) UseRecoveryCode(ctx context.Context, userID int, codeHash string) (bool, error) {
+	query := `
+		UPDATE totp_recovery_codes SET used_at = NOW()
+		WHERE user_id = $1 AND code_hash = $2 AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, userID, codeHash)
+	if err != nil {
+		return false, err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	return rowsAffected > 0, nil
+}
+
+func (r *userRepository) CreateLoginChallenge(ctx context.Context, challenge *models.LoginChallenge) error {
+	query := `
+		INSERT INTO login_challenges (challenge_hash, user_id, expires_at)
+		VALUES ($1, $2, $3)
+	`
+
+	_, err := r.db.ExecContext(ctx, query, challenge.ChallengeHash, challenge.UserID, challenge.ExpiresAt)
+	return err
+}
+
+// GetLoginChallenge returns the user a pending challenge was issued to. Used,
+// expired and exhausted challenges are reported as ErrInvalidLoginChallenge.
+func (r *userRepository) GetLoginChallenge(ctx context.Context, challengeHash string, maxAttempts int) (int, error) {
+	query := `
+		SELECT user_id FROM login_challenges
+		WHERE challenge_hash = $1 AND used_at IS NULL AND expires_at > NOW() AND failed_attempts < $2
+	`
+
+	var userID int
+	err := r.db.QueryRowContext(ctx, query, challengeHash, maxAttempts).Scan(&userID)
+	if err == sql.ErrNoRows {
+		return 0, ErrInvalidLoginChallenge
+	}
+	return userID, err
+}
+
+func (r *userRepository) RecordChallengeFailure(ctx context.Context, challengeHash string) error {
+	query := `
+		UPDATE login_challenges SET failed_attempts = failed_attempts + 1
+		WHERE challenge_hash = $1
+	`
+
+	_, err := r.db.ExecContext(ctx, query, challengeHash)
+	return err
+}
+
+func (r *userRepository) ConsumeLoginChallenge(ctx context.Context, challengeHash string) error {
+	query := `
+		UPDATE login_challenges SET used_at = NOW()
+		WHERE challenge_hash = $1 AND used_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, challengeHash)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return ErrInvalidLoginChallenge
+	}
+	return nil
+}
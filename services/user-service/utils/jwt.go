@@ -1,21 +1,42 @@
 package utils
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/metal-oopa/EcomMicroservices/services/user-service/auth"
+	"github.com/metal-oopa/EcomMicroservices/services/user-service/models"
 )
 
-func GenerateJWT(userID int, secretKey string, duration time.Duration) (string, error) {
-	claims := &auth.AuthClaims{
+// GenerateJWT issues an access token for the given session.
+func GenerateJWT(user *models.User, sessionID int, secretKey string, duration time.Duration) (string, error) {
+	return signJWT(newClaims(user, sessionID, duration), secretKey)
+}
+
+// GenerateImpersonationJWT issues an access token for the given session that
+// records the admin acting as the user.
+func GenerateImpersonationJWT(user *models.User, impersonatorID, sessionID int, secretKey string, duration time.Duration) (string, error) {
+	claims := newClaims(user, sessionID, duration)
+	claims.ImpersonatorID = impersonatorID
+	return signJWT(claims, secretKey)
+}
+
+func newClaims(user *models.User, sessionID int, duration time.Duration) *auth.AuthClaims {
+	return &auth.AuthClaims{
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        strconv.Itoa(sessionID),
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(duration)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 		},
-		UserID: userID,
+		UserID:        user.UserID,
+		EmailVerified: user.EmailVerified,
+		TokenVersion:  user.TokenVersion,
+		Role:          user.Role,
 	}
+}
 
+func signJWT(claims *auth.AuthClaims, secretKey string) (string, error) {
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
 	return token.SignedString([]byte(secretKey))
 }